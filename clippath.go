@@ -3,16 +3,15 @@ package svg
 import (
 	"fmt"
 	"strings"
-	"sync/atomic"
 )
 
-// Global counter for unique clipPath IDs across all renderers
-// This ensures thread-safe unique ID generation
-var clipPathCounter int64
-
-// ClipPathManager manages SVG clipPath definitions and generates unique IDs
+// ClipPathManager manages SVG clipPath definitions and generates unique IDs.
+// Adding the same clip geometry again returns the ID of the existing clipPath.
 type ClipPathManager struct {
-	paths []ClipPath
+	paths  []ClipPath
+	byPath map[string]string // clip geometry -> ID
+	format NumberFormat
+	ids    *IDAllocator
 }
 
 // ClipPath represents an SVG clipPath definition
@@ -21,80 +20,99 @@ type ClipPath struct {
 	Path string // SVG path or shapes to use for clipping
 }
 
-// NewClipPathManager creates a new clipPath manager
+// NewClipPathManager creates a new clipPath manager with its own
+// sequential IDs ("clip-1", "clip-2", ...)
 func NewClipPathManager() *ClipPathManager {
 	return &ClipPathManager{
-		paths: make([]ClipPath, 0),
+		paths:  make([]ClipPath, 0),
+		byPath: make(map[string]string),
+		ids:    NewIDAllocator("", IDSequential),
 	}
 }
 
 // GenerateID generates a unique clipPath ID
 func (m *ClipPathManager) GenerateID() string {
-	id := atomic.AddInt64(&clipPathCounter, 1)
-	return fmt.Sprintf("clip-%d", id)
+	return m.allocator().Next("clip")
 }
 
-// AddRoundedRect adds a rounded rectangle clipPath and returns its ID
-func (m *ClipPathManager) AddRoundedRect(x, y, width, height, radius float64) string {
-	id := m.GenerateID()
-	path := fmt.Sprintf(`<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" rx="%.2f" ry="%.2f"/>`,
-		x, y, width, height, radius, radius)
+// SetIDAllocator sets the allocator used for subsequently added clipPaths.
+// Share one allocator between managers that write into the same document.
+func (m *ClipPathManager) SetIDAllocator(ids *IDAllocator) {
+	m.ids = ids
+}
 
-	m.paths = append(m.paths, ClipPath{
-		ID:   id,
-		Path: path,
-	})
+func (m *ClipPathManager) allocator() *IDAllocator {
+	if m.ids == nil {
+		m.ids = NewIDAllocator("", IDSequential)
+	}
+	return m.ids
+}
 
-	return id
+// SetNumberFormat sets how coordinates of subsequently added shapes are written
+func (m *ClipPathManager) SetNumberFormat(format NumberFormat) {
+	m.format = format
+}
+
+// AddRoundedRect adds a rounded rectangle clipPath and returns its ID
+func (m *ClipPathManager) AddRoundedRect(x, y, width, height, radius float64) string {
+	n := m.format
+	path := fmt.Sprintf(`<rect x="%s" y="%s" width="%s" height="%s" rx="%s" ry="%s"/>`,
+		n.Format(x), n.Format(y), n.Format(width), n.Format(height), n.Format(radius), n.Format(radius))
+	return m.add(path)
 }
 
 // AddRect adds a rectangle clipPath and returns its ID
 func (m *ClipPathManager) AddRect(x, y, width, height float64) string {
-	id := m.GenerateID()
-	path := fmt.Sprintf(`<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f"/>`,
-		x, y, width, height)
-
-	m.paths = append(m.paths, ClipPath{
-		ID:   id,
-		Path: path,
-	})
-
-	return id
+	n := m.format
+	path := fmt.Sprintf(`<rect x="%s" y="%s" width="%s" height="%s"/>`,
+		n.Format(x), n.Format(y), n.Format(width), n.Format(height))
+	return m.add(path)
 }
 
 // AddCircle adds a circle clipPath and returns its ID
 func (m *ClipPathManager) AddCircle(cx, cy, r float64) string {
-	id := m.GenerateID()
-	path := fmt.Sprintf(`<circle cx="%.2f" cy="%.2f" r="%.2f"/>`,
-		cx, cy, r)
-
-	m.paths = append(m.paths, ClipPath{
-		ID:   id,
-		Path: path,
-	})
-
-	return id
+	n := m.format
+	path := fmt.Sprintf(`<circle cx="%s" cy="%s" r="%s"/>`,
+		n.Format(cx), n.Format(cy), n.Format(r))
+	return m.add(path)
 }
 
 // AddCustom adds a custom clipPath and returns its ID
 func (m *ClipPathManager) AddCustom(pathContent string) string {
-	id := m.GenerateID()
+	return m.add(pathContent)
+}
+
+// add registers clip geometry, reusing the clipPath of identical geometry
+func (m *ClipPathManager) add(path string) string {
+	if id, ok := m.byPath[path]; ok {
+		return id
+	}
+	id := m.allocator().ForContent("clip", path)
 	m.paths = append(m.paths, ClipPath{
 		ID:   id,
-		Path: pathContent,
+		Path: path,
 	})
+	if m.byPath == nil {
+		m.byPath = make(map[string]string)
+	}
+	m.byPath[path] = id
 	return id
 }
 
 // ToSVGDefs converts all clipPaths to SVG <defs> content
 func (m *ClipPathManager) ToSVGDefs() string {
-	if len(m.paths) == 0 {
+	return clipPathDefs(m.paths)
+}
+
+// clipPathDefs converts clipPaths to SVG <defs> content
+func clipPathDefs(paths []ClipPath) string {
+	if len(paths) == 0 {
 		return ""
 	}
 
 	var b strings.Builder
 
-	for _, cp := range m.paths {
+	for _, cp := range paths {
 		b.WriteString(fmt.Sprintf(`<clipPath id="%s">%s</clipPath>`, cp.ID, cp.Path))
 		b.WriteString("\n    ")
 	}
@@ -0,0 +1,202 @@
+package svg
+
+import "strings"
+
+// PatternDef represents a pattern definition
+type PatternDef struct {
+	ID                  string
+	X, Y                float64
+	Width, Height       float64       // Size of one pattern tile
+	Units               GradientUnits // patternUnits: userSpaceOnUse or objectBoundingBox
+	ContentUnits        GradientUnits // patternContentUnits
+	ViewBox             string
+	PreserveAspectRatio string
+	Transform           string       // patternTransform
+	Content             string       // SVG content of one tile
+	NumberFormat        NumberFormat // How the tile position and size are written
+}
+
+// FilterDef represents a filter definition. The filter region is given as
+// lengths or percentages, e.g. "-10%".
+type FilterDef struct {
+	ID                 string
+	X, Y               string
+	Width, Height      string
+	Units              GradientUnits // filterUnits
+	PrimitiveUnits     GradientUnits // primitiveUnits
+	ColorInterpolation string        // color-interpolation-filters, e.g. "sRGB"
+	Content            string        // Filter primitives such as <feGaussianBlur>
+}
+
+// SymbolDef represents a symbol definition, instantiated with <use>
+type SymbolDef struct {
+	ID                  string
+	ViewBox             string
+	PreserveAspectRatio string
+	Content             string // SVG content of the symbol
+}
+
+// Pattern creates a pattern definition (for use in <defs>)
+func Pattern(def PatternDef) string {
+	return PatternElement(def).String()
+}
+
+// Filter creates a filter definition (for use in <defs>)
+func Filter(def FilterDef) string {
+	return FilterElement(def).String()
+}
+
+// Symbol creates a symbol definition (for use in <defs>)
+func Symbol(def SymbolDef) string {
+	return SymbolElement(def).String()
+}
+
+// Use creates a <use> element instantiating a symbol. ref may be an ID,
+// "#id" or a url(#id) reference as returned by Defs.
+func Use(ref string, x, y, width, height float64, style Style) string {
+	return UseElement(ref, x, y, width, height, style).String()
+}
+
+// refID returns the ID of a local reference such as "#id" or "url(#id)"
+func refID(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if strings.HasPrefix(ref, "url(") && strings.HasSuffix(ref, ")") {
+		ref = strings.Trim(strings.TrimSpace(ref[4:len(ref)-1]), `"'`)
+	}
+	return strings.TrimPrefix(ref, "#")
+}
+
+// Defs is a registry of the reusable definitions of a document: gradients,
+// markers, clipPaths, patterns, filters and symbols. Each Add method
+// returns a url(#id) reference. Structurally identical definitions are
+// stored once, so adding the same gradient twice returns the same reference.
+// IDs are allocated by the registry; the ID field of a definition is ignored.
+//
+// A Renderer writes its registry into the <defs> of every document it
+// renders, including definitions added by StyleNodeFunc or BoxStyleFunc
+// while rendering.
+type Defs struct {
+	ids       *IDAllocator
+	clipPaths *ClipPathManager
+	entries   []*Element
+	byKey     map[string]string // definition without its ID -> ID
+}
+
+// NewDefs creates an empty registry that allocates IDs with ids. If ids is
+// nil, IDs are sequential without a prefix.
+func NewDefs(ids *IDAllocator) *Defs {
+	if ids == nil {
+		ids = NewIDAllocator("", IDSequential)
+	}
+	clipPaths := NewClipPathManager()
+	clipPaths.SetIDAllocator(ids)
+	return &Defs{
+		ids:       ids,
+		clipPaths: clipPaths,
+		byKey:     make(map[string]string),
+	}
+}
+
+// IDs returns the allocator for the registry's IDs
+func (d *Defs) IDs() *IDAllocator {
+	return d.ids
+}
+
+// ClipPaths returns the clipPath manager of the registry. ClipPaths added
+// through either share IDs and deduplication.
+func (d *Defs) ClipPaths() *ClipPathManager {
+	return d.clipPaths
+}
+
+// AddLinearGradient registers a linear gradient
+func (d *Defs) AddLinearGradient(def LinearGradientDef) string {
+	def.ID = ""
+	return d.add("gradient", LinearGradientElement(def))
+}
+
+// AddRadialGradient registers a radial gradient
+func (d *Defs) AddRadialGradient(def RadialGradientDef) string {
+	def.ID = ""
+	return d.add("gradient", RadialGradientElement(def))
+}
+
+// AddMarker registers a marker. Its content is def.Content followed by children.
+func (d *Defs) AddMarker(def MarkerDef, children ...Node) string {
+	def.ID = ""
+	return d.add("marker", MarkerElement(def, children...))
+}
+
+// AddClipPath registers a clipPath with the given shapes
+func (d *Defs) AddClipPath(children ...Node) string {
+	var b strings.Builder
+	for _, child := range children {
+		child.writeXML(&xmlWriter{w: &b})
+	}
+	return URL(d.clipPaths.AddCustom(b.String()))
+}
+
+// AddPattern registers a pattern. Its content is def.Content followed by children.
+func (d *Defs) AddPattern(def PatternDef, children ...Node) string {
+	def.ID = ""
+	return d.add("pattern", PatternElement(def, children...))
+}
+
+// AddFilter registers a filter. Its primitives are def.Content followed by children.
+func (d *Defs) AddFilter(def FilterDef, children ...Node) string {
+	def.ID = ""
+	return d.add("filter", FilterElement(def, children...))
+}
+
+// AddSymbol registers a symbol. Its content is def.Content followed by
+// children. Instantiate it with Use or UseElement.
+func (d *Defs) AddSymbol(def SymbolDef, children ...Node) string {
+	def.ID = ""
+	return d.add("symbol", SymbolElement(def, children...))
+}
+
+// Len returns the number of registered definitions, including clipPaths
+func (d *Defs) Len() int {
+	return len(d.entries) + len(d.clipPaths.paths)
+}
+
+// Elements returns copies of the registered definitions, clipPaths last
+func (d *Defs) Elements() []*Element {
+	return d.elementsSince(defsMark{})
+}
+
+// add registers a definition built without an ID and returns its reference
+func (d *Defs) add(kind string, e *Element) string {
+	key := kind + "\x00" + e.String()
+	if id, ok := d.byKey[key]; ok {
+		return URL(id)
+	}
+	id := d.ids.ForContent(kind, key)
+	e.SetAttr("id", id)
+	d.entries = append(d.entries, e)
+	if d.byKey == nil {
+		d.byKey = make(map[string]string)
+	}
+	d.byKey[key] = id
+	return URL(id)
+}
+
+// defsMark records how many definitions of each list have been written
+type defsMark struct {
+	entries, clipPaths int
+}
+
+func (d *Defs) mark() defsMark {
+	return defsMark{len(d.entries), len(d.clipPaths.paths)}
+}
+
+// elementsSince returns the definitions registered after m, clipPaths last
+func (d *Defs) elementsSince(m defsMark) []*Element {
+	elems := make([]*Element, 0, d.Len()-m.entries-m.clipPaths)
+	for _, e := range d.entries[m.entries:] {
+		elems = append(elems, e.Clone())
+	}
+	for _, cp := range d.clipPaths.paths[m.clipPaths:] {
+		elems = append(elems, ClipPathElement(cp.ID, &Raw{XML: cp.Path}))
+	}
+	return elems
+}
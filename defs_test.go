@@ -0,0 +1,124 @@
+package svg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/SCKelemen/layout"
+)
+
+func TestDefs_Deduplicates(t *testing.T) {
+	defs := NewDefs(nil)
+	stops := []GradientStop{{Offset: "0%", Color: "red"}, {Offset: "100%", Color: "blue"}}
+
+	a := defs.AddLinearGradient(LinearGradientDef{ID: "ignored", X2: "100%", Stops: stops})
+	b := defs.AddLinearGradient(LinearGradientDef{ID: "other", X2: "100%", Stops: stops})
+	c := defs.AddLinearGradient(LinearGradientDef{Y2: "100%", Stops: stops})
+	if a != "url(#gradient-1)" || a != b {
+		t.Errorf("expected identical gradients to share url(#gradient-1), got %s and %s", a, b)
+	}
+	if c == a {
+		t.Error("different gradients should get different IDs")
+	}
+
+	arrow := MarkerDef{ViewBox: "0 0 10 10", RefX: 10, RefY: 5, Content: `<path d="M 0 0 L 10 5 L 0 10 Z"/>`}
+	if defs.AddMarker(arrow) != defs.AddMarker(arrow) {
+		t.Error("identical markers should share an ID")
+	}
+	if defs.AddClipPath(CircleElement(5, 5, 5, Style{})) != defs.AddClipPath(CircleElement(5, 5, 5, Style{})) {
+		t.Error("identical clipPaths should share an ID")
+	}
+	if got := defs.Len(); got != 4 {
+		t.Errorf("expected 4 definitions, got %d", got)
+	}
+}
+
+func TestDefs_AllKinds(t *testing.T) {
+	defs := NewDefs(NewIDAllocator("icon-", IDSequential))
+	refs := []string{
+		defs.AddRadialGradient(RadialGradientDef{R: "50%", Stops: []GradientStop{{Offset: "0", Color: "white"}}}),
+		defs.AddPattern(PatternDef{Width: 4, Height: 4, Units: GradientUnitsUserSpaceOnUse}, RectElement(0, 0, 2, 2, Style{Fill: "#ccc"})),
+		defs.AddFilter(FilterDef{X: "-10%", Width: "120%", Content: `<feGaussianBlur stdDeviation="2"/>`}),
+		defs.AddSymbol(SymbolDef{ViewBox: "0 0 24 24"}, PathElement("M 0 0 L 24 24", Style{})),
+		defs.AddClipPath(RectElement(0, 0, 10, 10, Style{})),
+	}
+	want := []string{"url(#icon-gradient-1)", "url(#icon-pattern-1)", "url(#icon-filter-1)", "url(#icon-symbol-1)", "url(#icon-clip-1)"}
+	for i := range want {
+		if refs[i] != want[i] {
+			t.Errorf("expected %s, got %s", want[i], refs[i])
+		}
+	}
+
+	var tags []string
+	for _, e := range defs.Elements() {
+		tags = append(tags, e.Tag+"#"+e.ID())
+	}
+	if got := strings.Join(tags, " "); got != "radialGradient#icon-gradient-1 pattern#icon-pattern-1 filter#icon-filter-1 symbol#icon-symbol-1 clipPath#icon-clip-1" {
+		t.Errorf("unexpected definitions: %s", got)
+	}
+
+	use := Use(refs[3], 0, 0, 24, 24, Style{Fill: "red"})
+	if use != `<use href="#icon-symbol-1" x="0.00" y="0.00" width="24.00" height="24.00" fill="red"/>` {
+		t.Errorf("unexpected use element: %s", use)
+	}
+}
+
+func TestDefs_ContentHashIDs(t *testing.T) {
+	def := PatternDef{Width: 8, Height: 8, Content: `<circle cx="4" cy="4" r="2"/>`}
+	a := NewDefs(NewIDAllocator("", IDContentHash)).AddPattern(def)
+	b := NewDefs(NewIDAllocator("", IDContentHash)).AddPattern(def)
+	if a != b || !strings.HasPrefix(a, "url(#pattern-") {
+		t.Errorf("expected identical content-hash IDs, got %s and %s", a, b)
+	}
+}
+
+func TestRenderer_EmitsDefs(t *testing.T) {
+	node := &layout.Node{Rect: layout.Rect{Width: 100, Height: 50}}
+	opts := DefaultOptions()
+	opts.StyleSheet = nil
+	renderer := NewRenderer(opts)
+
+	// Registered before rendering: written in the leading <defs>
+	renderer.Defs().AddFilter(FilterDef{Content: `<feDropShadow dx="1" dy="1"/>`})
+	// Registered while rendering: written after the content
+	renderer.options.StyleNodeFunc = func(n *layout.Node, depth int) Style {
+		fill := renderer.Defs().AddLinearGradient(LinearGradientDef{Stops: []GradientStop{{Offset: "0", Color: "red"}}})
+		return Style{Fill: fill}
+	}
+
+	svg := renderer.Render(node)
+	filter := strings.Index(svg, `<filter id="filter-1">`)
+	rect := strings.Index(svg, `<rect x="0.00" y="0.00" width="100.00" height="50.00" fill="url(#gradient-1)"`)
+	gradient := strings.Index(svg, `<linearGradient id="gradient-1">`)
+	if filter < 0 || rect < filter || gradient < rect {
+		t.Errorf("expected the filter before and the gradient after the content:\n%s", svg)
+	}
+	if strings.Count(svg, "<linearGradient") != 1 {
+		t.Errorf("expected the gradient once:\n%s", svg)
+	}
+
+	doc := renderer.RenderDocument(node)
+	defs := doc.Root.FindAll("defs")[0]
+	if defs.FindByID("filter-1") == nil || defs.FindByID("gradient-1") == nil {
+		t.Errorf("RenderDocument: expected both definitions in <defs>, got %s", defs)
+	}
+	// The document holds copies, so editing it leaves the registry alone
+	defs.FindByID("filter-1").SetAttr("x", "0")
+	if _, ok := renderer.Defs().Elements()[0].Attr("x"); ok {
+		t.Error("editing the document should not change the registry")
+	}
+}
+
+func TestRenderNodes_EmitsSharedDefs(t *testing.T) {
+	defs := NewDefs(nil)
+	fill := defs.AddPattern(PatternDef{Width: 4, Height: 4})
+
+	opts := DefaultOptions()
+	opts.Defs = defs
+	opts.StyleNodeFunc = func(*layout.Node, int) Style { return Style{Fill: fill} }
+	svg := RenderNodes([]*layout.Node{{Rect: layout.Rect{Width: 10, Height: 10}}}, opts)
+
+	if !strings.Contains(svg, `<pattern id="pattern-1" width="4.00" height="4.00"/>`) || !strings.Contains(svg, `fill="url(#pattern-1)"`) {
+		t.Errorf("expected the shared pattern and a reference to it:\n%s", svg)
+	}
+}
@@ -0,0 +1,454 @@
+package svg
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Node is a node in an SVG document tree: an *Element, *CharData, *Comment,
+// *ProcInst or *Raw.
+type Node interface {
+	writeXML(w *xmlWriter)
+}
+
+// Attr is an XML attribute. Name includes the namespace prefix, if any
+// (e.g., "xlink:href").
+type Attr struct {
+	Name  string
+	Value string
+}
+
+// Element is an SVG element with ordered attributes and child nodes
+type Element struct {
+	Tag      string
+	Attrs    []Attr
+	Children []Node
+	parent   *Element
+}
+
+// CharData is text content
+type CharData struct {
+	Data string
+}
+
+// Comment is an XML comment
+type Comment struct {
+	Data string
+}
+
+// ProcInst is an XML processing instruction, such as the XML declaration
+type ProcInst struct {
+	Target string
+	Inst   string
+}
+
+// Raw is pre-serialized markup that is written verbatim. It lets the output
+// of the string helpers (Marker, LinearGradient, ...) be placed in a tree.
+type Raw struct {
+	XML string
+}
+
+// Document is an SVG document: a root element and the nodes around it
+type Document struct {
+	// Prolog holds the nodes before the root element, such as the XML
+	// declaration and comments.
+	Prolog []Node
+	Root   *Element
+	// Epilog holds the nodes after the root element, such as comments.
+	Epilog []Node
+}
+
+// NewDocument creates a document with the given root element
+func NewDocument(root *Element) *Document {
+	return &Document{Root: root}
+}
+
+// XMLDeclaration returns the <?xml?> declaration processing instruction
+func XMLDeclaration() *ProcInst {
+	return &ProcInst{Target: "xml", Inst: `version="1.0" encoding="UTF-8"`}
+}
+
+// WriteTo serializes the document to w
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	xw := &xmlWriter{w: w}
+	for _, n := range d.Prolog {
+		n.writeXML(xw)
+		xw.writeString("\n")
+	}
+	if d.Root != nil {
+		d.Root.writeXML(xw)
+	}
+	for _, n := range d.Epilog {
+		xw.writeString("\n")
+		n.writeXML(xw)
+	}
+	return xw.n, xw.err
+}
+
+// String serializes the document to a string
+func (d *Document) String() string {
+	var b strings.Builder
+	d.WriteTo(&b)
+	return b.String()
+}
+
+// NewElement creates an element with the given tag and attributes
+func NewElement(tag string, attrs ...Attr) *Element {
+	return &Element{Tag: tag, Attrs: attrs}
+}
+
+// Parent returns the element containing e, or nil
+func (e *Element) Parent() *Element {
+	return e.parent
+}
+
+// Attr returns the value of the named attribute
+func (e *Element) Attr(name string) (string, bool) {
+	for _, attr := range e.Attrs {
+		if attr.Name == name {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// SetAttr sets the named attribute, keeping its position if it already exists
+func (e *Element) SetAttr(name, value string) *Element {
+	for i := range e.Attrs {
+		if e.Attrs[i].Name == name {
+			e.Attrs[i].Value = value
+			return e
+		}
+	}
+	e.Attrs = append(e.Attrs, Attr{Name: name, Value: value})
+	return e
+}
+
+// RemoveAttr removes the named attribute and reports whether it was present
+func (e *Element) RemoveAttr(name string) bool {
+	for i, attr := range e.Attrs {
+		if attr.Name == name {
+			e.Attrs = append(e.Attrs[:i], e.Attrs[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// ID returns the id attribute
+func (e *Element) ID() string {
+	id, _ := e.Attr("id")
+	return id
+}
+
+// SetStyle sets the presentation attributes of style
+func (e *Element) SetStyle(style Style) *Element {
+	for _, attr := range styleAttrs(style) {
+		e.SetAttr(attr.Name, attr.Value)
+	}
+	return e
+}
+
+// AppendChild adds nodes at the end of e's children. A node that is
+// already a child of e moves to the end. It panics if a node is e or one of
+// its ancestors.
+func (e *Element) AppendChild(nodes ...Node) *Element {
+	for _, n := range nodes {
+		e.adopt(n)
+		e.Children = append(e.Children, n)
+	}
+	return e
+}
+
+// InsertChild inserts nodes before the child at index i. The index refers
+// to the children before the call, so moving a child of e to a later
+// position lands it before the child that was at i. Like AppendChild, it
+// panics if a node is e or one of its ancestors.
+func (e *Element) InsertChild(i int, nodes ...Node) *Element {
+	if i < 0 || i > len(e.Children) {
+		panic(fmt.Sprintf("svg: child index %d out of range [0, %d]", i, len(e.Children)))
+	}
+	// Check every node before any is moved
+	for _, n := range nodes {
+		e.checkCycle(n)
+	}
+	for _, n := range nodes {
+		// Detaching a child of e before i shifts the insert position
+		if j := e.childIndex(n); j >= 0 && j < i {
+			i--
+		}
+		e.adopt(n)
+	}
+	children := make([]Node, 0, len(e.Children)+len(nodes))
+	children = append(children, e.Children[:i]...)
+	children = append(children, nodes...)
+	e.Children = append(children, e.Children[i:]...)
+	return e
+}
+
+// RemoveChild removes n from e's children and reports whether it was found
+func (e *Element) RemoveChild(n Node) bool {
+	i := e.childIndex(n)
+	if i < 0 {
+		return false
+	}
+	e.Children = append(e.Children[:i:i], e.Children[i+1:]...)
+	if el, ok := n.(*Element); ok {
+		el.parent = nil
+	}
+	return true
+}
+
+// ReplaceChild replaces the child old with n and reports whether old was
+// found. If n is a child of e, it moves to the position of old. Replacing a
+// child with itself changes nothing; n must not be e or one of its
+// ancestors.
+func (e *Element) ReplaceChild(old, n Node) bool {
+	if e.childIndex(old) < 0 {
+		return false
+	}
+	if n == old {
+		return true
+	}
+	// Detach n first: if it is a child of e, the index of old shifts
+	e.adopt(n)
+	i := e.childIndex(old)
+	e.Children[i] = n
+	if el, ok := old.(*Element); ok {
+		el.parent = nil
+	}
+	return true
+}
+
+// childIndex returns the index of n among e's children, or -1
+func (e *Element) childIndex(n Node) int {
+	for i, child := range e.Children {
+		if child == n {
+			return i
+		}
+	}
+	return -1
+}
+
+// adopt detaches an element from its previous parent, which may be e,
+// before it is added to e
+func (e *Element) adopt(n Node) {
+	e.checkCycle(n)
+	el, ok := n.(*Element)
+	if !ok {
+		return
+	}
+	if el.parent != nil {
+		el.parent.RemoveChild(el)
+	}
+	el.parent = e
+}
+
+// checkCycle panics if n is e or one of its ancestors, which cannot become
+// a child of e without making the tree a cycle
+func (e *Element) checkCycle(n Node) {
+	for a := e; a != nil; a = a.parent {
+		if n == a {
+			panic(fmt.Sprintf("svg: cannot add <%s> to itself or a descendant", a.Tag))
+		}
+	}
+}
+
+// Clone returns a deep copy of e that has no parent
+func (e *Element) Clone() *Element {
+	c := &Element{Tag: e.Tag, Attrs: append([]Attr(nil), e.Attrs...)}
+	for _, child := range e.Children {
+		switch n := child.(type) {
+		case *Element:
+			c.AppendChild(n.Clone())
+		case *CharData:
+			c.AppendChild(&CharData{Data: n.Data})
+		case *Comment:
+			c.AppendChild(&Comment{Data: n.Data})
+		case *ProcInst:
+			c.AppendChild(&ProcInst{Target: n.Target, Inst: n.Inst})
+		case *Raw:
+			c.AppendChild(&Raw{XML: n.XML})
+		default:
+			c.AppendChild(child)
+		}
+	}
+	return c
+}
+
+// ChildElements returns the element children of e
+func (e *Element) ChildElements() []*Element {
+	var out []*Element
+	for _, child := range e.Children {
+		if el, ok := child.(*Element); ok {
+			out = append(out, el)
+		}
+	}
+	return out
+}
+
+// Walk calls fn for e and its descendant elements in document order. If fn
+// returns false, the children of that element are skipped.
+func (e *Element) Walk(fn func(*Element) bool) {
+	if !fn(e) {
+		return
+	}
+	for _, child := range e.ChildElements() {
+		child.Walk(fn)
+	}
+}
+
+// FindByID returns the first element with the given id, or nil
+func (e *Element) FindByID(id string) *Element {
+	var found *Element
+	e.Walk(func(el *Element) bool {
+		if found == nil && el.ID() == id {
+			found = el
+		}
+		return found == nil
+	})
+	return found
+}
+
+// FindAll returns all elements with the given tag in document order
+func (e *Element) FindAll(tag string) []*Element {
+	var out []*Element
+	e.Walk(func(el *Element) bool {
+		if el.Tag == tag {
+			out = append(out, el)
+		}
+		return true
+	})
+	return out
+}
+
+// TextContent returns the concatenated character data of e and its descendants
+func (e *Element) TextContent() string {
+	var b strings.Builder
+	for _, child := range e.Children {
+		switch c := child.(type) {
+		case *CharData:
+			b.WriteString(c.Data)
+		case *Element:
+			b.WriteString(c.TextContent())
+		}
+	}
+	return b.String()
+}
+
+// WriteTo serializes the element and its descendants to w
+func (e *Element) WriteTo(w io.Writer) (int64, error) {
+	xw := &xmlWriter{w: w}
+	e.writeXML(xw)
+	return xw.n, xw.err
+}
+
+// String serializes the element to a string
+func (e *Element) String() string {
+	var b strings.Builder
+	e.WriteTo(&b)
+	return b.String()
+}
+
+func (e *Element) writeXML(w *xmlWriter) {
+	e.writeStartTag(w)
+	if len(e.Children) == 0 {
+		w.writeString("/>")
+		return
+	}
+	w.writeString(">")
+	for _, child := range e.Children {
+		child.writeXML(w)
+	}
+	w.writeString("</")
+	w.writeString(e.Tag)
+	w.writeString(">")
+}
+
+// writeIndented writes the element with each child on its own line, indented
+// two spaces per level. Elements holding text are written inline, since
+// whitespace added inside them would become content.
+func (e *Element) writeIndented(w *xmlWriter, depth int) {
+	if len(e.Children) == 0 || e.Tag == "text" || e.hasCharData() {
+		e.writeXML(w)
+		return
+	}
+	e.writeStartTag(w)
+	w.writeString(">\n")
+	for _, child := range e.Children {
+		w.writeString(strings.Repeat("  ", depth+1))
+		if c, ok := child.(*Element); ok {
+			c.writeIndented(w, depth+1)
+		} else {
+			child.writeXML(w)
+		}
+		w.writeString("\n")
+	}
+	w.writeString(strings.Repeat("  ", depth))
+	w.writeString("</")
+	w.writeString(e.Tag)
+	w.writeString(">")
+}
+
+// writeStartTag writes the tag name and attributes, leaving the tag open
+func (e *Element) writeStartTag(w *xmlWriter) {
+	w.writeString("<")
+	w.writeString(e.Tag)
+	for _, attr := range e.Attrs {
+		w.writeString(" ")
+		w.writeString(attr.Name)
+		w.writeString(`="`)
+		w.writeString(escapeAttr(attr.Value))
+		w.writeString(`"`)
+	}
+}
+
+func (e *Element) hasCharData() bool {
+	for _, child := range e.Children {
+		if _, ok := child.(*CharData); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CharData) writeXML(w *xmlWriter) {
+	w.writeString(escapeXML(c.Data))
+}
+
+func (c *Comment) writeXML(w *xmlWriter) {
+	w.writeString("<!--")
+	w.writeString(c.Data)
+	w.writeString("-->")
+}
+
+func (p *ProcInst) writeXML(w *xmlWriter) {
+	w.writeString("<?")
+	w.writeString(p.Target)
+	if p.Inst != "" {
+		w.writeString(" ")
+		w.writeString(p.Inst)
+	}
+	w.writeString("?>")
+}
+
+func (r *Raw) writeXML(w *xmlWriter) {
+	w.writeString(r.XML)
+}
+
+// xmlWriter counts written bytes and keeps the first write error
+type xmlWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (w *xmlWriter) writeString(s string) {
+	if w.err != nil {
+		return
+	}
+	n, err := io.WriteString(w.w, s)
+	w.n += int64(n)
+	w.err = err
+}
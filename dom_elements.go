@@ -0,0 +1,271 @@
+package svg
+
+import "strings"
+
+// Typed constructors for document trees. Each mirrors the string helper of
+// the same name without the Element suffix and produces the same markup.
+
+// RectElement creates an SVG rectangle element
+func RectElement(x, y, width, height float64, style Style) *Element {
+	n := style.NumberFormat
+	e := NewElement("rect", numAttr("x", x, n), numAttr("y", y, n), numAttr("width", width, n), numAttr("height", height, n))
+	return e.SetStyle(style)
+}
+
+// RoundedRectElement creates an SVG rectangle element with rounded corners
+func RoundedRectElement(x, y, width, height, rx, ry float64, style Style) *Element {
+	n := style.NumberFormat
+	if ry == 0 {
+		ry = rx // If ry not specified, use rx for both
+	}
+	e := NewElement("rect", numAttr("x", x, n), numAttr("y", y, n), numAttr("width", width, n), numAttr("height", height, n),
+		numAttr("rx", rx, n), numAttr("ry", ry, n))
+	return e.SetStyle(style)
+}
+
+// CircleElement creates an SVG circle element
+func CircleElement(cx, cy, r float64, style Style) *Element {
+	n := style.NumberFormat
+	e := NewElement("circle", numAttr("cx", cx, n), numAttr("cy", cy, n), numAttr("r", r, n))
+	return e.SetStyle(style)
+}
+
+// EllipseElement creates an SVG ellipse element
+func EllipseElement(cx, cy, rx, ry float64, style Style) *Element {
+	n := style.NumberFormat
+	e := NewElement("ellipse", numAttr("cx", cx, n), numAttr("cy", cy, n), numAttr("rx", rx, n), numAttr("ry", ry, n))
+	return e.SetStyle(style)
+}
+
+// PolygonElement creates an SVG polygon element
+func PolygonElement(points []Point, style Style) *Element {
+	e := NewElement("polygon", Attr{Name: "points", Value: formatPoints(points, style.NumberFormat)})
+	return e.SetStyle(style)
+}
+
+// PolylineElement creates an SVG polyline element
+func PolylineElement(points []Point, style Style) *Element {
+	e := NewElement("polyline", Attr{Name: "points", Value: formatPoints(points, style.NumberFormat)})
+	return e.SetStyle(style)
+}
+
+// LineElement creates an SVG line element
+func LineElement(x1, y1, x2, y2 float64, style Style) *Element {
+	n := style.NumberFormat
+	e := NewElement("line", numAttr("x1", x1, n), numAttr("y1", y1, n), numAttr("x2", x2, n), numAttr("y2", y2, n))
+	return e.SetStyle(style)
+}
+
+// PathElement creates an SVG path element
+func PathElement(d string, style Style) *Element {
+	e := NewElement("path", Attr{Name: "d", Value: d})
+	return e.SetStyle(style)
+}
+
+// TextElement creates an SVG text element
+func TextElement(content string, x, y float64, style Style) *Element {
+	n := style.NumberFormat
+	e := NewElement("text", numAttr("x", x, n), numAttr("y", y, n))
+	e.SetStyle(style)
+	if content != "" {
+		e.AppendChild(&CharData{Data: content})
+	}
+	return e
+}
+
+// TSpanElement creates an SVG tspan element (for use inside text elements)
+func TSpanElement(content string, style Style, dx, dy float64) *Element {
+	e := NewElement("tspan")
+	if dx != 0 {
+		e.SetAttr("dx", style.NumberFormat.Format(dx))
+	}
+	if dy != 0 {
+		e.SetAttr("dy", style.NumberFormat.Format(dy))
+	}
+	e.SetStyle(style)
+	if content != "" {
+		e.AppendChild(&CharData{Data: content})
+	}
+	return e
+}
+
+// GroupElement creates an SVG <g> element with optional transform
+func GroupElement(transform string, style Style, children ...Node) *Element {
+	e := NewElement("g")
+	if transform != "" {
+		e.SetAttr("transform", transform)
+	}
+	return e.SetStyle(style).AppendChild(children...)
+}
+
+// GroupWithClipPathElement creates an SVG <g> element with a clipPath
+func GroupWithClipPathElement(clipPathID string, style Style, children ...Node) *Element {
+	style.ClipPath = URL(clipPathID)
+	return GroupElement("", style, children...)
+}
+
+// DefsElement creates an SVG <defs> element
+func DefsElement(children ...Node) *Element {
+	return NewElement("defs").AppendChild(children...)
+}
+
+// TitleElement creates an SVG <title> element, the accessible name of its parent
+func TitleElement(title string) *Element {
+	return NewElement("title").AppendChild(&CharData{Data: title})
+}
+
+// ClipPathElement creates an SVG <clipPath> element
+func ClipPathElement(id string, children ...Node) *Element {
+	return NewElement("clipPath", Attr{Name: "id", Value: id}).AppendChild(children...)
+}
+
+// LinearGradientElement creates a linear gradient definition element
+func LinearGradientElement(def LinearGradientDef) *Element {
+	e := NewElement("linearGradient", Attr{Name: "id", Value: def.ID})
+	setNonEmpty(e, "x1", def.X1)
+	setNonEmpty(e, "y1", def.Y1)
+	setNonEmpty(e, "x2", def.X2)
+	setNonEmpty(e, "y2", def.Y2)
+	setNonEmpty(e, "gradientUnits", string(def.Units))
+	setNonEmpty(e, "spreadMethod", string(def.SpreadMethod))
+	return e.AppendChild(stopElements(def.Stops, def.NumberFormat)...)
+}
+
+// RadialGradientElement creates a radial gradient definition element
+func RadialGradientElement(def RadialGradientDef) *Element {
+	e := NewElement("radialGradient", Attr{Name: "id", Value: def.ID})
+	setNonEmpty(e, "cx", def.CX)
+	setNonEmpty(e, "cy", def.CY)
+	setNonEmpty(e, "r", def.R)
+	setNonEmpty(e, "fx", def.FX)
+	setNonEmpty(e, "fy", def.FY)
+	setNonEmpty(e, "fr", def.FR)
+	setNonEmpty(e, "gradientUnits", string(def.Units))
+	setNonEmpty(e, "spreadMethod", string(def.SpreadMethod))
+	return e.AppendChild(stopElements(def.Stops, def.NumberFormat)...)
+}
+
+func stopElements(stops []GradientStop, format NumberFormat) []Node {
+	nodes := make([]Node, 0, len(stops))
+	for _, stop := range stops {
+		e := NewElement("stop", Attr{Name: "offset", Value: stop.Offset}, Attr{Name: "stop-color", Value: stop.Color})
+		if stop.OpacitySet {
+			e.SetAttr("stop-opacity", format.Format(clamp01(stop.Opacity)))
+		} else if stop.Opacity > 0 && stop.Opacity < 1 {
+			e.SetAttr("stop-opacity", format.Format(stop.Opacity))
+		}
+		nodes = append(nodes, e)
+	}
+	return nodes
+}
+
+// MarkerElement creates a marker definition element. The marker content is
+// def.Content as raw markup followed by children.
+func MarkerElement(def MarkerDef, children ...Node) *Element {
+	e := NewElement("marker", Attr{Name: "id", Value: def.ID})
+	setNonEmpty(e, "viewBox", def.ViewBox)
+	n := def.NumberFormat
+	e.SetAttr("refX", n.Format(def.RefX))
+	e.SetAttr("refY", n.Format(def.RefY))
+	if def.MarkerWidth > 0 {
+		e.SetAttr("markerWidth", n.Format(def.MarkerWidth))
+	}
+	if def.MarkerHeight > 0 {
+		e.SetAttr("markerHeight", n.Format(def.MarkerHeight))
+	}
+	setNonEmpty(e, "orient", string(def.Orient))
+	setNonEmpty(e, "markerUnits", string(def.MarkerUnits))
+	if def.Content != "" {
+		e.AppendChild(&Raw{XML: def.Content})
+	}
+	return e.AppendChild(children...)
+}
+
+// PatternElement creates a pattern definition element. The tile content is
+// def.Content as raw markup followed by children.
+func PatternElement(def PatternDef, children ...Node) *Element {
+	n := def.NumberFormat
+	e := NewElement("pattern", Attr{Name: "id", Value: def.ID})
+	if def.X != 0 || def.Y != 0 {
+		e.SetAttr("x", n.Format(def.X))
+		e.SetAttr("y", n.Format(def.Y))
+	}
+	e.SetAttr("width", n.Format(def.Width))
+	e.SetAttr("height", n.Format(def.Height))
+	setNonEmpty(e, "patternUnits", string(def.Units))
+	setNonEmpty(e, "patternContentUnits", string(def.ContentUnits))
+	setNonEmpty(e, "viewBox", def.ViewBox)
+	setNonEmpty(e, "preserveAspectRatio", def.PreserveAspectRatio)
+	setNonEmpty(e, "patternTransform", def.Transform)
+	if def.Content != "" {
+		e.AppendChild(&Raw{XML: def.Content})
+	}
+	return e.AppendChild(children...)
+}
+
+// FilterElement creates a filter definition element. The primitives are
+// def.Content as raw markup followed by children.
+func FilterElement(def FilterDef, children ...Node) *Element {
+	e := NewElement("filter", Attr{Name: "id", Value: def.ID})
+	setNonEmpty(e, "x", def.X)
+	setNonEmpty(e, "y", def.Y)
+	setNonEmpty(e, "width", def.Width)
+	setNonEmpty(e, "height", def.Height)
+	setNonEmpty(e, "filterUnits", string(def.Units))
+	setNonEmpty(e, "primitiveUnits", string(def.PrimitiveUnits))
+	setNonEmpty(e, "color-interpolation-filters", def.ColorInterpolation)
+	if def.Content != "" {
+		e.AppendChild(&Raw{XML: def.Content})
+	}
+	return e.AppendChild(children...)
+}
+
+// SymbolElement creates a symbol definition element. The symbol content is
+// def.Content as raw markup followed by children.
+func SymbolElement(def SymbolDef, children ...Node) *Element {
+	e := NewElement("symbol", Attr{Name: "id", Value: def.ID})
+	setNonEmpty(e, "viewBox", def.ViewBox)
+	setNonEmpty(e, "preserveAspectRatio", def.PreserveAspectRatio)
+	if def.Content != "" {
+		e.AppendChild(&Raw{XML: def.Content})
+	}
+	return e.AppendChild(children...)
+}
+
+// UseElement creates a <use> element instantiating a symbol. A zero width or
+// height is left unset.
+func UseElement(ref string, x, y, width, height float64, style Style) *Element {
+	n := style.NumberFormat
+	e := NewElement("use", Attr{Name: "href", Value: "#" + refID(ref)}, numAttr("x", x, n), numAttr("y", y, n))
+	if width > 0 {
+		e.SetAttr("width", n.Format(width))
+	}
+	if height > 0 {
+		e.SetAttr("height", n.Format(height))
+	}
+	return e.SetStyle(style)
+}
+
+func numAttr(name string, v float64, format NumberFormat) Attr {
+	return Attr{Name: name, Value: format.Format(v)}
+}
+
+func setNonEmpty(e *Element, name, value string) {
+	if value != "" {
+		e.SetAttr(name, value)
+	}
+}
+
+// formatPoints formats a points attribute value
+func formatPoints(points []Point, format NumberFormat) string {
+	var b strings.Builder
+	for i, p := range points {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString(format.Format(p.X))
+		b.WriteString(",")
+		b.WriteString(format.Format(p.Y))
+	}
+	return b.String()
+}
@@ -0,0 +1,244 @@
+package svg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/SCKelemen/layout"
+)
+
+func TestElementConstructorsMatchStringHelpers(t *testing.T) {
+	style := Style{Fill: "#ff0000", Stroke: "#000", StrokeWidth: 2, Opacity: 0.5}
+	points := []Point{{X: 0, Y: 0}, {X: 10, Y: 5}}
+
+	tests := []struct {
+		name string
+		elem *Element
+		want string
+	}{
+		{"rect", RectElement(1, 2, 3, 4, style), Rect(1, 2, 3, 4, style)},
+		{"rounded rect", RoundedRectElement(1, 2, 3, 4, 5, 0, style), RoundedRect(1, 2, 3, 4, 5, 0, style)},
+		{"circle", CircleElement(1, 2, 3, style), Circle(1, 2, 3, style)},
+		{"ellipse", EllipseElement(1, 2, 3, 4, style), Ellipse(1, 2, 3, 4, style)},
+		{"polygon", PolygonElement(points, style), Polygon(points, style)},
+		{"polyline", PolylineElement(points, style), Polyline(points, style)},
+		{"line", LineElement(1, 2, 3, 4, style), Line(1, 2, 3, 4, style)},
+		{"path", PathElement("M0 0 L10 10", style), Path("M0 0 L10 10", style)},
+		{"text", TextElement("a < b", 1, 2, style), Text("a < b", 1, 2, style)},
+		{"tspan", TSpanElement("x", style, 1, 0), TSpan("x", style, 1, 0)},
+		{"group", GroupElement("translate(1,2)", style, CircleElement(1, 2, 3, Style{})),
+			Group(Circle(1, 2, 3, Style{}), "translate(1,2)", style)},
+	}
+	for _, tt := range tests {
+		if got := tt.elem.String(); got != tt.want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestElementAttributes(t *testing.T) {
+	e := NewElement("rect", Attr{Name: "x", Value: "1"}, Attr{Name: "y", Value: "2"})
+	e.SetAttr("x", "3").SetAttr("id", "box")
+
+	if v, ok := e.Attr("x"); !ok || v != "3" {
+		t.Errorf("Attr(x) = %q, %v", v, ok)
+	}
+	if e.ID() != "box" {
+		t.Errorf("ID() = %q", e.ID())
+	}
+	if !e.RemoveAttr("y") || e.RemoveAttr("y") {
+		t.Error("expected RemoveAttr to remove y once")
+	}
+	if got, want := e.String(), `<rect x="3" id="box"/>`; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+}
+
+func TestElementChildManipulation(t *testing.T) {
+	a, b, c := NewElement("a"), NewElement("b"), NewElement("c")
+	parent := NewElement("g").AppendChild(a, c)
+	parent.InsertChild(1, b)
+	if got := parent.String(); got != "<g><a/><b/><c/></g>" {
+		t.Fatalf("after insert: %s", got)
+	}
+	if b.Parent() != parent {
+		t.Error("expected inserted child to know its parent")
+	}
+
+	// Appending an element elsewhere moves it.
+	other := NewElement("g").AppendChild(b)
+	if got := parent.String(); got != "<g><a/><c/></g>" {
+		t.Errorf("after move: %s", got)
+	}
+	if b.Parent() != other {
+		t.Error("expected moved child to know its new parent")
+	}
+
+	d := NewElement("d")
+	if !parent.ReplaceChild(a, d) || a.Parent() != nil {
+		t.Error("expected ReplaceChild to detach the old child")
+	}
+	if !parent.RemoveChild(c) || parent.RemoveChild(c) {
+		t.Error("expected RemoveChild to remove c once")
+	}
+	if got := parent.String(); got != "<g><d/></g>" {
+		t.Errorf("after replace and remove: %s", got)
+	}
+}
+
+func TestElementQueries(t *testing.T) {
+	root := GroupElement("", Style{},
+		RectElement(0, 0, 1, 1, Style{}).SetAttr("id", "first"),
+		GroupElement("", Style{}, TextElement("hello ", 0, 0, Style{}).AppendChild(TSpanElement("world", Style{}, 0, 0))),
+	)
+
+	if got := root.FindByID("first"); got == nil || got.Tag != "rect" {
+		t.Errorf("FindByID(first) = %v", got)
+	}
+	if root.FindByID("missing") != nil {
+		t.Error("expected no element for missing id")
+	}
+	if got := len(root.FindAll("g")); got != 2 {
+		t.Errorf("FindAll(g) found %d elements, want 2", got)
+	}
+	if got := root.TextContent(); got != "hello world" {
+		t.Errorf("TextContent() = %q", got)
+	}
+}
+
+func TestDocumentWriteTo(t *testing.T) {
+	root := NewElement("svg", Attr{Name: "xmlns", Value: "http://www.w3.org/2000/svg"})
+	root.AppendChild(&Comment{Data: " icon "}, TextElement(`"quoted" & <escaped>`, 0, 0, Style{}), &Raw{XML: "<g/>"})
+	doc := NewDocument(root)
+	doc.Prolog = append(doc.Prolog, XMLDeclaration())
+
+	var b strings.Builder
+	n, err := doc.WriteTo(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+		`<svg xmlns="http://www.w3.org/2000/svg"><!-- icon --><text x="0.00" y="0.00">&quot;quoted&quot; &amp; &lt;escaped&gt;</text><g/></svg>`
+	if b.String() != want {
+		t.Errorf("WriteTo wrote:\n%s\nwant:\n%s", b.String(), want)
+	}
+	if n != int64(len(want)) {
+		t.Errorf("WriteTo returned %d bytes, want %d", n, len(want))
+	}
+
+	if _, err := doc.WriteTo(failingWriter{}); !errors.Is(err, errWriteFailed) {
+		t.Errorf("expected write error, got %v", err)
+	}
+}
+
+func TestRendererRenderDocument(t *testing.T) {
+	root := &layout.Node{
+		Rect: layout.Rect{X: 0, Y: 0, Width: 40, Height: 20},
+		Children: []*layout.Node{
+			{Rect: layout.Rect{X: 0, Y: 0, Width: 20, Height: 20}},
+		},
+	}
+
+	opts := DefaultOptions()
+	opts.IncludeXMLDeclaration = true
+	doc := NewRenderer(opts).RenderDocument(root)
+
+	rects := doc.Root.FindAll("rect")
+	if len(rects) != 2 {
+		t.Fatalf("expected 2 rects, got %d", len(rects))
+	}
+	rects[1].AppendChild(TitleElement("Child"))
+
+	out := doc.String()
+	if !strings.HasPrefix(out, "<?xml") {
+		t.Errorf("expected XML declaration, got: %s", out)
+	}
+	if !strings.Contains(out, `<defs><style>`) {
+		t.Errorf("expected stylesheet in defs, got: %s", out)
+	}
+	if !strings.Contains(out, `<title>Child</title></rect></g></svg>`) {
+		t.Errorf("expected title added to child rect, got: %s", out)
+	}
+}
+
+func TestElementChildManipulation_SameParent(t *testing.T) {
+	children := func() (*Element, *Element, *Element, *Element) {
+		a, b, c := NewElement("a"), NewElement("b"), NewElement("c")
+		return NewElement("g").AppendChild(a, b, c), a, b, c
+	}
+
+	g, a, _, _ := children()
+	g.AppendChild(a)
+	if got := g.String(); got != "<g><b/><c/><a/></g>" {
+		t.Errorf("append a child again: %s", got)
+	}
+	g.AppendChild(a)
+	if got := g.String(); got != "<g><b/><c/><a/></g>" {
+		t.Errorf("append the last child again: %s", got)
+	}
+
+	g, a, _, _ = children()
+	g.InsertChild(2, a)
+	if got := g.String(); got != "<g><b/><a/><c/></g>" {
+		t.Errorf("insert a child later: %s", got)
+	}
+	g.InsertChild(3, a)
+	if got := g.String(); got != "<g><b/><c/><a/></g>" {
+		t.Errorf("insert a child at the end: %s", got)
+	}
+	g.InsertChild(0, a)
+	if got := g.String(); got != "<g><a/><b/><c/></g>" {
+		t.Errorf("insert a child earlier: %s", got)
+	}
+
+	g, a, _, c := children()
+	if !g.ReplaceChild(c, a) || c.Parent() != nil || a.Parent() != g {
+		t.Error("expected ReplaceChild to move a over c")
+	}
+	if got := g.String(); got != "<g><b/><a/></g>" {
+		t.Errorf("replace with a child: %s", got)
+	}
+	if !g.ReplaceChild(a, a) || a.Parent() != g {
+		t.Error("replacing a child with itself should keep it")
+	}
+	if got := g.String(); got != "<g><b/><a/></g>" {
+		t.Errorf("replace a child with itself: %s", got)
+	}
+	if g.ReplaceChild(c, a) {
+		t.Error("expected ReplaceChild to report a missing child")
+	}
+}
+
+func TestElementChildManipulation_RejectsCycles(t *testing.T) {
+	expectPanic := func(name string, fn func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s: expected a panic", name)
+			}
+		}()
+		fn()
+	}
+
+	x := NewElement("g")
+	y := NewElement("g")
+	z := NewElement("rect")
+	a := NewElement("a")
+	x.AppendChild(y)
+	y.AppendChild(z)
+	z.AppendChild(a)
+
+	expectPanic("append to itself", func() { x.AppendChild(x) })
+	expectPanic("append a parent", func() { y.AppendChild(x) })
+	expectPanic("insert an ancestor", func() { z.InsertChild(0, x) })
+	expectPanic("replace with a parent", func() { z.ReplaceChild(a, y) })
+
+	// The tree is unchanged and still serializes
+	if got := x.String(); got != "<g><g><rect><a/></rect></g></g>" {
+		t.Errorf("unexpected tree after rejected moves: %s", got)
+	}
+	if x.Parent() != nil || y.Parent() != x || z.Parent() != y {
+		t.Error("expected the parents unchanged")
+	}
+}
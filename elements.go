@@ -101,13 +101,15 @@ type Style struct {
 	FontSize         units.Length // Type-safe CSS length with units
 	FontWeight       FontWeight
 	FontStyle        FontStyle
+	NumberFormat     NumberFormat // How coordinates and other numbers are written
 }
 
 // Rect renders an SVG rectangle
 func Rect(x, y, width, height float64, style Style) string {
 	attrs := formatStyle(style)
-	return fmt.Sprintf(`<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f"%s/>`,
-		x, y, width, height, attrs)
+	n := style.NumberFormat
+	return fmt.Sprintf(`<rect x="%s" y="%s" width="%s" height="%s"%s/>`,
+		n.Format(x), n.Format(y), n.Format(width), n.Format(height), attrs)
 }
 
 // RoundedRect renders an SVG rectangle with rounded corners
@@ -116,22 +118,25 @@ func RoundedRect(x, y, width, height, rx, ry float64, style Style) string {
 	if ry == 0 {
 		ry = rx // If ry not specified, use rx for both
 	}
-	return fmt.Sprintf(`<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" rx="%.2f" ry="%.2f"%s/>`,
-		x, y, width, height, rx, ry, attrs)
+	n := style.NumberFormat
+	return fmt.Sprintf(`<rect x="%s" y="%s" width="%s" height="%s" rx="%s" ry="%s"%s/>`,
+		n.Format(x), n.Format(y), n.Format(width), n.Format(height), n.Format(rx), n.Format(ry), attrs)
 }
 
 // Circle renders an SVG circle
 func Circle(cx, cy, r float64, style Style) string {
 	attrs := formatStyle(style)
-	return fmt.Sprintf(`<circle cx="%.2f" cy="%.2f" r="%.2f"%s/>`,
-		cx, cy, r, attrs)
+	n := style.NumberFormat
+	return fmt.Sprintf(`<circle cx="%s" cy="%s" r="%s"%s/>`,
+		n.Format(cx), n.Format(cy), n.Format(r), attrs)
 }
 
 // Ellipse renders an SVG ellipse
 func Ellipse(cx, cy, rx, ry float64, style Style) string {
 	attrs := formatStyle(style)
-	return fmt.Sprintf(`<ellipse cx="%.2f" cy="%.2f" rx="%.2f" ry="%.2f"%s/>`,
-		cx, cy, rx, ry, attrs)
+	n := style.NumberFormat
+	return fmt.Sprintf(`<ellipse cx="%s" cy="%s" rx="%s" ry="%s"%s/>`,
+		n.Format(cx), n.Format(cy), n.Format(rx), n.Format(ry), attrs)
 }
 
 // Polygon renders an SVG polygon (closed shape from points)
@@ -140,16 +145,8 @@ func Polygon(points []Point, style Style) string {
 		return ""
 	}
 
-	var pointsStr strings.Builder
-	for i, p := range points {
-		if i > 0 {
-			pointsStr.WriteString(" ")
-		}
-		fmt.Fprintf(&pointsStr, "%.2f,%.2f", p.X, p.Y)
-	}
-
 	attrs := formatStyle(style)
-	return fmt.Sprintf(`<polygon points="%s"%s/>`, pointsStr.String(), attrs)
+	return fmt.Sprintf(`<polygon points="%s"%s/>`, formatPoints(points, style.NumberFormat), attrs)
 }
 
 // Polyline renders an SVG polyline (open shape from points)
@@ -158,30 +155,24 @@ func Polyline(points []Point, style Style) string {
 		return ""
 	}
 
-	var pointsStr strings.Builder
-	for i, p := range points {
-		if i > 0 {
-			pointsStr.WriteString(" ")
-		}
-		fmt.Fprintf(&pointsStr, "%.2f,%.2f", p.X, p.Y)
-	}
-
 	attrs := formatStyle(style)
-	return fmt.Sprintf(`<polyline points="%s"%s/>`, pointsStr.String(), attrs)
+	return fmt.Sprintf(`<polyline points="%s"%s/>`, formatPoints(points, style.NumberFormat), attrs)
 }
 
 // Line renders an SVG line
 func Line(x1, y1, x2, y2 float64, style Style) string {
 	attrs := formatStyle(style)
-	return fmt.Sprintf(`<line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f"%s/>`,
-		x1, y1, x2, y2, attrs)
+	n := style.NumberFormat
+	return fmt.Sprintf(`<line x1="%s" y1="%s" x2="%s" y2="%s"%s/>`,
+		n.Format(x1), n.Format(y1), n.Format(x2), n.Format(y2), attrs)
 }
 
 // Text renders an SVG text element
 func Text(content string, x, y float64, style Style) string {
 	attrs := formatStyle(style)
-	return fmt.Sprintf(`<text x="%.2f" y="%.2f"%s>%s</text>`,
-		x, y, attrs, escapeXML(content))
+	n := style.NumberFormat
+	return fmt.Sprintf(`<text x="%s" y="%s"%s>%s</text>`,
+		n.Format(x), n.Format(y), attrs, escapeXML(content))
 }
 
 // TSpan renders an SVG tspan element (for use inside text elements)
@@ -190,10 +181,10 @@ func TSpan(content string, style Style, dx, dy float64) string {
 	attrs := formatStyle(style)
 	posAttrs := ""
 	if dx != 0 {
-		posAttrs += fmt.Sprintf(` dx="%.2f"`, dx)
+		posAttrs += fmt.Sprintf(` dx="%s"`, style.NumberFormat.Format(dx))
 	}
 	if dy != 0 {
-		posAttrs += fmt.Sprintf(` dy="%.2f"`, dy)
+		posAttrs += fmt.Sprintf(` dy="%s"`, style.NumberFormat.Format(dy))
 	}
 	return fmt.Sprintf(`<tspan%s%s>%s</tspan>`, posAttrs, attrs, escapeXML(content))
 }
@@ -201,8 +192,9 @@ func TSpan(content string, style Style, dx, dy float64) string {
 // TextWithSpans renders an SVG text element with multiple styled spans
 func TextWithSpans(x, y float64, style Style, spans []string) string {
 	attrs := formatStyle(style)
-	return fmt.Sprintf(`<text x="%.2f" y="%.2f"%s>%s</text>`,
-		x, y, attrs, strings.Join(spans, ""))
+	n := style.NumberFormat
+	return fmt.Sprintf(`<text x="%s" y="%s"%s>%s</text>`,
+		n.Format(x), n.Format(y), attrs, strings.Join(spans, ""))
 }
 
 // TextPath renders text along a path
@@ -241,81 +233,91 @@ func GroupWithClipPath(content string, clipPathID string, style Style) string {
 
 // formatStyle converts a Style struct to SVG attribute string
 func formatStyle(s Style) string {
-	var attrs []string
+	attrs := styleAttrs(s)
+	if len(attrs) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, attr := range attrs {
+		fmt.Fprintf(&b, ` %s="%s"`, attr.Name, escapeAttr(attr.Value))
+	}
+	return b.String()
+}
+
+// styleAttrs converts a Style struct to SVG presentation attributes
+func styleAttrs(s Style) []Attr {
+	var attrs []Attr
 
 	if s.Fill != "" {
-		attrs = append(attrs, fmt.Sprintf(`fill="%s"`, escapeAttr(s.Fill)))
+		attrs = append(attrs, Attr{Name: "fill", Value: s.Fill})
 	}
 	if s.Stroke != "" {
-		attrs = append(attrs, fmt.Sprintf(`stroke="%s"`, escapeAttr(s.Stroke)))
+		attrs = append(attrs, Attr{Name: "stroke", Value: s.Stroke})
 	}
 	if s.StrokeWidth > 0 {
-		attrs = append(attrs, fmt.Sprintf(`stroke-width="%.2f"`, s.StrokeWidth))
+		attrs = append(attrs, Attr{Name: "stroke-width", Value: s.NumberFormat.Format(s.StrokeWidth)})
 	}
 	if s.StrokeDashArray != "" {
-		attrs = append(attrs, fmt.Sprintf(`stroke-dasharray="%s"`, escapeAttr(s.StrokeDashArray)))
+		attrs = append(attrs, Attr{Name: "stroke-dasharray", Value: s.StrokeDashArray})
 	}
 	if s.StrokeLinecap != "" {
-		attrs = append(attrs, fmt.Sprintf(`stroke-linecap="%s"`, escapeAttr(string(s.StrokeLinecap))))
+		attrs = append(attrs, Attr{Name: "stroke-linecap", Value: string(s.StrokeLinecap)})
 	}
 	if s.StrokeLinejoin != "" {
-		attrs = append(attrs, fmt.Sprintf(`stroke-linejoin="%s"`, escapeAttr(string(s.StrokeLinejoin))))
+		attrs = append(attrs, Attr{Name: "stroke-linejoin", Value: string(s.StrokeLinejoin)})
 	}
 	if s.OpacitySet {
-		attrs = append(attrs, fmt.Sprintf(`opacity="%.2f"`, clamp01(s.Opacity)))
+		attrs = append(attrs, Attr{Name: "opacity", Value: s.NumberFormat.Format(clamp01(s.Opacity))})
 	} else if s.Opacity > 0 && s.Opacity < 1 {
-		attrs = append(attrs, fmt.Sprintf(`opacity="%.2f"`, s.Opacity))
+		attrs = append(attrs, Attr{Name: "opacity", Value: s.NumberFormat.Format(s.Opacity)})
 	}
 	if s.FillOpacitySet {
-		attrs = append(attrs, fmt.Sprintf(`fill-opacity="%.2f"`, clamp01(s.FillOpacity)))
+		attrs = append(attrs, Attr{Name: "fill-opacity", Value: s.NumberFormat.Format(clamp01(s.FillOpacity))})
 	} else if s.FillOpacity > 0 && s.FillOpacity < 1 {
-		attrs = append(attrs, fmt.Sprintf(`fill-opacity="%.2f"`, s.FillOpacity))
+		attrs = append(attrs, Attr{Name: "fill-opacity", Value: s.NumberFormat.Format(s.FillOpacity)})
 	}
 	if s.StrokeOpacitySet {
-		attrs = append(attrs, fmt.Sprintf(`stroke-opacity="%.2f"`, clamp01(s.StrokeOpacity)))
+		attrs = append(attrs, Attr{Name: "stroke-opacity", Value: s.NumberFormat.Format(clamp01(s.StrokeOpacity))})
 	} else if s.StrokeOpacity > 0 && s.StrokeOpacity < 1 {
-		attrs = append(attrs, fmt.Sprintf(`stroke-opacity="%.2f"`, s.StrokeOpacity))
+		attrs = append(attrs, Attr{Name: "stroke-opacity", Value: s.NumberFormat.Format(s.StrokeOpacity)})
 	}
 	if s.Class != "" {
-		attrs = append(attrs, fmt.Sprintf(`class="%s"`, escapeAttr(s.Class)))
+		attrs = append(attrs, Attr{Name: "class", Value: s.Class})
 	}
 	if s.ClipPath != "" {
-		attrs = append(attrs, fmt.Sprintf(`clip-path="%s"`, escapeAttr(s.ClipPath)))
+		attrs = append(attrs, Attr{Name: "clip-path", Value: s.ClipPath})
 	}
 	if s.MarkerStart != "" {
-		attrs = append(attrs, fmt.Sprintf(`marker-start="%s"`, escapeAttr(s.MarkerStart)))
+		attrs = append(attrs, Attr{Name: "marker-start", Value: s.MarkerStart})
 	}
 	if s.MarkerMid != "" {
-		attrs = append(attrs, fmt.Sprintf(`marker-mid="%s"`, escapeAttr(s.MarkerMid)))
+		attrs = append(attrs, Attr{Name: "marker-mid", Value: s.MarkerMid})
 	}
 	if s.MarkerEnd != "" {
-		attrs = append(attrs, fmt.Sprintf(`marker-end="%s"`, escapeAttr(s.MarkerEnd)))
+		attrs = append(attrs, Attr{Name: "marker-end", Value: s.MarkerEnd})
 	}
 	if s.TextAnchor != "" {
-		attrs = append(attrs, fmt.Sprintf(`text-anchor="%s"`, escapeAttr(string(s.TextAnchor))))
+		attrs = append(attrs, Attr{Name: "text-anchor", Value: string(s.TextAnchor)})
 	}
 	if s.DominantBaseline != "" {
-		attrs = append(attrs, fmt.Sprintf(`dominant-baseline="%s"`, escapeAttr(string(s.DominantBaseline))))
+		attrs = append(attrs, Attr{Name: "dominant-baseline", Value: string(s.DominantBaseline)})
 	}
 	if s.FontFamily != "" {
-		attrs = append(attrs, fmt.Sprintf(`font-family="%s"`, escapeAttr(s.FontFamily)))
+		attrs = append(attrs, Attr{Name: "font-family", Value: s.FontFamily})
 	}
 	if s.FontSize.Value != 0 {
 		// Format as "valueunit" (e.g., "16px", "1.5em", "2rem")
-		attrs = append(attrs, fmt.Sprintf(`font-size="%s"`, escapeAttr(s.FontSize.String())))
+		attrs = append(attrs, Attr{Name: "font-size", Value: s.FontSize.String()})
 	}
 	if s.FontWeight != "" {
-		attrs = append(attrs, fmt.Sprintf(`font-weight="%s"`, escapeAttr(string(s.FontWeight))))
+		attrs = append(attrs, Attr{Name: "font-weight", Value: string(s.FontWeight)})
 	}
 	if s.FontStyle != "" {
-		attrs = append(attrs, fmt.Sprintf(`font-style="%s"`, escapeAttr(string(s.FontStyle))))
-	}
-
-	if len(attrs) == 0 {
-		return ""
+		attrs = append(attrs, Attr{Name: "font-style", Value: string(s.FontStyle)})
 	}
 
-	return " " + strings.Join(attrs, " ")
+	return attrs
 }
 
 // escapeXML escapes special XML characters in text content
@@ -2,15 +2,12 @@ package svg
 
 import (
 	"bytes"
-	"encoding/xml"
-	"errors"
 	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
 	"image/jpeg"
 	"image/png"
-	"io"
 	"math"
 	"regexp"
 	"sort"
@@ -45,7 +42,10 @@ type ExportOptions struct {
 	Height  int // For raster formats, 0 = use SVG dimensions
 	Quality int // For JPEG, 0-100 (default 90)
 	DPI     int // Dots per inch for physical SVG units like in/cm/mm/pt (default 96)
-	// IgnoreUnsupported skips unsupported renderable SVG elements (e.g. text/path)
+	// Fonts resolves font-family names for text rendering (optional).
+	// The embedded Go fonts are used when it is nil or has no match.
+	Fonts FontResolver
+	// IgnoreUnsupported skips unsupported renderable SVG elements (e.g. image)
 	// instead of returning an error.
 	IgnoreUnsupported bool
 }
@@ -61,13 +61,55 @@ func (e *UnsupportedElementsError) Error() string {
 }
 
 type rasterRenderState struct {
+	dpi         float64
+	fonts       *fontSet
+	ids         map[string]*svgElement
 	unsupported map[string]struct{}
 	inDefsDepth int
-}
-
-func newRasterRenderState() *rasterRenderState {
+	styles      []map[string]string
+	transforms  []Matrix
+	viewports   []Point
+	clips       []*image.Alpha
+	// activeMarkers holds the markers being drawn, to stop markers whose
+	// content references themselves.
+	activeMarkers map[*svgElement]bool
+}
+
+// inheritedProperties lists the presentation attributes that descendants
+// inherit from their ancestors.
+var inheritedProperties = map[string]bool{
+	"color":             true,
+	"fill":              true,
+	"fill-opacity":      true,
+	"fill-rule":         true,
+	"stroke":            true,
+	"stroke-width":      true,
+	"stroke-linecap":    true,
+	"stroke-linejoin":   true,
+	"stroke-miterlimit": true,
+	"stroke-dasharray":  true,
+	"stroke-dashoffset": true,
+	"stroke-opacity":    true,
+	"clip-rule":         true,
+	"marker-start":      true,
+	"marker-mid":        true,
+	"marker-end":        true,
+	"font-family":       true,
+	"font-size":         true,
+	"font-weight":       true,
+	"font-style":        true,
+	"text-anchor":       true,
+	"dominant-baseline": true,
+	"visibility":        true,
+}
+
+func newRasterRenderState(opts ExportOptions) *rasterRenderState {
 	return &rasterRenderState{
+		dpi:         resolveDPI(opts),
+		fonts:       newFontSet(opts.Fonts),
 		unsupported: make(map[string]struct{}),
+
+		activeMarkers: make(map[*svgElement]bool),
 	}
 }
 
@@ -104,6 +146,108 @@ func (s *rasterRenderState) popDefs() {
 	}
 }
 
+// computedStyle returns the presentation properties of elem, combining the
+// inherited properties of its ancestors, its presentation attributes, and
+// declarations in its style attribute (which take precedence).
+func (s *rasterRenderState) computedStyle(elem *svgElement) map[string]string {
+	props := make(map[string]string)
+	var parent map[string]string
+	if len(s.styles) > 0 {
+		parent = s.styles[len(s.styles)-1]
+		for k, v := range parent {
+			props[k] = v
+		}
+	}
+
+	set := func(name, value string) {
+		value = strings.TrimSpace(value)
+		if value == "inherit" {
+			if v, ok := parent[name]; ok {
+				props[name] = v
+			} else {
+				delete(props, name)
+			}
+			return
+		}
+		props[name] = value
+	}
+	for name, value := range elem.Attributes {
+		if name != "style" {
+			set(name, value)
+		}
+	}
+	for _, decl := range strings.Split(elem.Attributes["style"], ";") {
+		name, value, ok := strings.Cut(decl, ":")
+		if !ok {
+			continue
+		}
+		set(strings.ToLower(strings.TrimSpace(name)), value)
+	}
+
+	// Resolve relative font sizes against the parent so descendants inherit
+	// an absolute size.
+	if v, ok := props["font-size"]; ok && v != parent["font-size"] {
+		size := resolveFontSize(v, fontSizeFromProps(parent, s.dpi), s.dpi)
+		props["font-size"] = strconv.FormatFloat(size, 'f', -1, 64)
+	}
+
+	return props
+}
+
+func (s *rasterRenderState) pushStyle(elem *svgElement) {
+	inherited := make(map[string]string)
+	for name, value := range s.computedStyle(elem) {
+		if inheritedProperties[name] {
+			inherited[name] = value
+		}
+	}
+	s.styles = append(s.styles, inherited)
+}
+
+func (s *rasterRenderState) popStyle() {
+	if len(s.styles) > 0 {
+		s.styles = s.styles[:len(s.styles)-1]
+	}
+}
+
+// transform returns the current user-space to device-space transform.
+func (s *rasterRenderState) transform() Matrix {
+	if len(s.transforms) == 0 {
+		return IdentityMatrix()
+	}
+	return s.transforms[len(s.transforms)-1]
+}
+
+// pushTransform concatenates m onto the current transform.
+func (s *rasterRenderState) pushTransform(m Matrix) {
+	s.transforms = append(s.transforms, s.transform().Multiply(m))
+}
+
+func (s *rasterRenderState) popTransform() {
+	if len(s.transforms) > 0 {
+		s.transforms = s.transforms[:len(s.transforms)-1]
+	}
+}
+
+// viewport returns the size of the nearest viewport in user units, used to
+// resolve percentage lengths.
+func (s *rasterRenderState) viewport() Point {
+	if len(s.viewports) == 0 {
+		return Point{}
+	}
+	return s.viewports[len(s.viewports)-1]
+}
+
+func (s *rasterRenderState) pushViewport(size Point) {
+	s.viewports = append(s.viewports, size)
+}
+
+func (s *rasterRenderState) popViewport() {
+	if len(s.viewports) > 0 {
+		s.viewports = s.viewports[:len(s.viewports)-1]
+	}
+}
+
 // DefaultExportOptions returns sensible defaults
 func DefaultExportOptions() ExportOptions {
 	return ExportOptions{
@@ -132,62 +276,47 @@ type svgElement struct {
 	Text       string
 }
 
-// parseSVG performs basic SVG parsing for our own generated SVG
-func parseSVG(svgData string) (*svgElement, error) {
-	decoder := xml.NewDecoder(strings.NewReader(svgData))
-
-	var root *svgElement
-	var stack []*svgElement
-
-	for {
-		token, err := decoder.Token()
-		if err != nil {
-			if errors.Is(err, io.EOF) {
-				break
-			}
-			return nil, fmt.Errorf("SVG parse error: %w", err)
-		}
-
-		switch t := token.(type) {
-		case xml.StartElement:
-			elem := &svgElement{
-				Tag:        t.Name.Local,
-				Attributes: make(map[string]string),
-			}
-
-			for _, attr := range t.Attr {
-				elem.Attributes[attr.Name.Local] = attr.Value
-			}
-
-			if len(stack) > 0 {
-				parent := stack[len(stack)-1]
-				parent.Children = append(parent.Children, elem)
-			} else {
-				root = elem
-			}
-
-			stack = append(stack, elem)
+// charDataTag is the Tag of the pseudo-elements that hold character data,
+// which keep text interleaved with sibling elements in document order.
+const charDataTag = "#text"
 
-		case xml.EndElement:
-			if len(stack) > 0 {
-				stack = stack[:len(stack)-1]
-			}
-
-		case xml.CharData:
-			if len(stack) > 0 {
-				text := strings.TrimSpace(string(t))
-				if text != "" {
-					stack[len(stack)-1].Text = text
-				}
+// parseSVG parses SVG data into the tree used by the rasterizer. Namespace
+// prefixes are dropped from names, so "xlink:href" is read as "href".
+func parseSVG(svgData string) (*svgElement, error) {
+	doc, err := Parse(strings.NewReader(svgData))
+	if err != nil {
+		return nil, err
+	}
+	return rasterElement(doc.Root), nil
+}
+
+// rasterElement converts a document element and its descendants. Character
+// data becomes charDataTag pseudo-elements; comments and processing
+// instructions are dropped.
+func rasterElement(e *Element) *svgElement {
+	elem := &svgElement{
+		Tag:        localName(e.Tag),
+		Attributes: make(map[string]string, len(e.Attrs)),
+	}
+	for _, attr := range e.Attrs {
+		elem.Attributes[localName(attr.Name)] = attr.Value
+	}
+
+	for _, child := range e.Children {
+		switch c := child.(type) {
+		case *Element:
+			elem.Children = append(elem.Children, rasterElement(c))
+		case *CharData:
+			elem.Children = append(elem.Children, &svgElement{
+				Tag:  charDataTag,
+				Text: c.Data,
+			})
+			if text := strings.TrimSpace(c.Data); text != "" {
+				elem.Text = text
 			}
 		}
 	}
-
-	if root == nil {
-		return nil, fmt.Errorf("no SVG root element found")
-	}
-
-	return root, nil
+	return elem
 }
 
 // rasterize converts SVG to a raster image
@@ -216,7 +345,8 @@ func rasterize(svgData string, opts ExportOptions) ([]byte, error) {
 
 	// Create rasterizer
 	rasterizer := vector.NewRasterizer(width, height)
-	state := newRasterRenderState()
+	state := newRasterRenderState(opts)
+	state.ids = indexElementIDs(root)
 
 	// Render SVG elements
 	if err := renderElement(root, img, rasterizer, width, height, dpi, state); err != nil {
@@ -263,12 +393,9 @@ func getSVGDimensions(root *svgElement, opts ExportOptions, dpi float64) (int, i
 	var viewBoxWidth float64
 	var viewBoxHeight float64
 
-	if viewBox, ok := root.Attributes["viewBox"]; ok {
-		parts := strings.Fields(viewBox)
-		if len(parts) == 4 {
-			viewBoxWidth = parseLengthFloat(parts[2], dpi)
-			viewBoxHeight = parseLengthFloat(parts[3], dpi)
-		}
+	if vb, ok := parseViewBox(root.Attributes["viewBox"]); ok {
+		viewBoxWidth = vb[2]
+		viewBoxHeight = vb[3]
 	}
 
 	// Try to get from attributes
@@ -396,41 +523,66 @@ func absoluteLengthFromUnit(value float64, unitSuffix string) (units.Length, boo
 
 // renderElement renders an SVG element to the image.
 func renderElement(elem *svgElement, img *image.RGBA, rasterizer *vector.Rasterizer, width, height int, dpi float64, state *rasterRenderState) error {
-	switch elem.Tag {
-	case "svg":
-		// Render children
-		for _, child := range elem.Children {
-			if err := renderElement(child, img, rasterizer, width, height, dpi, state); err != nil {
-				return err
-			}
+	if v, ok := elem.Attributes["transform"]; ok {
+		// An invalid transform list disables the transform, as in browsers.
+		if m, err := ParseTransform(v); err == nil {
+			state.pushTransform(m)
+			defer state.popTransform()
 		}
+	}
 
-	case "rect":
-		if state.inDefs() {
-			return nil
+	if !state.inDefs() && elem.Tag != "defs" && elem.Tag != "clipPath" {
+		if clip := state.clipPathFor(elem); clip != nil {
+			state.pushClip(state.clipMask(elem, clip, state.transform(), rasterizer, img.Bounds(), nil))
+			defer state.popClip()
 		}
-		return renderRect(elem, img, rasterizer, width, height, dpi)
 
-	case "circle":
-		if state.inDefs() {
-			return nil
+		// Opacity applies to the element as a whole, so it is rendered into
+		// an offscreen layer that is composited back with the opacity.
+		if v, ok := declaredProperties(elem)["opacity"]; ok {
+			opacity := parseOpacity(v)
+			if opacity <= 0 {
+				return nil
+			}
+			if opacity < 1 {
+				layer := image.NewRGBA(img.Bounds())
+				defer compositeLayer(img, layer, opacity)
+				img = layer
+			}
 		}
-		return renderCircle(elem, img, rasterizer, width, height, dpi)
+	}
 
-	case "line":
-		if state.inDefs() {
-			return nil
+	switch elem.Tag {
+	case "svg":
+		m, size := svgViewport(elem, len(state.viewports) == 0, state.viewport(), width, height, dpi)
+		state.pushTransform(m)
+		defer state.popTransform()
+		state.pushViewport(size)
+		defer state.popViewport()
+		state.pushStyle(elem)
+		defer state.popStyle()
+		for _, child := range elem.Children {
+			if err := renderElement(child, img, rasterizer, width, height, dpi, state); err != nil {
+				return err
+			}
 		}
-		return renderLine(elem, img, width, height, dpi)
 
 	case "g":
-		// Group - render children
+		// Render children with inherited presentation attributes
+		state.pushStyle(elem)
+		defer state.popStyle()
 		for _, child := range elem.Children {
 			if err := renderElement(child, img, rasterizer, width, height, dpi, state); err != nil {
 				return err
 			}
 		}
 
+	case "rect", "circle", "ellipse", "line", "polyline", "polygon", "path":
+		if state.inDefs() {
+			return nil
+		}
+		return renderShape(elem, img, rasterizer, width, height, dpi, state)
+
 	case "defs", "clipPath":
 		state.pushDefs()
 		defer state.popDefs()
@@ -440,18 +592,18 @@ func renderElement(elem *svgElement, img *image.RGBA, rasterizer *vector.Rasteri
 			}
 		}
 
-	case "style", "linearGradient", "radialGradient", "stop", "title", "desc", "metadata":
-		// Intentionally ignored non-rendering definitions/metadata.
+	case "style", "linearGradient", "radialGradient", "stop", "marker", "title", "desc", "metadata":
+		// Non-rendering definitions/metadata. Gradients and markers are
+		// looked up by id when an element references them.
 
 	case "text":
-		if !state.inDefs() {
-			state.addUnsupported("text")
+		if state.inDefs() {
+			return nil
 		}
+		return renderText(elem, img, rasterizer, dpi, state)
 
-	case "path":
-		if !state.inDefs() {
-			state.addUnsupported("path")
-		}
+	case charDataTag:
+		// Character data is rendered by its enclosing text element.
 
 	default:
 		// Unknown or unsupported element, continue rendering children
@@ -468,160 +620,322 @@ func renderElement(elem *svgElement, img *image.RGBA, rasterizer *vector.Rasteri
 	return nil
 }
 
-// renderRect renders a rectangle
-func renderRect(elem *svgElement, img *image.RGBA, rasterizer *vector.Rasterizer, width, height int, dpi float64) error {
-	_ = rasterizer
-	if width <= 0 || height <= 0 {
+// compositeLayer draws an offscreen layer onto dst with the given opacity.
+func compositeLayer(dst, layer *image.RGBA, opacity float64) {
+	mask := image.NewUniform(color.Alpha{A: uint8(math.Round(opacity * 255))})
+	draw.DrawMask(dst, dst.Bounds(), layer, layer.Bounds().Min, mask, image.Point{}, draw.Over)
+}
+
+// renderShape fills and strokes a basic shape or path element and draws its
+// markers.
+func renderShape(elem *svgElement, img *image.RGBA, rasterizer *vector.Rasterizer, width, height int, dpi float64, state *rasterRenderState) error {
+	if img.Bounds().Empty() {
 		return nil
 	}
-	x := parseLengthFloatWithReference(elem.Attributes["x"], dpi, float64(width))
-	y := parseLengthFloatWithReference(elem.Attributes["y"], dpi, float64(height))
-	w := parseLengthFloatWithReference(elem.Attributes["width"], dpi, float64(width))
-	h := parseLengthFloatWithReference(elem.Attributes["height"], dpi, float64(height))
 
-	fillColor := parseColor(elem.Attributes["fill"])
-	if isTransparent(fillColor) || w <= 0 || h <= 0 {
+	viewport := state.viewport()
+	segs := shapeSegments(elem, viewport.X, viewport.Y, dpi)
+	if len(segs) == 0 {
 		return nil
 	}
 
-	left := int(math.Floor(x))
-	top := int(math.Floor(y))
-	right := int(math.Ceil(x + w))
-	bottom := int(math.Ceil(y + h))
-
-	// Draw rectangle
-	rect := image.Rect(left, top, right, bottom)
-	draw.Draw(img, rect, &image.Uniform{fillColor}, image.Point{}, draw.Over)
+	bbox, _ := segmentsBounds(segs)
+	props := state.computedStyle(elem)
+	// Lines have no interior, so only their stroke is painted.
+	paintPath(segs, bbox, props, elem.Tag != "line", img, rasterizer, dpi, state)
 
+	switch elem.Tag {
+	case "path", "line", "polyline", "polygon":
+		return renderMarkers(segs, props, img, rasterizer, width, height, dpi, state)
+	}
 	return nil
 }
 
-// renderCircle renders a circle
-func renderCircle(elem *svgElement, img *image.RGBA, rasterizer *vector.Rasterizer, width, height int, dpi float64) error {
-	if width <= 0 || height <= 0 {
-		return nil
+// paintPath fills and strokes user-space path segments using the given
+// presentation properties and the current transform. bbox is the bounding
+// box of the element the segments belong to.
+func paintPath(segs []pathSegment, bbox boundingBox, props map[string]string, fill bool, img *image.RGBA, rasterizer *vector.Rasterizer, dpi float64, state *rasterRenderState) {
+	m := state.transform()
+	scale := m.maxScale()
+	if scale == 0 {
+		return
 	}
-	cx := parseLengthFloatWithReference(elem.Attributes["cx"], dpi, float64(width))
-	cy := parseLengthFloatWithReference(elem.Attributes["cy"], dpi, float64(height))
-	r := parseLengthFloatWithReference(elem.Attributes["r"], dpi, math.Min(float64(width), float64(height)))
 
-	fillColor := parseColor(elem.Attributes["fill"])
-	if isTransparent(fillColor) || r <= 0 {
-		return nil
+	if fill {
+		if src := state.paintSource(props, "fill", bbox); src != nil {
+			state.fillPath(img, rasterizer, transformSegments(segs, m), isEvenOdd(props["fill-rule"]), src)
+		}
+	}
+
+	strokeSrc := state.paintSource(props, "stroke", bbox)
+	if strokeSrc == nil {
+		return
 	}
+	viewport := state.viewport()
+	stroke, ok := parseStrokeStyle(props, viewport.X, viewport.Y, dpi)
+	if !ok {
+		return
+	}
+
+	// Stroke in user space and transform the outline afterwards, so that
+	// non-uniform scales and skews distort the stroke like in browsers.
+	tolerance := flattenTolerance / scale
+	polys := strokePolygons(flattenPath(segs, tolerance), stroke, tolerance)
+	if len(polys) == 0 {
+		return
+	}
+	state.fillPolygons(img, rasterizer, transformPolygons(polys, m), strokeSrc)
+}
+
+// shapeSegments converts a basic shape or path element into path segments.
+// Percentage lengths resolve against the viewport size w×h.
+func shapeSegments(elem *svgElement, w, h, dpi float64) []pathSegment {
+	length := func(name string, reference float64) float64 {
+		return parseLengthFloatWithReference(elem.Attributes[name], dpi, reference)
+	}
+
+	switch elem.Tag {
+	case "rect":
+		x, y := length("x", w), length("y", h)
+		rw, rh := length("width", w), length("height", h)
+		if rw <= 0 || rh <= 0 {
+			return nil
+		}
+		rx, rxOK := parseRadius(elem.Attributes["rx"], dpi, w)
+		ry, ryOK := parseRadius(elem.Attributes["ry"], dpi, h)
+		if !rxOK {
+			rx = ry
+		}
+		if !ryOK {
+			ry = rx
+		}
+		return rectSegments(x, y, rw, rh, math.Min(rx, rw/2), math.Min(ry, rh/2))
 
-	// Use vector rasterizer for smooth circles
-	rasterizer.Reset(img.Bounds().Dx(), img.Bounds().Dy())
-	rasterizer.DrawOp = draw.Over
+	case "circle":
+		r := length("r", math.Min(w, h))
+		if r <= 0 {
+			return nil
+		}
+		return ellipseSegments(length("cx", w), length("cy", h), r, r)
 
-	// Draw circle using arc approximation
-	drawCircle(rasterizer, float32(cx), float32(cy), float32(r))
+	case "ellipse":
+		rx, rxOK := parseRadius(elem.Attributes["rx"], dpi, w)
+		ry, ryOK := parseRadius(elem.Attributes["ry"], dpi, h)
+		if !rxOK {
+			rx = ry
+		}
+		if !ryOK {
+			ry = rx
+		}
+		if rx <= 0 || ry <= 0 {
+			return nil
+		}
+		return ellipseSegments(length("cx", w), length("cy", h), rx, ry)
 
-	// Rasterize
-	src := image.NewUniform(fillColor)
-	rasterizer.Draw(img, img.Bounds(), src, image.Point{})
+	case "line":
+		return []pathSegment{
+			{Op: pathOpMoveTo, Pts: [3]Point{{X: length("x1", w), Y: length("y1", h)}}},
+			{Op: pathOpLineTo, Pts: [3]Point{{X: length("x2", w), Y: length("y2", h)}}},
+		}
+
+	case "polyline", "polygon":
+		points := parsePoints(elem.Attributes["points"])
+		if len(points) == 0 {
+			return nil
+		}
+		segs := make([]pathSegment, 0, len(points)+1)
+		segs = append(segs, pathSegment{Op: pathOpMoveTo, Pts: [3]Point{points[0]}})
+		for _, p := range points[1:] {
+			segs = append(segs, pathSegment{Op: pathOpLineTo, Pts: [3]Point{p}})
+		}
+		if elem.Tag == "polygon" {
+			segs = append(segs, pathSegment{Op: pathOpClose})
+		}
+		return segs
+
+	case "path":
+		// Per the SVG error handling rules, render everything up to the first
+		// error in the path data.
+		segs, _ := parsePathData(elem.Attributes["d"])
+		return segs
+	}
 
 	return nil
 }
 
-// renderLine renders a line
-func renderLine(elem *svgElement, img *image.RGBA, width, height int, dpi float64) error {
-	if width <= 0 || height <= 0 {
-		return nil
+// parseRadius parses an rx/ry attribute. The second result is false when the
+// attribute is absent or "auto".
+func parseRadius(s string, dpi, reference float64) (float64, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "auto" {
+		return 0, false
 	}
-	x1 := parseLengthFloatWithReference(elem.Attributes["x1"], dpi, float64(width))
-	y1 := parseLengthFloatWithReference(elem.Attributes["y1"], dpi, float64(height))
-	x2 := parseLengthFloatWithReference(elem.Attributes["x2"], dpi, float64(width))
-	y2 := parseLengthFloatWithReference(elem.Attributes["y2"], dpi, float64(height))
-
-	strokeColor := parseColor(elem.Attributes["stroke"])
-	strokeWidth := parseLengthFloatWithReference(elem.Attributes["stroke-width"], dpi, math.Min(float64(width), float64(height)))
-	if strokeWidth <= 0 {
-		strokeWidth = 1
+	r := parseLengthFloatWithReference(s, dpi, reference)
+	if r < 0 {
+		return 0, false
 	}
-	if isTransparent(strokeColor) {
-		return nil
+	return r, true
+}
+
+// rectSegments returns the outline of a (possibly rounded) rectangle.
+func rectSegments(x, y, w, h, rx, ry float64) []pathSegment {
+	if rx <= 0 || ry <= 0 {
+		return []pathSegment{
+			{Op: pathOpMoveTo, Pts: [3]Point{{X: x, Y: y}}},
+			{Op: pathOpLineTo, Pts: [3]Point{{X: x + w, Y: y}}},
+			{Op: pathOpLineTo, Pts: [3]Point{{X: x + w, Y: y + h}}},
+			{Op: pathOpLineTo, Pts: [3]Point{{X: x, Y: y + h}}},
+			{Op: pathOpClose},
+		}
 	}
 
-	drawThickLine(img, x1, y1, x2, y2, strokeWidth, strokeColor)
+	segs := []pathSegment{{Op: pathOpMoveTo, Pts: [3]Point{{X: x + rx, Y: y}}}}
+	corner := func(from, to Point) {
+		segs = append(segs, arcToCubics(from, rx, ry, 0, false, true, to)...)
+	}
+	line := func(p Point) {
+		segs = append(segs, pathSegment{Op: pathOpLineTo, Pts: [3]Point{p}})
+	}
+	line(Point{X: x + w - rx, Y: y})
+	corner(Point{X: x + w - rx, Y: y}, Point{X: x + w, Y: y + ry})
+	line(Point{X: x + w, Y: y + h - ry})
+	corner(Point{X: x + w, Y: y + h - ry}, Point{X: x + w - rx, Y: y + h})
+	line(Point{X: x + rx, Y: y + h})
+	corner(Point{X: x + rx, Y: y + h}, Point{X: x, Y: y + h - ry})
+	line(Point{X: x, Y: y + ry})
+	corner(Point{X: x, Y: y + ry}, Point{X: x + rx, Y: y})
+	return append(segs, pathSegment{Op: pathOpClose})
+}
 
-	return nil
+// ellipseSegments returns the outline of an ellipse, starting at its rightmost point.
+func ellipseSegments(cx, cy, rx, ry float64) []pathSegment {
+	right := Point{X: cx + rx, Y: cy}
+	left := Point{X: cx - rx, Y: cy}
+	segs := []pathSegment{{Op: pathOpMoveTo, Pts: [3]Point{right}}}
+	segs = append(segs, arcToCubics(right, rx, ry, 0, false, true, left)...)
+	segs = append(segs, arcToCubics(left, rx, ry, 0, false, true, right)...)
+	return append(segs, pathSegment{Op: pathOpClose})
 }
 
-func isTransparent(c color.Color) bool {
-	_, _, _, a := c.RGBA()
-	return a == 0
+// parsePoints parses a polyline/polygon points list. A trailing odd
+// coordinate is ignored.
+func parsePoints(s string) []Point {
+	sc := &pathDataScanner{s: s}
+	var pts []Point
+	for {
+		sc.skipSeparators()
+		if sc.done() {
+			break
+		}
+		p, err := sc.point()
+		if err != nil {
+			break
+		}
+		pts = append(pts, p)
+	}
+	return pts
 }
 
-func drawThickLine(img *image.RGBA, x1, y1, x2, y2, width float64, c color.Color) {
-	dx := x2 - x1
-	dy := y2 - y1
-	steps := int(math.Max(math.Abs(dx), math.Abs(dy)))
+// parseStrokeStyle resolves stroke properties. It returns false when the
+// stroke has no visible width.
+func parseStrokeStyle(props map[string]string, width, height, dpi float64) (strokeStyle, bool) {
+	diagonal := math.Hypot(width, height) / math.Sqrt2
+	style := strokeStyle{
+		Width:      1,
+		Linecap:    StrokeLinecapButt,
+		Linejoin:   StrokeLinejoinMiter,
+		MiterLimit: 4,
+	}
+
+	if v, ok := props["stroke-width"]; ok {
+		style.Width = parseLengthFloatWithReference(v, dpi, math.Min(width, height))
+		if style.Width <= 0 {
+			return style, false
+		}
+	}
 
-	brush := int(math.Round(width))
-	if brush < 1 {
-		brush = 1
+	switch StrokeLinecap(strings.TrimSpace(props["stroke-linecap"])) {
+	case StrokeLinecapRound:
+		style.Linecap = StrokeLinecapRound
+	case StrokeLinecapSquare:
+		style.Linecap = StrokeLinecapSquare
 	}
-	half := brush / 2
 
-	if steps == 0 {
-		drawBrush(img, int(math.Round(x1)), int(math.Round(y1)), brush, half, c)
-		return
+	switch StrokeLinejoin(strings.TrimSpace(props["stroke-linejoin"])) {
+	case StrokeLinejoinRound:
+		style.Linejoin = StrokeLinejoinRound
+	case StrokeLinejoinBevel:
+		style.Linejoin = StrokeLinejoinBevel
 	}
 
-	for i := 0; i <= steps; i++ {
-		t := float64(i) / float64(steps)
-		x := x1 + dx*t
-		y := y1 + dy*t
-		drawBrush(img, int(math.Round(x)), int(math.Round(y)), brush, half, c)
+	if v, err := strconv.ParseFloat(strings.TrimSpace(props["stroke-miterlimit"]), 64); err == nil && v >= 1 {
+		style.MiterLimit = v
 	}
+
+	style.DashArray = parseDashArray(props["stroke-dasharray"], dpi, diagonal)
+	if len(style.DashArray) > 0 {
+		style.DashOffset = parseLengthFloatWithReference(props["stroke-dashoffset"], dpi, diagonal)
+	}
+
+	return style, true
 }
 
-func drawBrush(img *image.RGBA, cx, cy, brush, half int, c color.Color) {
-	rgba := color.RGBAModel.Convert(c).(color.RGBA)
-	for oy := -half; oy < brush-half; oy++ {
-		for ox := -half; ox < brush-half; ox++ {
-			x := cx + ox
-			y := cy + oy
-			if !image.Pt(x, y).In(img.Bounds()) {
-				continue
-			}
-			i := img.PixOffset(x, y)
-			if rgba.A == 255 {
-				img.Pix[i+0] = rgba.R
-				img.Pix[i+1] = rgba.G
-				img.Pix[i+2] = rgba.B
-				img.Pix[i+3] = 255
-				continue
-			}
+// parseDashArray parses stroke-dasharray. It returns nil for "none" and for
+// invalid lists (negative values or a zero total), which render solid.
+func parseDashArray(s string, dpi, reference float64) []float64 {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "none" {
+		return nil
+	}
 
-			dstR := img.Pix[i+0]
-			dstG := img.Pix[i+1]
-			dstB := img.Pix[i+2]
-			dstA := img.Pix[i+3]
-
-			srcA := int(rgba.A)
-			invA := 255 - srcA
-
-			outA := srcA + (int(dstA)*invA+127)/255
-			if outA == 0 {
-				img.Pix[i+0] = 0
-				img.Pix[i+1] = 0
-				img.Pix[i+2] = 0
-				img.Pix[i+3] = 0
-				continue
-			}
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	dashes := make([]float64, 0, len(fields)*2)
+	total := 0.0
+	for _, f := range fields {
+		v := parseLengthFloatWithReference(f, dpi, reference)
+		if v < 0 || (v == 0 && !isZeroLength(f)) {
+			return nil
+		}
+		dashes = append(dashes, v)
+		total += v
+	}
+	if total <= 0 {
+		return nil
+	}
+	if len(dashes)%2 == 1 {
+		dashes = append(dashes, dashes...)
+	}
+	return dashes
+}
 
-			outR := ((int(rgba.R)*srcA + int(dstR)*invA) + 127) / 255
-			outG := ((int(rgba.G)*srcA + int(dstG)*invA) + 127) / 255
-			outB := ((int(rgba.B)*srcA + int(dstB)*invA) + 127) / 255
+// isZeroLength reports whether s is a literal zero length rather than an
+// unparseable value.
+func isZeroLength(s string) bool {
+	v, err := strconv.ParseFloat(strings.TrimRight(strings.TrimSpace(s), "abcdefghijklmnopqrstuvwxyz%"), 64)
+	return err == nil && v == 0
+}
 
-			img.Pix[i+0] = uint8(outR)
-			img.Pix[i+1] = uint8(outG)
-			img.Pix[i+2] = uint8(outB)
-			img.Pix[i+3] = uint8(outA)
+// parsePaint resolves a fill or stroke paint to a color. A missing fill
+// defaults to black and a missing stroke to none, as in SVG.
+func parsePaint(props map[string]string, name string) color.Color {
+	value, ok := props[name]
+	if !ok {
+		if name == "fill" {
+			return color.Black
 		}
+		return color.Transparent
 	}
+	if strings.EqualFold(strings.TrimSpace(value), "currentColor") {
+		value = props["color"]
+	}
+	return parseColor(value)
+}
+
+func isTransparent(c color.Color) bool {
+	_, _, _, a := c.RGBA()
+	return a == 0
 }
 
 // parseColor parses a color string (hex or named)
@@ -646,22 +960,6 @@ func parseColor(s string) color.Color {
 	}
 }
 
-// drawCircle draws a circle using the vector rasterizer
-func drawCircle(r *vector.Rasterizer, cx, cy, radius float32) {
-	const segments = 32
-
-	r.MoveTo(cx+radius, cy)
-
-	for i := 1; i <= segments; i++ {
-		angle := float64(i) * 2.0 * math.Pi / float64(segments)
-		x := cx + radius*float32(math.Cos(angle))
-		y := cy + radius*float32(math.Sin(angle))
-		r.LineTo(x, y)
-	}
-
-	r.ClosePath()
-}
-
 // GetMimeType returns the MIME type for a format
 func GetMimeType(format ExportFormat) string {
 	switch format {
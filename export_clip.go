@@ -0,0 +1,153 @@
+package svg
+
+import (
+	"image"
+	"math"
+	"strings"
+
+	"golang.org/x/image/vector"
+)
+
+// clipPathFor returns the <clipPath> element referenced by the clip-path
+// property of elem. Invalid references are ignored, as if no clip-path was
+// specified.
+func (s *rasterRenderState) clipPathFor(elem *svgElement) *svgElement {
+	value := declaredProperties(elem)["clip-path"]
+	if value == "" || value == "none" {
+		return nil
+	}
+	clip := s.lookupID(value)
+	if clip == nil || clip.Tag != "clipPath" {
+		return nil
+	}
+	return clip
+}
+
+// clipMask rasterizes the coverage of the clipPath clip applied to target,
+// whose user space maps to device space through base. The result is the union
+// of the clip children, each honoring its clip-rule and own clip-path, and
+// intersected with the clip-path of the clipPath element itself.
+func (s *rasterRenderState) clipMask(target, clip *svgElement, base Matrix, rasterizer *vector.Rasterizer, bounds image.Rectangle, visited map[*svgElement]bool) *image.Alpha {
+	mask := image.NewAlpha(bounds)
+	// A clipPath that references itself is an error and clips everything.
+	if visited[clip] {
+		return mask
+	}
+	if visited == nil {
+		visited = make(map[*svgElement]bool)
+	}
+	visited[clip] = true
+	defer delete(visited, clip)
+
+	m := base
+	if v, ok := clip.Attributes["transform"]; ok {
+		if t, err := ParseTransform(v); err == nil {
+			m = m.Multiply(t)
+		}
+	}
+	if strings.TrimSpace(clip.Attributes["clipPathUnits"]) == "objectBoundingBox" {
+		bbox, ok := s.elementBounds(target)
+		if !ok || bbox.width() <= 0 || bbox.height() <= 0 {
+			return mask
+		}
+		m = m.Multiply(TranslateMatrix(bbox.Min.X, bbox.Min.Y)).Multiply(ScaleMatrix(bbox.width(), bbox.height()))
+	}
+
+	s.pushStyle(clip)
+	for _, child := range clip.Children {
+		segs := s.clipChildSegments(child)
+		if len(segs) == 0 {
+			continue
+		}
+		props := s.computedStyle(child)
+		if v := props["visibility"]; v == "hidden" || v == "collapse" || props["display"] == "none" {
+			continue
+		}
+
+		childM := m
+		if v, ok := child.Attributes["transform"]; ok {
+			if t, err := ParseTransform(v); err == nil {
+				childM = childM.Multiply(t)
+			}
+		}
+		coverage := pathCoverage(rasterizer, transformSegments(segs, childM), isEvenOdd(props["clip-rule"]), bounds)
+		if nested := s.clipPathFor(child); nested != nil {
+			intersectMask(coverage, s.clipMask(child, nested, childM, rasterizer, bounds, visited))
+		}
+		unionMask(mask, coverage)
+	}
+	s.popStyle()
+
+	if nested := s.clipPathFor(clip); nested != nil {
+		intersectMask(mask, s.clipMask(target, nested, base, rasterizer, bounds, visited))
+	}
+	return mask
+}
+
+// clipChildSegments returns the user-space outline of a clipPath child. Only
+// shapes and text contribute to a clipping path.
+func (s *rasterRenderState) clipChildSegments(child *svgElement) []pathSegment {
+	switch child.Tag {
+	case "rect", "circle", "ellipse", "line", "polyline", "polygon", "path":
+		viewport := s.viewport()
+		return shapeSegments(child, viewport.X, viewport.Y, s.dpi)
+	case "text":
+		var segs []pathSegment
+		for _, run := range layoutText(child, s.dpi, s) {
+			segs = append(segs, run.segs...)
+		}
+		return segs
+	}
+	return nil
+}
+
+// elementBounds returns the user-space bounding box of the geometry of elem,
+// including the transforms of its descendants.
+func (s *rasterRenderState) elementBounds(elem *svgElement) (boundingBox, bool) {
+	switch elem.Tag {
+	case "rect", "circle", "ellipse", "line", "polyline", "polygon", "path", "text":
+		return segmentsBounds(s.clipChildSegments(elem))
+	case "g", "a", "switch":
+		s.pushStyle(elem)
+		defer s.popStyle()
+
+		var bounds boundingBox
+		found := false
+		for _, child := range elem.Children {
+			b, ok := s.elementBounds(child)
+			if !ok {
+				continue
+			}
+			if v, ok := child.Attributes["transform"]; ok {
+				if t, err := ParseTransform(v); err == nil {
+					b = transformBounds(b, t)
+				}
+			}
+			if !found {
+				bounds, found = b, true
+				continue
+			}
+			bounds = unionBounds(bounds, b)
+		}
+		return bounds, found
+	}
+	return boundingBox{}, false
+}
+
+// transformBounds returns the bounding box of the transformed corners of b.
+func transformBounds(b boundingBox, m Matrix) boundingBox {
+	corners := []Point{b.Min, {X: b.Max.X, Y: b.Min.Y}, b.Max, {X: b.Min.X, Y: b.Max.Y}}
+	p := m.Apply(corners[0])
+	out := boundingBox{Min: p, Max: p}
+	for _, c := range corners[1:] {
+		out = unionBounds(out, boundingBox{Min: m.Apply(c), Max: m.Apply(c)})
+	}
+	return out
+}
+
+func unionBounds(a, b boundingBox) boundingBox {
+	return boundingBox{
+		Min: Point{X: math.Min(a.Min.X, b.Min.X), Y: math.Min(a.Min.Y, b.Min.Y)},
+		Max: Point{X: math.Max(a.Max.X, b.Max.X), Y: math.Max(a.Max.Y, b.Max.Y)},
+	}
+}
@@ -0,0 +1,131 @@
+package svg
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestExportClipPathCircle(t *testing.T) {
+	cm := NewClipPathManager()
+	id := cm.AddCircle(50, 50, 40)
+	svgData := `<svg width="100" height="100"><defs>` + cm.ToSVGDefs() + `</defs>` +
+		GroupWithClipPath(Rect(0, 0, 100, 100, Style{Fill: "#000000"}), id, Style{}) +
+		`</svg>`
+
+	visible := exportVisiblePixels(t, svgData, 100, 100)
+	want := math.Pi * 40 * 40
+	if math.Abs(float64(visible)-want) > want*0.03 {
+		t.Fatalf("expected about %.0f clipped pixels, got %d", want, visible)
+	}
+}
+
+func TestExportClipPathObjectBoundingBoxUnits(t *testing.T) {
+	svgData := `<svg width="100" height="100">
+		<clipPath id="c" clipPathUnits="objectBoundingBox"><rect width="0.5" height="0.5"/></clipPath>
+		<rect x="20" y="20" width="60" height="40" clip-path="url(#c)"/>
+	</svg>`
+
+	img := exportImage(t, svgData, 100, 100)
+	if a := rgbaAt(img, 30, 30).A; a != 255 {
+		t.Errorf("expected top-left quarter to be painted, got alpha %d", a)
+	}
+	if a := rgbaAt(img, 60, 30).A; a != 0 {
+		t.Errorf("expected right half to be clipped, got alpha %d", a)
+	}
+	if a := rgbaAt(img, 30, 50).A; a != 0 {
+		t.Errorf("expected bottom half to be clipped, got alpha %d", a)
+	}
+}
+
+func TestExportClipRule(t *testing.T) {
+	clip := func(rule string) string {
+		return `<svg width="100" height="100">
+			<clipPath id="c"><path clip-rule="` + rule + `" d="M10 10H90V90H10Z M30 30H70V70H30Z"/></clipPath>
+			<rect width="100" height="100" clip-path="url(#c)"/>
+		</svg>`
+	}
+
+	if a := rgbaAt(exportImage(t, clip("nonzero"), 100, 100), 50, 50).A; a != 255 {
+		t.Errorf("nonzero clip should include the inner square, got alpha %d", a)
+	}
+	evenOdd := exportImage(t, clip("evenodd"), 100, 100)
+	if a := rgbaAt(evenOdd, 50, 50).A; a != 0 {
+		t.Errorf("evenodd clip should exclude the inner square, got alpha %d", a)
+	}
+	if a := rgbaAt(evenOdd, 20, 20).A; a != 255 {
+		t.Errorf("evenodd clip should include the outer ring, got alpha %d", a)
+	}
+}
+
+func TestExportNestedClipPaths(t *testing.T) {
+	// The group clip, the element clip and the clip on the clipPath element
+	// all intersect, leaving only the square from (40,40) to (60,60).
+	svgData := `<svg width="100" height="100">
+		<clipPath id="left"><rect width="60" height="100"/></clipPath>
+		<clipPath id="top" clip-path="url(#right)"><rect width="100" height="60"/></clipPath>
+		<clipPath id="right"><rect x="40" width="60" height="100"/></clipPath>
+		<clipPath id="bottom"><rect y="40" width="100" height="60"/></clipPath>
+		<g clip-path="url(#left)">
+			<g clip-path="url(#bottom)">
+				<rect width="100" height="100" clip-path="url(#top)"/>
+			</g>
+		</g>
+	</svg>`
+
+	if visible := exportVisiblePixels(t, svgData, 100, 100); visible != 400 {
+		t.Fatalf("expected 20x20 intersection, got %d pixels", visible)
+	}
+}
+
+func TestExportClipPathFollowsElementTransform(t *testing.T) {
+	svgData := `<svg width="100" height="100">
+		<clipPath id="c"><rect width="10" height="10"/></clipPath>
+		<rect width="10" height="10" transform="translate(50 50) scale(2)" clip-path="url(#c)"/>
+	</svg>`
+
+	img := exportImage(t, svgData, 100, 100)
+	if a := rgbaAt(img, 65, 65).A; a != 255 {
+		t.Errorf("expected clip to move with the element, got alpha %d", a)
+	}
+	if visible := exportVisiblePixels(t, svgData, 100, 100); visible != 400 {
+		t.Errorf("expected 20x20 visible pixels, got %d", visible)
+	}
+}
+
+func TestExportInvalidClipPathReferenceIsIgnored(t *testing.T) {
+	svgData := `<svg width="10" height="10"><rect width="10" height="10" clip-path="url(#missing)"/></svg>`
+	if visible := exportVisiblePixels(t, svgData, 10, 10); visible != 100 {
+		t.Fatalf("expected unclipped rect, got %d pixels", visible)
+	}
+}
+
+func TestExportFillRuleEvenOdd(t *testing.T) {
+	svgData := `<svg width="100" height="100">
+		<path fill-rule="evenodd" d="M10 10H90V90H10Z M30 30H70V70H30Z"/>
+	</svg>`
+	if visible := exportVisiblePixels(t, svgData, 100, 100); visible != 80*80-40*40 {
+		t.Fatalf("expected ring of %d pixels, got %d", 80*80-40*40, visible)
+	}
+}
+
+func TestRasterizeScanlinesPartialCoverage(t *testing.T) {
+	mask := image.NewAlpha(image.Rect(0, 0, 4, 4))
+	square := []flattenedPath{{Points: []Point{{X: 0.5, Y: 0.5}, {X: 2.5, Y: 0.5}, {X: 2.5, Y: 2.5}, {X: 0.5, Y: 2.5}}, Closed: true}}
+	rasterizeScanlines(mask, square, false)
+
+	tests := []struct {
+		x, y int
+		want int
+	}{
+		{0, 0, 64},
+		{1, 1, 255},
+		{1, 0, 128},
+		{3, 3, 0},
+	}
+	for _, tt := range tests {
+		if got := int(mask.AlphaAt(tt.x, tt.y).A); got < tt.want-1 || got > tt.want+1 {
+			t.Errorf("coverage at (%d,%d) = %d, want %d", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
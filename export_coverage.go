@@ -0,0 +1,229 @@
+package svg
+
+import (
+	"image"
+	"image/draw"
+	"math"
+	"sort"
+	"strings"
+
+	"golang.org/x/image/vector"
+)
+
+// coverageSubsamples is the number of sample rows per pixel used by the
+// scanline rasterizer.
+const coverageSubsamples = 16
+
+// isEvenOdd reports whether a fill-rule or clip-rule value selects the
+// even-odd rule. Anything else uses nonzero.
+func isEvenOdd(rule string) bool {
+	return strings.TrimSpace(rule) == "evenodd"
+}
+
+// clip returns the current clip mask, or nil if drawing is unclipped.
+func (s *rasterRenderState) clip() *image.Alpha {
+	if len(s.clips) == 0 {
+		return nil
+	}
+	return s.clips[len(s.clips)-1]
+}
+
+// pushClip intersects mask with the current clip and makes it current.
+func (s *rasterRenderState) pushClip(mask *image.Alpha) {
+	if current := s.clip(); current != nil {
+		intersectMask(mask, current)
+	}
+	s.clips = append(s.clips, mask)
+}
+
+func (s *rasterRenderState) popClip() {
+	if len(s.clips) > 0 {
+		s.clips = s.clips[:len(s.clips)-1]
+	}
+}
+
+// fillPath composites src into img through the coverage of device-space path
+// segments, honoring the fill rule and the current clip.
+func (s *rasterRenderState) fillPath(img *image.RGBA, rasterizer *vector.Rasterizer, segs []pathSegment, evenOdd bool, src image.Image) {
+	if !evenOdd && s.clip() == nil {
+		rasterizer.Reset(img.Bounds().Dx(), img.Bounds().Dy())
+		rasterizer.DrawOp = draw.Over
+		addPathToRasterizer(rasterizer, segs)
+		rasterizer.Draw(img, img.Bounds(), src, image.Point{})
+		return
+	}
+	s.composite(img, pathCoverage(rasterizer, segs, evenOdd, img.Bounds()), src)
+}
+
+// fillPolygons composites src into img through the nonzero coverage of
+// device-space polygons, honoring the current clip.
+func (s *rasterRenderState) fillPolygons(img *image.RGBA, rasterizer *vector.Rasterizer, polys [][]Point, src image.Image) {
+	rasterizer.Reset(img.Bounds().Dx(), img.Bounds().Dy())
+	rasterizer.DrawOp = draw.Over
+	addPolygonsToRasterizer(rasterizer, polys)
+	if s.clip() == nil {
+		rasterizer.Draw(img, img.Bounds(), src, image.Point{})
+		return
+	}
+	mask := image.NewAlpha(img.Bounds())
+	rasterizer.Draw(mask, mask.Bounds(), image.Opaque, image.Point{})
+	s.composite(img, mask, src)
+}
+
+// composite draws src into img through mask and the current clip.
+func (s *rasterRenderState) composite(img *image.RGBA, mask *image.Alpha, src image.Image) {
+	if clip := s.clip(); clip != nil {
+		intersectMask(mask, clip)
+	}
+	draw.DrawMask(img, img.Bounds(), src, image.Point{}, mask, image.Point{}, draw.Over)
+}
+
+// pathCoverage rasterizes device-space path segments into a coverage mask.
+func pathCoverage(rasterizer *vector.Rasterizer, segs []pathSegment, evenOdd bool, bounds image.Rectangle) *image.Alpha {
+	mask := image.NewAlpha(bounds)
+	if evenOdd {
+		rasterizeScanlines(mask, flattenPath(segs, flattenTolerance), true)
+		return mask
+	}
+	rasterizer.Reset(bounds.Dx(), bounds.Dy())
+	rasterizer.DrawOp = draw.Over
+	addPathToRasterizer(rasterizer, segs)
+	rasterizer.Draw(mask, bounds, image.Opaque, image.Point{})
+	return mask
+}
+
+// coverageEdge is a non-horizontal polygon edge oriented top to bottom.
+type coverageEdge struct {
+	x0, y0, x1, y1 float64
+	dir            int
+}
+
+// rasterizeScanlines accumulates the antialiased coverage of closed polygons
+// into mask using either the even-odd or the nonzero winding rule. Each pixel
+// row is sampled at coverageSubsamples rows with exact horizontal coverage.
+func rasterizeScanlines(mask *image.Alpha, paths []flattenedPath, evenOdd bool) {
+	b := mask.Bounds()
+	var edges []coverageEdge
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, path := range paths {
+		pts := path.Points
+		for i := range pts {
+			p, q := pts[i], pts[(i+1)%len(pts)]
+			if p.Y == q.Y {
+				continue
+			}
+			e := coverageEdge{x0: p.X, y0: p.Y, x1: q.X, y1: q.Y, dir: 1}
+			if p.Y > q.Y {
+				e = coverageEdge{x0: q.X, y0: q.Y, x1: p.X, y1: p.Y, dir: -1}
+			}
+			edges = append(edges, e)
+			minY = math.Min(minY, e.y0)
+			maxY = math.Max(maxY, e.y1)
+		}
+	}
+	if len(edges) == 0 {
+		return
+	}
+	sort.Slice(edges, func(i, j int) bool { return edges[i].y0 < edges[j].y0 })
+
+	type crossing struct {
+		x   float64
+		dir int
+	}
+	width := b.Dx()
+	acc := make([]float64, width+1)
+	var active []coverageEdge
+	var crossings []crossing
+	next := 0
+	const weight = 1.0 / coverageSubsamples
+
+	y0 := int(math.Max(float64(b.Min.Y), math.Floor(minY)))
+	y1 := int(math.Min(float64(b.Max.Y), math.Ceil(maxY)))
+	for y := y0; y < y1; y++ {
+		// Keep the edges that overlap this pixel row.
+		kept := active[:0]
+		for _, e := range active {
+			if e.y1 > float64(y) {
+				kept = append(kept, e)
+			}
+		}
+		active = kept
+		for next < len(edges) && edges[next].y0 < float64(y+1) {
+			if edges[next].y1 > float64(y) {
+				active = append(active, edges[next])
+			}
+			next++
+		}
+
+		for i := range acc {
+			acc[i] = 0
+		}
+		for s := 0; s < coverageSubsamples; s++ {
+			sy := float64(y) + (float64(s)+0.5)*weight
+			crossings = crossings[:0]
+			for _, e := range active {
+				if sy < e.y0 || sy >= e.y1 {
+					continue
+				}
+				x := e.x0 + (sy-e.y0)*(e.x1-e.x0)/(e.y1-e.y0)
+				crossings = append(crossings, crossing{x: x - float64(b.Min.X), dir: e.dir})
+			}
+			sort.Slice(crossings, func(i, j int) bool { return crossings[i].x < crossings[j].x })
+
+			winding := 0
+			for i, c := range crossings {
+				if evenOdd {
+					winding ^= 1
+				} else {
+					winding += c.dir
+				}
+				if winding != 0 && i+1 < len(crossings) {
+					addCoverageSpan(acc[:width], c.x, crossings[i+1].x, weight)
+				}
+			}
+		}
+
+		row := mask.Pix[(y-b.Min.Y)*mask.Stride:]
+		for x := 0; x < width; x++ {
+			row[x] = uint8(math.Round(math.Min(1, acc[x]) * 255))
+		}
+	}
+}
+
+// addCoverageSpan adds weight times the covered fraction of each pixel in
+// [x0, x1) to acc.
+func addCoverageSpan(acc []float64, x0, x1, weight float64) {
+	x0 = math.Max(0, x0)
+	x1 = math.Min(float64(len(acc)), x1)
+	if x1 <= x0 {
+		return
+	}
+	i0, i1 := int(x0), int(x1)
+	if i0 == i1 {
+		acc[i0] += (x1 - x0) * weight
+		return
+	}
+	acc[i0] += (float64(i0+1) - x0) * weight
+	for i := i0 + 1; i < i1; i++ {
+		acc[i] += weight
+	}
+	if i1 < len(acc) {
+		acc[i1] += (x1 - float64(i1)) * weight
+	}
+}
+
+// intersectMask multiplies dst by src. Both masks must share bounds.
+func intersectMask(dst, src *image.Alpha) {
+	for i, s := range src.Pix {
+		dst.Pix[i] = uint8((uint32(dst.Pix[i])*uint32(s) + 127) / 255)
+	}
+}
+
+// unionMask combines src into dst as the union of two coverages. Both masks
+// must share bounds.
+func unionMask(dst, src *image.Alpha) {
+	for i, s := range src.Pix {
+		d := uint32(dst.Pix[i])
+		dst.Pix[i] = uint8(d + uint32(s) - (d*uint32(s)+127)/255)
+	}
+}
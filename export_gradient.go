@@ -0,0 +1,432 @@
+package svg
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// gradientLUTSize is the number of precomputed colors along a gradient.
+const gradientLUTSize = 1024
+
+// indexElementIDs maps every id attribute in the tree to its element. The
+// first element wins when ids are duplicated, as in browsers.
+func indexElementIDs(root *svgElement) map[string]*svgElement {
+	ids := make(map[string]*svgElement)
+	var walk func(elem *svgElement)
+	walk = func(elem *svgElement) {
+		if id := elem.Attributes["id"]; id != "" {
+			if _, ok := ids[id]; !ok {
+				ids[id] = elem
+			}
+		}
+		for _, child := range elem.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+	return ids
+}
+
+// lookupID resolves a local IRI reference such as "#grad" or "url(#grad)".
+func (s *rasterRenderState) lookupID(ref string) *svgElement {
+	ref = strings.TrimSpace(ref)
+	if strings.HasPrefix(ref, "url(") && strings.HasSuffix(ref, ")") {
+		ref = strings.Trim(strings.TrimSpace(ref[4:len(ref)-1]), `"'`)
+	}
+	if !strings.HasPrefix(ref, "#") {
+		return nil
+	}
+	return s.ids[ref[1:]]
+}
+
+// paintSource returns the source image for the fill or stroke property in
+// props, or nil if nothing is painted. bbox is the user-space bounding box of
+// the element, used by paint servers in objectBoundingBox units. The matching
+// fill-opacity or stroke-opacity is multiplied into the paint.
+func (s *rasterRenderState) paintSource(props map[string]string, name string, bbox boundingBox) image.Image {
+	opacity := 1.0
+	if v, ok := props[name+"-opacity"]; ok {
+		opacity = parseOpacity(v)
+	}
+	if opacity <= 0 {
+		return nil
+	}
+
+	value := strings.TrimSpace(props[name])
+	if !strings.HasPrefix(value, "url(") {
+		return uniformPaint(parsePaint(props, name), opacity)
+	}
+
+	end := strings.IndexByte(value, ')')
+	if end < 0 {
+		return nil
+	}
+	fallback := strings.TrimSpace(value[end+1:])
+
+	if server := s.lookupID(value[:end+1]); server != nil {
+		switch server.Tag {
+		case "linearGradient", "radialGradient":
+			if g := s.newGradientPaint(server, bbox, opacity); g != nil {
+				return g
+			}
+			return nil
+		}
+	}
+
+	// Missing or unsupported paint servers use the fallback color, if any.
+	if fallback == "" {
+		return nil
+	}
+	return uniformPaint(parsePaint(map[string]string{name: fallback, "color": props["color"]}, name), opacity)
+}
+
+// uniformPaint returns a solid paint of c with opacity applied, or nil if the
+// result is fully transparent.
+func uniformPaint(c color.Color, opacity float64) image.Image {
+	r, g, b, a := straightRGBA(c)
+	if a*opacity <= 0 {
+		return nil
+	}
+	return image.NewUniform(premultiply(r, g, b, a*opacity))
+}
+
+// parseOpacity parses an opacity value given as a number or a percentage,
+// clamped to [0, 1]. Invalid values are fully opaque.
+func parseOpacity(s string) float64 {
+	return math.Max(0, math.Min(1, parseFractionOrPercent(s, 1)))
+}
+
+// gradientChain returns elem followed by the gradients it references through
+// href, stopping at cycles and non-gradient elements.
+func (s *rasterRenderState) gradientChain(elem *svgElement) []*svgElement {
+	chain := []*svgElement{elem}
+	seen := map[*svgElement]bool{elem: true}
+	for {
+		next := s.lookupID(chain[len(chain)-1].Attributes["href"])
+		if next == nil || seen[next] || (next.Tag != "linearGradient" && next.Tag != "radialGradient") {
+			return chain
+		}
+		seen[next] = true
+		chain = append(chain, next)
+	}
+}
+
+// gradientStop is a parsed color stop with a non-premultiplied color.
+type gradientStop struct {
+	offset     float64
+	r, g, b, a float64
+}
+
+// parseGradientStops returns the stops of the first gradient in the chain
+// that has any, with offsets clamped and made monotonic.
+func parseGradientStops(chain []*svgElement) []gradientStop {
+	for _, elem := range chain {
+		var stops []gradientStop
+		for _, child := range elem.Children {
+			if child.Tag != "stop" {
+				continue
+			}
+			props := declaredProperties(child)
+
+			offset := parseFractionOrPercent(props["offset"], 0)
+			offset = math.Max(0, math.Min(1, offset))
+			if n := len(stops); n > 0 && offset < stops[n-1].offset {
+				offset = stops[n-1].offset
+			}
+
+			value, ok := props["stop-color"]
+			if !ok {
+				value = "black"
+			}
+			if strings.EqualFold(strings.TrimSpace(value), "currentColor") {
+				value = props["color"]
+			}
+			r, g, b, a := straightRGBA(parseColor(value))
+			if v, ok := props["stop-opacity"]; ok {
+				a *= parseOpacity(v)
+			}
+			stops = append(stops, gradientStop{offset: offset, r: r, g: g, b: b, a: a})
+		}
+		if len(stops) > 0 {
+			return stops
+		}
+	}
+	return nil
+}
+
+// declaredProperties returns the presentation attributes of elem merged with
+// the declarations of its style attribute.
+func declaredProperties(elem *svgElement) map[string]string {
+	props := make(map[string]string, len(elem.Attributes))
+	for name, value := range elem.Attributes {
+		if name != "style" {
+			props[name] = strings.TrimSpace(value)
+		}
+	}
+	for _, decl := range strings.Split(elem.Attributes["style"], ";") {
+		name, value, ok := strings.Cut(decl, ":")
+		if ok {
+			props[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(value)
+		}
+	}
+	return props
+}
+
+// parseFractionOrPercent parses a number or a percentage as a fraction.
+func parseFractionOrPercent(s string, def float64) float64 {
+	s = strings.TrimSpace(s)
+	percent := strings.HasSuffix(s, "%")
+	v, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil {
+		return def
+	}
+	if percent {
+		return v / 100
+	}
+	return v
+}
+
+// straightRGBA returns the non-premultiplied components of a paint color.
+// parseColor stores straight color in color.RGBA; other colors follow the
+// premultiplied color.Color convention.
+func straightRGBA(c color.Color) (r, g, b, a float64) {
+	if rgba, ok := c.(color.RGBA); ok {
+		return float64(rgba.R) / 255, float64(rgba.G) / 255, float64(rgba.B) / 255, float64(rgba.A) / 255
+	}
+	pr, pg, pb, pa := c.RGBA()
+	if pa == 0 {
+		return 0, 0, 0, 0
+	}
+	return float64(pr) / float64(pa), float64(pg) / float64(pa), float64(pb) / float64(pa), float64(pa) / 0xffff
+}
+
+// gradientPaint is an image that evaluates a linear or radial gradient at
+// device pixel centers.
+type gradientPaint struct {
+	radial bool
+	// Linear gradients run from p1 to p2. Radial gradients interpolate from
+	// the focal circle (focal, fr) to the end circle (center, r).
+	p1, p2        Point
+	center, focal Point
+	r, fr         float64
+	spread        string
+	inverse       Matrix
+	lut           [gradientLUTSize]color.RGBA
+}
+
+// newGradientPaint builds the paint for a gradient element applied to an
+// element with the given bounding box, or returns nil if nothing is painted.
+// opacity is multiplied into every stop.
+func (s *rasterRenderState) newGradientPaint(elem *svgElement, bbox boundingBox, opacity float64) image.Image {
+	chain := s.gradientChain(elem)
+	stops := parseGradientStops(chain)
+	if len(stops) == 0 {
+		return nil
+	}
+
+	// attr returns the first value of name in the chain. Geometry attributes
+	// are only inherited from gradients of the same kind.
+	attr := func(name string, geometry bool) (string, bool) {
+		for _, e := range chain {
+			if geometry && e.Tag != elem.Tag {
+				continue
+			}
+			if v, ok := e.Attributes[name]; ok {
+				return v, true
+			}
+		}
+		return "", false
+	}
+
+	objectBBox := true
+	if v, _ := attr("gradientUnits", false); strings.TrimSpace(v) == "userSpaceOnUse" {
+		objectBBox = false
+	}
+
+	gradientToUser := IdentityMatrix()
+	if objectBBox {
+		if bbox.width() <= 0 || bbox.height() <= 0 {
+			return nil
+		}
+		gradientToUser = TranslateMatrix(bbox.Min.X, bbox.Min.Y).Multiply(ScaleMatrix(bbox.width(), bbox.height()))
+	}
+	if v, ok := attr("gradientTransform", false); ok {
+		if m, err := ParseTransform(v); err == nil {
+			gradientToUser = gradientToUser.Multiply(m)
+		}
+	}
+
+	viewport := s.viewport()
+	diagonal := math.Sqrt((viewport.X*viewport.X + viewport.Y*viewport.Y) / 2)
+	length := func(name, def string, reference float64) float64 {
+		v, ok := attr(name, true)
+		if !ok {
+			v = def
+		}
+		if objectBBox {
+			return parseFractionOrPercent(v, parseFractionOrPercent(def, 0))
+		}
+		return parseLengthFloatWithReference(v, s.dpi, reference)
+	}
+
+	g := &gradientPaint{radial: elem.Tag == "radialGradient"}
+	if v, _ := attr("spreadMethod", false); v != "" {
+		g.spread = strings.TrimSpace(v)
+	}
+
+	last := stops[len(stops)-1]
+	solid := len(stops) == 1
+	if g.radial {
+		g.center = Point{X: length("cx", "50%", viewport.X), Y: length("cy", "50%", viewport.Y)}
+		g.r = length("r", "50%", diagonal)
+		g.focal = g.center
+		if _, ok := attr("fx", true); ok {
+			g.focal.X = length("fx", "50%", viewport.X)
+		}
+		if _, ok := attr("fy", true); ok {
+			g.focal.Y = length("fy", "50%", viewport.Y)
+		}
+		g.fr = length("fr", "0%", diagonal)
+		if g.r < 0 || g.fr < 0 {
+			return nil
+		}
+		// A zero radius paints the area with the last stop color.
+		solid = solid || g.r == 0
+	} else {
+		g.p1 = Point{X: length("x1", "0%", viewport.X), Y: length("y1", "0%", viewport.Y)}
+		g.p2 = Point{X: length("x2", "100%", viewport.X), Y: length("y2", "0%", viewport.Y)}
+		// Coincident endpoints paint the area with the last stop color.
+		solid = solid || g.p1 == g.p2
+	}
+	if solid {
+		return image.NewUniform(premultiply(last.r, last.g, last.b, last.a*opacity))
+	}
+
+	inverse, ok := s.transform().Multiply(gradientToUser).Invert()
+	if !ok {
+		return nil
+	}
+	g.inverse = inverse
+	g.fillLUT(stops, opacity)
+	return g
+}
+
+// fillLUT samples the color stops at evenly spaced offsets, interpolating in
+// non-premultiplied sRGB like browsers do.
+func (g *gradientPaint) fillLUT(stops []gradientStop, opacity float64) {
+	j := 0
+	for i := range g.lut {
+		t := float64(i) / (gradientLUTSize - 1)
+		for j < len(stops) && stops[j].offset < t {
+			j++
+		}
+		var c gradientStop
+		switch {
+		case j == 0:
+			c = stops[0]
+		case j == len(stops):
+			c = stops[len(stops)-1]
+		default:
+			a, b := stops[j-1], stops[j]
+			f := 0.0
+			if b.offset > a.offset {
+				f = (t - a.offset) / (b.offset - a.offset)
+			}
+			c = gradientStop{
+				r: a.r + (b.r-a.r)*f,
+				g: a.g + (b.g-a.g)*f,
+				b: a.b + (b.b-a.b)*f,
+				a: a.a + (b.a-a.a)*f,
+			}
+		}
+		g.lut[i] = premultiply(c.r, c.g, c.b, c.a*opacity)
+	}
+}
+
+func premultiply(r, g, b, a float64) color.RGBA {
+	return color.RGBA{
+		R: uint8(math.Round(r * a * 255)),
+		G: uint8(math.Round(g * a * 255)),
+		B: uint8(math.Round(b * a * 255)),
+		A: uint8(math.Round(a * 255)),
+	}
+}
+
+func (g *gradientPaint) ColorModel() color.Model {
+	return color.RGBAModel
+}
+
+func (g *gradientPaint) Bounds() image.Rectangle {
+	return image.Rect(-1<<30, -1<<30, 1<<30, 1<<30)
+}
+
+func (g *gradientPaint) At(x, y int) color.Color {
+	p := g.inverse.Apply(Point{X: float64(x) + 0.5, Y: float64(y) + 0.5})
+
+	var t float64
+	if g.radial {
+		var ok bool
+		if t, ok = g.radialOffset(p); !ok {
+			return color.RGBA{}
+		}
+	} else {
+		d := Point{X: g.p2.X - g.p1.X, Y: g.p2.Y - g.p1.Y}
+		t = ((p.X-g.p1.X)*d.X + (p.Y-g.p1.Y)*d.Y) / (d.X*d.X + d.Y*d.Y)
+	}
+
+	switch g.spread {
+	case "repeat":
+		t -= math.Floor(t)
+	case "reflect":
+		t = math.Mod(math.Abs(t), 2)
+		if t > 1 {
+			t = 2 - t
+		}
+	default:
+		t = math.Max(0, math.Min(1, t))
+	}
+	return g.lut[int(math.Round(t*(gradientLUTSize-1)))]
+}
+
+// radialOffset returns the largest t for which p lies on the circle
+// interpolated between the focal circle (t = 0) and the end circle (t = 1)
+// with a non-negative radius. It reports false if there is none.
+func (g *gradientPaint) radialOffset(p Point) (float64, bool) {
+	cd := Point{X: g.center.X - g.focal.X, Y: g.center.Y - g.focal.Y}
+	pd := Point{X: p.X - g.focal.X, Y: p.Y - g.focal.Y}
+	dr := g.r - g.fr
+
+	a := cd.X*cd.X + cd.Y*cd.Y - dr*dr
+	b := pd.X*cd.X + pd.Y*cd.Y + g.fr*dr
+	c := pd.X*pd.X + pd.Y*pd.Y - g.fr*g.fr
+
+	valid := func(t float64) bool { return g.fr+t*dr >= 0 }
+
+	if math.Abs(a) < 1e-9 {
+		if b == 0 {
+			return 0, false
+		}
+		t := c / (2 * b)
+		return t, valid(t)
+	}
+
+	disc := b*b - a*c
+	if disc < 0 {
+		return 0, false
+	}
+	sq := math.Sqrt(disc)
+	t1, t2 := (b+sq)/a, (b-sq)/a
+	if t1 < t2 {
+		t1, t2 = t2, t1
+	}
+	if valid(t1) {
+		return t1, true
+	}
+	if valid(t2) {
+		return t2, true
+	}
+	return 0, false
+}
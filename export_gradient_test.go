@@ -0,0 +1,166 @@
+package svg
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func exportImage(t *testing.T, svgData string, width, height int) image.Image {
+	t.Helper()
+
+	result, err := Export(svgData, ExportOptions{Format: FormatPNG, Width: width, Height: height})
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	return decodePNG(t, result)
+}
+
+func rgbaAt(img image.Image, x, y int) color.RGBA {
+	return color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+}
+
+func expectColorNear(t *testing.T, img image.Image, x, y int, want color.RGBA, tolerance int) {
+	t.Helper()
+	if got := rgbaAt(img, x, y); colorDistance(got, want) > tolerance {
+		t.Errorf("pixel (%d,%d) = %v, want about %v", x, y, got, want)
+	}
+}
+
+func TestExportLinearGradientFill(t *testing.T) {
+	svgData := `<svg width="100" height="20"><defs>` +
+		LinearGradient(LinearGradientDef{
+			ID:    "g",
+			Stops: []GradientStop{{Offset: "0%", Color: "#ff0000"}, {Offset: "100%", Color: "#0000ff"}},
+		}) +
+		`</defs>` + Rect(0, 0, 100, 20, Style{Fill: GradientURL("g")}) + `</svg>`
+
+	img := exportImage(t, svgData, 100, 20)
+	expectColorNear(t, img, 0, 10, color.RGBA{R: 255, A: 255}, 12)
+	expectColorNear(t, img, 50, 10, color.RGBA{R: 127, B: 128, A: 255}, 12)
+	expectColorNear(t, img, 99, 10, color.RGBA{B: 255, A: 255}, 12)
+}
+
+func TestExportGradientSpreadMethods(t *testing.T) {
+	gradient := func(spread GradientSpreadMethod) string {
+		return `<svg width="100" height="10"><defs>` +
+			LinearGradient(LinearGradientDef{
+				ID: "g", X2: "25%", SpreadMethod: spread,
+				Stops: []GradientStop{{Offset: "0", Color: "#000000"}, {Offset: "1", Color: "#ffffff"}},
+			}) +
+			`</defs>` + Rect(0, 0, 100, 10, Style{Fill: GradientURL("g")}) + `</svg>`
+	}
+
+	black := color.RGBA{A: 255}
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+
+	pad := exportImage(t, gradient(GradientSpreadPad), 100, 10)
+	expectColorNear(t, pad, 60, 5, white, 8)
+
+	repeat := exportImage(t, gradient(GradientSpreadRepeat), 100, 10)
+	expectColorNear(t, repeat, 26, 5, black, 40)
+	expectColorNear(t, repeat, 49, 5, white, 40)
+
+	reflect := exportImage(t, gradient(GradientSpreadReflect), 100, 10)
+	expectColorNear(t, reflect, 26, 5, white, 40)
+	expectColorNear(t, reflect, 49, 5, black, 40)
+}
+
+func TestExportGradientUserSpaceUnits(t *testing.T) {
+	// In user space the gradient spans the canvas rather than the rect.
+	svgData := `<svg width="100" height="10"><defs>` +
+		LinearGradient(LinearGradientDef{
+			ID: "g", X1: "0", X2: "100", Units: GradientUnitsUserSpaceOnUse,
+			Stops: []GradientStop{{Offset: "0", Color: "#000000"}, {Offset: "1", Color: "#ffffff"}},
+		}) +
+		`</defs>` + Rect(50, 0, 50, 10, Style{Fill: GradientURL("g")}) + `</svg>`
+
+	img := exportImage(t, svgData, 100, 10)
+	expectColorNear(t, img, 50, 5, color.RGBA{R: 128, G: 128, B: 128, A: 255}, 12)
+}
+
+func TestExportGradientHrefInheritance(t *testing.T) {
+	svgData := `<svg width="100" height="10" xmlns:xlink="http://www.w3.org/1999/xlink"><defs>
+		<linearGradient id="base" gradientUnits="userSpaceOnUse" x2="100">
+			<stop offset="0" stop-color="#00ff00"/>
+			<stop offset="1" stop-color="#00ff00" stop-opacity="0"/>
+		</linearGradient>
+		<linearGradient id="derived" xlink:href="#base" x1="50"/>
+		<linearGradient id="cycle" href="#cycle"/>
+	</defs>
+	<rect width="100" height="10" fill="url(#derived)"/>
+	</svg>`
+
+	img := exportImage(t, svgData, 100, 10)
+	// Stops, units and x2 come from the base gradient, x1 from the derived one.
+	expectColorNear(t, img, 40, 5, color.RGBA{G: 255, A: 255}, 8)
+	if a := rgbaAt(img, 99, 5).A; a > 8 {
+		t.Errorf("expected transparent end stop, got alpha %d", a)
+	}
+}
+
+func TestExportRadialGradientFocalPoint(t *testing.T) {
+	svgData := `<svg width="100" height="100"><defs>` +
+		RadialGradient(RadialGradientDef{
+			ID: "g", FX: "25%", FY: "25%",
+			Stops: []GradientStop{{Offset: "0", Color: "#ffffff"}, {Offset: "1", Color: "#000000"}},
+		}) +
+		`</defs>` + Rect(0, 0, 100, 100, Style{Fill: GradientURL("g")}) + `</svg>`
+
+	img := exportImage(t, svgData, 100, 100)
+	expectColorNear(t, img, 25, 25, color.RGBA{R: 255, G: 255, B: 255, A: 255}, 12)
+	expectColorNear(t, img, 50, 50, color.RGBA{R: 170, G: 170, B: 170, A: 255}, 60)
+	expectColorNear(t, img, 99, 50, color.RGBA{A: 255}, 12)
+}
+
+func TestExportGradientTransform(t *testing.T) {
+	// Rotating a horizontal gradient by 90° makes it vertical.
+	svgData := `<svg width="20" height="100"><defs>
+		<linearGradient id="g" gradientTransform="rotate(90 0.5 0.5)">
+			<stop offset="0" stop-color="#ff0000"/><stop offset="1" stop-color="#0000ff"/>
+		</linearGradient>
+	</defs><rect width="20" height="100" fill="url(#g)"/></svg>`
+
+	img := exportImage(t, svgData, 20, 100)
+	expectColorNear(t, img, 10, 0, color.RGBA{R: 255, A: 255}, 12)
+	expectColorNear(t, img, 10, 99, color.RGBA{B: 255, A: 255}, 12)
+}
+
+func TestExportPaintServerFallback(t *testing.T) {
+	svgData := `<svg width="10" height="10">
+		<rect width="5" height="10" fill="url(#missing) #00ff00"/>
+		<rect x="5" width="5" height="10" fill="url(#missing)"/>
+	</svg>`
+
+	img := exportImage(t, svgData, 10, 10)
+	expectColorNear(t, img, 2, 5, color.RGBA{G: 255, A: 255}, 0)
+	if a := rgbaAt(img, 7, 5).A; a != 0 {
+		t.Errorf("expected missing paint server without fallback to paint nothing, got alpha %d", a)
+	}
+}
+
+func TestRadialOffset(t *testing.T) {
+	g := &gradientPaint{radial: true, center: Point{X: 0, Y: 0}, focal: Point{X: 0, Y: 0}, r: 10}
+	tests := []struct {
+		p    Point
+		want float64
+	}{
+		{Point{X: 0, Y: 0}, 0},
+		{Point{X: 5, Y: 0}, 0.5},
+		{Point{X: 0, Y: -10}, 1},
+		{Point{X: 20, Y: 0}, 2},
+	}
+	for _, tt := range tests {
+		got, ok := g.radialOffset(tt.p)
+		if !ok || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("radialOffset(%v) = %v, %v; want %v", tt.p, got, ok, tt.want)
+		}
+	}
+
+	// With the focal point on the edge, points behind it are not painted.
+	g.focal = Point{X: -10, Y: 0}
+	if _, ok := g.radialOffset(Point{X: -15, Y: 0}); ok {
+		t.Error("expected point outside the gradient cone to be unpainted")
+	}
+}
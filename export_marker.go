@@ -0,0 +1,278 @@
+package svg
+
+import (
+	"image"
+	"math"
+	"strings"
+
+	"github.com/SCKelemen/units"
+	"golang.org/x/image/vector"
+)
+
+// markerVertex is a path vertex with the directions of the segments that
+// enter and leave it, used to place and orient markers.
+type markerVertex struct {
+	P       Point
+	In, Out Point
+	HasIn   bool
+	HasOut  bool
+}
+
+// angle returns the marker orientation for orient="auto" in degrees: the
+// bisector of the incoming and outgoing directions, or whichever exists.
+func (v markerVertex) angle() float64 {
+	switch {
+	case v.HasIn && v.HasOut:
+		in := math.Atan2(v.In.Y, v.In.X)
+		out := math.Atan2(v.Out.Y, v.Out.X)
+		diff := math.Remainder(out-in, 2*math.Pi)
+		return (in + diff/2) * 180 / math.Pi
+	case v.HasIn:
+		return math.Atan2(v.In.Y, v.In.X) * 180 / math.Pi
+	case v.HasOut:
+		return math.Atan2(v.Out.Y, v.Out.X) * 180 / math.Pi
+	}
+	return 0
+}
+
+// markerVertices returns the vertices of a path in order: the start of each
+// subpath and the end of each path command. Arcs split into several cubics
+// count as a single command, and closed subpaths join the directions at their
+// start vertex.
+func markerVertices(segs []pathSegment) []markerVertex {
+	var verts []markerVertex
+	var cur, start Point
+	subStart := -1
+	closing := -1
+
+	// finishClose gives the closing vertex of a subpath the outgoing
+	// direction of its first segment, unless drawing continued after it.
+	finishClose := func() {
+		if closing >= 0 && !verts[closing].HasOut && verts[subStart].HasOut {
+			verts[closing].Out, verts[closing].HasOut = verts[subStart].Out, true
+		}
+		closing = -1
+	}
+
+	for _, seg := range segs {
+		if seg.Op == pathOpMoveTo {
+			cur, start = seg.Pts[0], seg.Pts[0]
+			if seg.Continuation {
+				continue
+			}
+			finishClose()
+			verts = append(verts, markerVertex{P: cur})
+			subStart = len(verts) - 1
+			continue
+		}
+		if len(verts) == 0 {
+			verts = append(verts, markerVertex{P: cur})
+			subStart = 0
+		}
+
+		var end, d0, d1 Point
+		switch seg.Op {
+		case pathOpLineTo:
+			end = seg.Pts[0]
+			d0, d1 = sub(end, cur), sub(end, cur)
+		case pathOpQuadTo:
+			end = seg.Pts[1]
+			d0 = firstNonZero(sub(seg.Pts[0], cur), sub(end, cur))
+			d1 = firstNonZero(sub(end, seg.Pts[0]), sub(end, cur))
+		case pathOpCubeTo:
+			end = seg.Pts[2]
+			d0 = firstNonZero(sub(seg.Pts[0], cur), sub(seg.Pts[1], cur), sub(end, cur))
+			d1 = firstNonZero(sub(end, seg.Pts[1]), sub(end, seg.Pts[0]), sub(end, cur))
+		case pathOpClose:
+			end = start
+			d0, d1 = sub(end, cur), sub(end, cur)
+		}
+		nonZero := func(d Point) bool { return d.X != 0 || d.Y != 0 }
+
+		last := &verts[len(verts)-1]
+		if seg.Continuation {
+			last.P = end
+			last.In, last.HasIn = d1, nonZero(d1)
+		} else {
+			if !last.HasOut && nonZero(d0) {
+				last.Out, last.HasOut = d0, true
+			}
+			verts = append(verts, markerVertex{P: end, In: d1, HasIn: nonZero(d1)})
+		}
+
+		if seg.Op == pathOpClose {
+			closing = len(verts) - 1
+			first := &verts[subStart]
+			if in := verts[closing]; in.HasIn {
+				first.In, first.HasIn = in.In, true
+			}
+		}
+		cur = end
+	}
+	if closing >= 0 {
+		finishClose()
+	}
+	return verts
+}
+
+func sub(a, b Point) Point {
+	return Point{X: a.X - b.X, Y: a.Y - b.Y}
+}
+
+func firstNonZero(ds ...Point) Point {
+	for _, d := range ds {
+		if d.X != 0 || d.Y != 0 {
+			return d
+		}
+	}
+	return Point{}
+}
+
+// markerFor returns the <marker> element referenced by a marker property.
+func (s *rasterRenderState) markerFor(props map[string]string, name string) *svgElement {
+	value := props[name]
+	if value == "" || value == "none" {
+		return nil
+	}
+	marker := s.lookupID(value)
+	if marker == nil || marker.Tag != "marker" {
+		return nil
+	}
+	return marker
+}
+
+// renderMarkers draws the marker-start, marker-mid and marker-end markers of
+// a path, line, polyline or polygon at the vertices of its user-space path.
+func renderMarkers(segs []pathSegment, props map[string]string, img *image.RGBA, rasterizer *vector.Rasterizer, width, height int, dpi float64, state *rasterRenderState) error {
+	start := state.markerFor(props, "marker-start")
+	mid := state.markerFor(props, "marker-mid")
+	end := state.markerFor(props, "marker-end")
+	if start == nil && mid == nil && end == nil {
+		return nil
+	}
+
+	verts := markerVertices(segs)
+	if len(verts) == 0 {
+		return nil
+	}
+
+	viewport := state.viewport()
+	strokeWidth := 1.0
+	if v, ok := props["stroke-width"]; ok {
+		strokeWidth = parseLengthFloatWithReference(v, dpi, math.Min(viewport.X, viewport.Y))
+	}
+
+	for i, v := range verts {
+		if i == 0 && start != nil {
+			if err := renderMarker(start, v, true, strokeWidth, img, rasterizer, width, height, dpi, state); err != nil {
+				return err
+			}
+		}
+		if i > 0 && i < len(verts)-1 && mid != nil {
+			if err := renderMarker(mid, v, false, strokeWidth, img, rasterizer, width, height, dpi, state); err != nil {
+				return err
+			}
+		}
+		if i == len(verts)-1 && end != nil {
+			if err := renderMarker(end, v, false, strokeWidth, img, rasterizer, width, height, dpi, state); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// renderMarker draws the content of a marker with its reference point at the
+// vertex. The marker establishes a new viewport sized markerWidth ×
+// markerHeight (scaled by the stroke width unless markerUnits is
+// userSpaceOnUse) that clips its content unless overflow is visible.
+func renderMarker(marker *svgElement, v markerVertex, isStart bool, strokeWidth float64, img *image.RGBA, rasterizer *vector.Rasterizer, width, height int, dpi float64, state *rasterRenderState) error {
+	// A marker that references itself, directly or not, is not rendered.
+	if state.activeMarkers[marker] {
+		return nil
+	}
+
+	viewport := state.viewport()
+	markerWidth, markerHeight := 3.0, 3.0
+	if val, ok := marker.Attributes["markerWidth"]; ok {
+		markerWidth = parseLengthFloatWithReference(val, dpi, viewport.X)
+	}
+	if val, ok := marker.Attributes["markerHeight"]; ok {
+		markerHeight = parseLengthFloatWithReference(val, dpi, viewport.Y)
+	}
+	if markerWidth <= 0 || markerHeight <= 0 {
+		return nil
+	}
+
+	scale := strokeWidth
+	if strings.TrimSpace(marker.Attributes["markerUnits"]) == string(MarkerUnitsUserSpaceOnUse) {
+		scale = 1
+	}
+	if scale <= 0 {
+		return nil
+	}
+
+	angle := 0.0
+	switch orient := strings.TrimSpace(marker.Attributes["orient"]); orient {
+	case string(MarkerOrientAuto):
+		angle = v.angle()
+	case string(MarkerOrientAutoStart):
+		angle = v.angle()
+		if isStart {
+			angle += 180
+		}
+	default:
+		if a, err := units.ParseAngle(orient); err == nil {
+			angle = a.ToDeg().Raw()
+		}
+	}
+
+	content := IdentityMatrix()
+	contentSize := Point{X: markerWidth, Y: markerHeight}
+	if vb, ok := parseViewBox(marker.Attributes["viewBox"]); ok {
+		content = viewBoxTransform(vb, marker.Attributes["preserveAspectRatio"], markerWidth, markerHeight)
+		contentSize = Point{X: vb[2], Y: vb[3]}
+	}
+	ref := content.Apply(Point{
+		X: parseLengthFloatWithReference(marker.Attributes["refX"], dpi, contentSize.X),
+		Y: parseLengthFloatWithReference(marker.Attributes["refY"], dpi, contentSize.Y),
+	})
+
+	placement := TranslateMatrix(v.P.X, v.P.Y).
+		Multiply(RotateMatrix(angle)).
+		Multiply(ScaleMatrix(scale, scale)).
+		Multiply(TranslateMatrix(-ref.X, -ref.Y))
+	state.pushTransform(placement)
+	defer state.popTransform()
+
+	switch declaredProperties(marker)["overflow"] {
+	case "visible", "auto":
+	default:
+		clip := transformSegments(rectSegments(0, 0, markerWidth, markerHeight, 0, 0), state.transform())
+		state.pushClip(pathCoverage(rasterizer, clip, false, img.Bounds()))
+		defer state.popClip()
+	}
+
+	state.pushTransform(content)
+	defer state.popTransform()
+	state.pushViewport(contentSize)
+	defer state.popViewport()
+
+	// Marker content inherits properties from the marker, not from the
+	// referencing element, and renders even though markers live in defs.
+	savedStyles, savedDefs := state.styles, state.inDefsDepth
+	state.styles, state.inDefsDepth = nil, 0
+	state.pushStyle(marker)
+	state.activeMarkers[marker] = true
+	defer func() {
+		delete(state.activeMarkers, marker)
+		state.styles, state.inDefsDepth = savedStyles, savedDefs
+	}()
+
+	for _, child := range marker.Children {
+		if err := renderElement(child, img, rasterizer, width, height, dpi, state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
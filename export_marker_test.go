@@ -0,0 +1,128 @@
+package svg
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestMarkerVerticesCountArcAsOneCommand(t *testing.T) {
+	segs, err := parsePathData("M 0 0 L 10 0 A 10 10 0 0 1 30 0 L 40 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	verts := markerVertices(segs)
+	if len(verts) != 4 {
+		t.Fatalf("expected 4 vertices, got %d", len(verts))
+	}
+	if end := verts[2].P; math.Abs(end.X-30) > 1e-9 || math.Abs(end.Y) > 1e-9 {
+		t.Errorf("arc vertex at %v, want (30, 0)", end)
+	}
+}
+
+func TestMarkerVerticesClosedSubpathBisectsStart(t *testing.T) {
+	segs, err := parsePathData("M 0 0 L 10 0 L 10 10 Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	verts := markerVertices(segs)
+	if len(verts) != 4 {
+		t.Fatalf("expected 4 vertices, got %d", len(verts))
+	}
+	// The closing segment enters the start heading up-left (-135°) and the
+	// first segment leaves it heading right (0°).
+	if got := verts[0].angle(); math.Abs(got-(-67.5)) > 1e-9 {
+		t.Errorf("start angle = %v, want -67.5", got)
+	}
+	if got := verts[3].angle(); math.Abs(got-(-67.5)) > 1e-9 {
+		t.Errorf("closing angle = %v, want -67.5", got)
+	}
+}
+
+func TestExportMarkerEndOrientsAlongPath(t *testing.T) {
+	svgData := `<svg width="100" height="100"><defs>` +
+		ArrowMarker("arrow", "#ff0000") +
+		`</defs>` +
+		LineWithMarkers(50, 10, 50, 90, Style{Stroke: "#000000", StrokeWidth: 2}, "", MarkerURL("arrow")) +
+		`</svg>`
+
+	img := exportImage(t, svgData, 100, 100)
+	// The 12px arrow points down with its tip on the line end, so it is
+	// wide just above the tip and the stroke shows further up.
+	expectColorNear(t, img, 50, 87, color.RGBA{R: 255, A: 255}, 2)
+	expectColorNear(t, img, 46, 80, color.RGBA{R: 255, A: 255}, 2)
+	expectColorNear(t, img, 50, 50, color.RGBA{A: 255}, 2)
+	expectColorNear(t, img, 45, 50, color.RGBA{}, 2)
+}
+
+func TestExportMarkerAutoStartReverse(t *testing.T) {
+	svgData := `<svg width="100" height="100"><defs>
+		<marker id="m" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="10" markerHeight="10" markerUnits="userSpaceOnUse" orient="auto-start-reverse">
+			<path d="M0 0 L10 5 L0 10 Z" fill="#0000ff"/>
+		</marker>
+	</defs>
+	<line x1="20" y1="50" x2="80" y2="50" stroke="#000000" marker-start="url(#m)" marker-end="url(#m)"/>
+	</svg>`
+
+	img := exportImage(t, svgData, 100, 100)
+	// Both arrows point away from the line: the wide base sits inside it.
+	expectColorNear(t, img, 22, 47, color.RGBA{}, 2)
+	expectColorNear(t, img, 28, 47, color.RGBA{B: 255, A: 255}, 2)
+	expectColorNear(t, img, 72, 47, color.RGBA{B: 255, A: 255}, 2)
+	expectColorNear(t, img, 78, 47, color.RGBA{}, 2)
+}
+
+func TestExportMarkerUnitsScaleWithStrokeWidth(t *testing.T) {
+	marker := func(units string) string {
+		return `<svg width="100" height="100"><defs>
+			<marker id="m" refX="2" refY="2" markerWidth="4" markerHeight="4" markerUnits="` + units + `">
+				<rect width="4" height="4" fill="#00ff00"/>
+			</marker>
+		</defs>
+		<path d="M 50 50" stroke-width="5" marker-start="url(#m)"/>
+		</svg>`
+	}
+
+	if got := exportVisiblePixels(t, marker("strokeWidth"), 100, 100); got != 400 {
+		t.Errorf("strokeWidth marker covers %d pixels, want 400", got)
+	}
+	if got := exportVisiblePixels(t, marker("userSpaceOnUse"), 100, 100); got != 16 {
+		t.Errorf("userSpaceOnUse marker covers %d pixels, want 16", got)
+	}
+}
+
+func TestExportMarkerMidAndFixedOrient(t *testing.T) {
+	svgData := `<svg width="100" height="100"><defs>
+		<marker id="m" refX="0" refY="1" markerWidth="10" markerHeight="2" markerUnits="userSpaceOnUse" orient="90deg">
+			<rect width="10" height="2" fill="#ff0000"/>
+		</marker>
+	</defs>
+	<polyline points="10,50 50,50 90,50" fill="none" stroke="none" marker-mid="url(#m)"/>
+	</svg>`
+
+	img := exportImage(t, svgData, 100, 100)
+	// Only the middle vertex gets a marker, rotated to point down.
+	expectColorNear(t, img, 50, 55, color.RGBA{R: 255, A: 255}, 2)
+	expectColorNear(t, img, 55, 50, color.RGBA{}, 2)
+	expectColorNear(t, img, 10, 55, color.RGBA{}, 2)
+	expectColorNear(t, img, 90, 55, color.RGBA{}, 2)
+}
+
+func TestExportMarkerClipsToViewport(t *testing.T) {
+	marker := func(overflow string) string {
+		return `<svg width="100" height="100"><defs>
+			<marker id="m" markerWidth="10" markerHeight="10" markerUnits="userSpaceOnUse"` + overflow + `>
+				<rect width="20" height="20" fill="#000000"/>
+			</marker>
+		</defs>
+		<path d="M 50 50" marker-start="url(#m)"/>
+		</svg>`
+	}
+
+	if got := exportVisiblePixels(t, marker(""), 100, 100); got != 100 {
+		t.Errorf("clipped marker covers %d pixels, want 100", got)
+	}
+	if got := exportVisiblePixels(t, marker(` overflow="visible"`), 100, 100); got != 400 {
+		t.Errorf("overflowing marker covers %d pixels, want 400", got)
+	}
+}
@@ -0,0 +1,69 @@
+package svg
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestExportGroupOpacityUsesLayer(t *testing.T) {
+	// Overlapping children of a translucent group must not blend with each
+	// other: the overlap looks exactly like the rest of the top rect.
+	svgData := `<svg width="100" height="100">` +
+		Group(
+			Rect(0, 0, 60, 60, Style{Fill: "#ff0000"})+Rect(40, 40, 60, 60, Style{Fill: "#0000ff"}),
+			"", Style{Opacity: 0.5},
+		) +
+		`</svg>`
+
+	img := exportImage(t, svgData, 100, 100)
+	want := color.RGBA{B: 128, A: 128}
+	expectColorNear(t, img, 50, 50, want, 2)
+	expectColorNear(t, img, 80, 80, want, 2)
+	expectColorNear(t, img, 10, 10, color.RGBA{R: 128, A: 128}, 2)
+}
+
+func TestExportFillOpacityBlendsPerElement(t *testing.T) {
+	svgData := `<svg width="100" height="100">
+		<rect width="60" height="60" fill="#ff0000" fill-opacity="0.5"/>
+		<rect x="40" y="40" width="60" height="60" fill="#0000ff" fill-opacity="0.5"/>
+	</svg>`
+
+	img := exportImage(t, svgData, 100, 100)
+	// Blue at 50% over red at 50%: alpha 0.75.
+	expectColorNear(t, img, 50, 50, color.RGBA{R: 64, B: 128, A: 191}, 2)
+	expectColorNear(t, img, 80, 80, color.RGBA{B: 128, A: 128}, 2)
+}
+
+func TestExportElementOpacityCoversFillAndStroke(t *testing.T) {
+	svgData := `<svg width="100" height="100">
+		<rect x="20" y="20" width="60" height="60" fill="#00ff00" stroke="#000000" stroke-width="10" opacity="0.5"/>
+	</svg>`
+
+	img := exportImage(t, svgData, 100, 100)
+	// The stroke covers the fill edge instead of blending with it.
+	expectColorNear(t, img, 20, 50, color.RGBA{A: 128}, 2)
+	expectColorNear(t, img, 50, 50, color.RGBA{G: 128, A: 128}, 2)
+}
+
+func TestExportStrokeOpacity(t *testing.T) {
+	svgData := `<svg width="100" height="100">
+		<line x1="0" y1="50" x2="100" y2="50" stroke="#000000" stroke-width="10" stroke-opacity="25%"/>
+	</svg>`
+
+	img := exportImage(t, svgData, 100, 100)
+	expectColorNear(t, img, 50, 50, color.RGBA{A: 64}, 2)
+}
+
+func TestExportZeroOpacitySkipsElement(t *testing.T) {
+	svgData := `<svg width="100" height="100"><g opacity="0"><rect width="100" height="100"/></g></svg>`
+	if visible := exportVisiblePixels(t, svgData, 100, 100); visible != 0 {
+		t.Fatalf("expected nothing to render, got %d pixels", visible)
+	}
+}
+
+func TestExportTranslucentColorIsPremultiplied(t *testing.T) {
+	svgData := `<svg width="10" height="10"><rect width="10" height="10" fill="rgba(255, 0, 0, 0.5)"/></svg>`
+
+	img := exportImage(t, svgData, 10, 10)
+	expectColorNear(t, img, 5, 5, color.RGBA{R: 128, A: 128}, 2)
+}
@@ -0,0 +1,440 @@
+package svg
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"golang.org/x/image/vector"
+)
+
+// pathOp identifies the kind of a normalized path segment.
+type pathOp int
+
+const (
+	pathOpMoveTo pathOp = iota
+	pathOpLineTo
+	pathOpQuadTo
+	pathOpCubeTo
+	pathOpClose
+)
+
+// pathSegment is an absolute, normalized path segment.
+// MoveTo and LineTo use Pts[0], QuadTo uses Pts[0:2] and CubeTo uses Pts[0:3].
+// The last used point is always the segment end point.
+type pathSegment struct {
+	Op  pathOp
+	Pts [3]Point
+	// Continuation marks segments produced by normalization rather than by
+	// a path command, such as the second and later cubics of an arc or the
+	// implicit moveto after a closepath. They do not start a new vertex.
+	Continuation bool
+}
+
+// parsePathData parses SVG path data into absolute move/line/quad/cube/close
+// segments. Shorthand commands (H, V, S, T) are expanded and arcs are
+// converted to cubic Béziers.
+//
+// As required by the SVG specification, segments parsed before an error are
+// returned together with the error so callers can render them.
+func parsePathData(d string) ([]pathSegment, error) {
+	path, err := ParsePath(d)
+	return path.segments(), err
+}
+
+func isPathCommand(c byte) bool {
+	switch c {
+	case 'M', 'm', 'Z', 'z', 'L', 'l', 'H', 'h', 'V', 'v',
+		'C', 'c', 'S', 's', 'Q', 'q', 'T', 't', 'A', 'a':
+		return true
+	}
+	return false
+}
+
+func addPoint(p, offset Point) Point {
+	return Point{X: p.X + offset.X, Y: p.Y + offset.Y}
+}
+
+// reflectPoint reflects p about center.
+func reflectPoint(p, center Point) Point {
+	return Point{X: 2*center.X - p.X, Y: 2*center.Y - p.Y}
+}
+
+// ellipticalArc is an SVG elliptical arc in center parameterization.
+type ellipticalArc struct {
+	Center         Point
+	RX, RY         float64 // radii, scaled up if needed to span the endpoints
+	Cos, Sin       float64 // cosine and sine of the x-axis rotation
+	Theta1, DTheta float64 // start angle and signed sweep in radians
+}
+
+// arcCenter converts an SVG elliptical arc from p0 to p to center
+// parameterization, following SVG 1.1 F.6.5. It reports false for arcs that
+// are omitted (p0 == p) or drawn as a straight line (a zero radius).
+func arcCenter(p0 Point, rx, ry, rotationDeg float64, largeArc, sweep bool, p Point) (ellipticalArc, bool) {
+	rx = math.Abs(rx)
+	ry = math.Abs(ry)
+	if p0 == p || rx == 0 || ry == 0 {
+		return ellipticalArc{}, false
+	}
+
+	phi := rotationDeg * math.Pi / 180
+	sinPhi, cosPhi := math.Sincos(phi)
+
+	dx2 := (p0.X - p.X) / 2
+	dy2 := (p0.Y - p.Y) / 2
+	x1p := cosPhi*dx2 + sinPhi*dy2
+	y1p := -sinPhi*dx2 + cosPhi*dy2
+
+	// Scale up radii that are too small to span the endpoints.
+	if lambda := (x1p*x1p)/(rx*rx) + (y1p*y1p)/(ry*ry); lambda > 1 {
+		s := math.Sqrt(lambda)
+		rx *= s
+		ry *= s
+	}
+
+	rx2, ry2 := rx*rx, ry*ry
+	num := rx2*ry2 - rx2*y1p*y1p - ry2*x1p*x1p
+	den := rx2*y1p*y1p + ry2*x1p*x1p
+	coef := 0.0
+	if den != 0 && num > 0 {
+		coef = math.Sqrt(num / den)
+	}
+	if largeArc == sweep {
+		coef = -coef
+	}
+	cxp := coef * rx * y1p / ry
+	cyp := -coef * ry * x1p / rx
+
+	theta1 := vectorAngle(1, 0, (x1p-cxp)/rx, (y1p-cyp)/ry)
+	dTheta := vectorAngle((x1p-cxp)/rx, (y1p-cyp)/ry, (-x1p-cxp)/rx, (-y1p-cyp)/ry)
+	if !sweep && dTheta > 0 {
+		dTheta -= 2 * math.Pi
+	} else if sweep && dTheta < 0 {
+		dTheta += 2 * math.Pi
+	}
+
+	return ellipticalArc{
+		Center: Point{
+			X: cosPhi*cxp - sinPhi*cyp + (p0.X+p.X)/2,
+			Y: sinPhi*cxp + cosPhi*cyp + (p0.Y+p.Y)/2,
+		},
+		RX: rx, RY: ry,
+		Cos: cosPhi, Sin: sinPhi,
+		Theta1: theta1, DTheta: dTheta,
+	}, true
+}
+
+// toUser maps a point of the unit circle to the ellipse in user space.
+func (a ellipticalArc) toUser(x, y float64) Point {
+	return Point{
+		X: a.Center.X + a.RX*a.Cos*x - a.RY*a.Sin*y,
+		Y: a.Center.Y + a.RX*a.Sin*x + a.RY*a.Cos*y,
+	}
+}
+
+// point returns the point of the ellipse at angle theta.
+func (a ellipticalArc) point(theta float64) Point {
+	sin, cos := math.Sincos(theta)
+	return a.toUser(cos, sin)
+}
+
+// arcToCubics converts an SVG elliptical arc from p0 to p into cubic Bézier
+// segments, following the endpoint-to-center conversion in SVG 1.1 F.6.5.
+func arcToCubics(p0 Point, rx, ry, rotationDeg float64, largeArc, sweep bool, p Point) []pathSegment {
+	if p0 == p {
+		return nil
+	}
+	arc, ok := arcCenter(p0, rx, ry, rotationDeg, largeArc, sweep, p)
+	if !ok {
+		return []pathSegment{{Op: pathOpLineTo, Pts: [3]Point{p}}}
+	}
+
+	n := int(math.Ceil(math.Abs(arc.DTheta) / (math.Pi / 2)))
+	if n < 1 {
+		n = 1
+	}
+	delta := arc.DTheta / float64(n)
+	k := 4.0 / 3.0 * math.Tan(delta/4)
+
+	segs := make([]pathSegment, 0, n)
+	for i := 0; i < n; i++ {
+		t1 := arc.Theta1 + float64(i)*delta
+		t2 := t1 + delta
+		sin1, cos1 := math.Sincos(t1)
+		sin2, cos2 := math.Sincos(t2)
+
+		c1 := arc.toUser(cos1-k*sin1, sin1+k*cos1)
+		c2 := arc.toUser(cos2+k*sin2, sin2-k*cos2)
+		end := arc.toUser(cos2, sin2)
+		if i == n-1 {
+			end = p
+		}
+		segs = append(segs, pathSegment{Op: pathOpCubeTo, Pts: [3]Point{c1, c2, end}, Continuation: i > 0})
+	}
+	return segs
+}
+
+// vectorAngle returns the signed angle from vector u to vector v.
+func vectorAngle(ux, uy, vx, vy float64) float64 {
+	return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+}
+
+// addPathToRasterizer feeds path segments to the rasterizer, closing every
+// subpath so that fills are well defined.
+func addPathToRasterizer(r *vector.Rasterizer, segs []pathSegment) {
+	open := false
+	for _, seg := range segs {
+		switch seg.Op {
+		case pathOpMoveTo:
+			if open {
+				r.ClosePath()
+			}
+			r.MoveTo(float32(seg.Pts[0].X), float32(seg.Pts[0].Y))
+			open = true
+		case pathOpLineTo:
+			r.LineTo(float32(seg.Pts[0].X), float32(seg.Pts[0].Y))
+		case pathOpQuadTo:
+			r.QuadTo(float32(seg.Pts[0].X), float32(seg.Pts[0].Y),
+				float32(seg.Pts[1].X), float32(seg.Pts[1].Y))
+		case pathOpCubeTo:
+			r.CubeTo(float32(seg.Pts[0].X), float32(seg.Pts[0].Y),
+				float32(seg.Pts[1].X), float32(seg.Pts[1].Y),
+				float32(seg.Pts[2].X), float32(seg.Pts[2].Y))
+		case pathOpClose:
+			if open {
+				r.ClosePath()
+				open = false
+			}
+		}
+	}
+	if open {
+		r.ClosePath()
+	}
+}
+
+// pathDataScanner tokenizes SVG path data.
+type pathDataScanner struct {
+	s   string
+	pos int
+}
+
+func (sc *pathDataScanner) done() bool {
+	return sc.pos >= len(sc.s)
+}
+
+func (sc *pathDataScanner) skipSeparators() {
+	for !sc.done() {
+		switch sc.s[sc.pos] {
+		case ' ', '\t', '\n', '\r', '\f', ',':
+			sc.pos++
+		default:
+			return
+		}
+	}
+}
+
+// atNumber reports whether the next token starts a number.
+func (sc *pathDataScanner) atNumber() bool {
+	if sc.done() {
+		return false
+	}
+	c := sc.s[sc.pos]
+	return c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9')
+}
+
+func (sc *pathDataScanner) number() (float64, error) {
+	sc.skipSeparators()
+	start := sc.pos
+	i := sc.pos
+	if i < len(sc.s) && (sc.s[i] == '+' || sc.s[i] == '-') {
+		i++
+	}
+	digits := 0
+	for i < len(sc.s) && sc.s[i] >= '0' && sc.s[i] <= '9' {
+		i++
+		digits++
+	}
+	if i < len(sc.s) && sc.s[i] == '.' {
+		i++
+		for i < len(sc.s) && sc.s[i] >= '0' && sc.s[i] <= '9' {
+			i++
+			digits++
+		}
+	}
+	if digits == 0 {
+		return 0, fmt.Errorf("path data: expected number at offset %d", start)
+	}
+	if i < len(sc.s) && (sc.s[i] == 'e' || sc.s[i] == 'E') {
+		j := i + 1
+		if j < len(sc.s) && (sc.s[j] == '+' || sc.s[j] == '-') {
+			j++
+		}
+		if j < len(sc.s) && sc.s[j] >= '0' && sc.s[j] <= '9' {
+			for j < len(sc.s) && sc.s[j] >= '0' && sc.s[j] <= '9' {
+				j++
+			}
+			i = j
+		}
+	}
+
+	v, err := strconv.ParseFloat(sc.s[start:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("path data: invalid number %q: %w", sc.s[start:i], err)
+	}
+	sc.pos = i
+	return v, nil
+}
+
+// flag reads an arc flag, which may be written without separators ("a1 1 0 11 5 5").
+func (sc *pathDataScanner) flag() (bool, error) {
+	sc.skipSeparators()
+	if sc.done() {
+		return false, fmt.Errorf("path data: expected flag at end of input")
+	}
+	switch sc.s[sc.pos] {
+	case '0':
+		sc.pos++
+		return false, nil
+	case '1':
+		sc.pos++
+		return true, nil
+	}
+	return false, fmt.Errorf("path data: invalid flag %q at offset %d", sc.s[sc.pos], sc.pos)
+}
+
+func (sc *pathDataScanner) point() (Point, error) {
+	x, err := sc.number()
+	if err != nil {
+		return Point{}, err
+	}
+	y, err := sc.number()
+	if err != nil {
+		return Point{}, err
+	}
+	return Point{X: x, Y: y}, nil
+}
+
+func (sc *pathDataScanner) points(n int) ([]Point, error) {
+	pts := make([]Point, n)
+	for i := range pts {
+		p, err := sc.point()
+		if err != nil {
+			return nil, err
+		}
+		pts[i] = p
+	}
+	return pts, nil
+}
+
+// boundingBox is an axis-aligned rectangle in user space.
+type boundingBox struct {
+	Min, Max Point
+}
+
+func (b boundingBox) width() float64  { return b.Max.X - b.Min.X }
+func (b boundingBox) height() float64 { return b.Max.Y - b.Min.Y }
+
+// segmentsBounds returns the exact bounding box of the path geometry, using
+// the extrema of Bézier curves rather than their control points. It reports
+// false for an empty path.
+func segmentsBounds(segs []pathSegment) (boundingBox, bool) {
+	var b boundingBox
+	found := false
+	add := func(p Point) {
+		if !found {
+			b = boundingBox{Min: p, Max: p}
+			found = true
+			return
+		}
+		b.Min.X = math.Min(b.Min.X, p.X)
+		b.Min.Y = math.Min(b.Min.Y, p.Y)
+		b.Max.X = math.Max(b.Max.X, p.X)
+		b.Max.Y = math.Max(b.Max.Y, p.Y)
+	}
+
+	var cur Point
+	for _, seg := range segs {
+		switch seg.Op {
+		case pathOpMoveTo, pathOpLineTo:
+			add(seg.Pts[0])
+			cur = seg.Pts[0]
+		case pathOpQuadTo:
+			p0, p1, p2 := cur, seg.Pts[0], seg.Pts[1]
+			for _, t := range append(quadExtrema(p0.X, p1.X, p2.X), quadExtrema(p0.Y, p1.Y, p2.Y)...) {
+				add(quadPoint(p0, p1, p2, t))
+			}
+			add(p2)
+			cur = p2
+		case pathOpCubeTo:
+			p0, p1, p2, p3 := cur, seg.Pts[0], seg.Pts[1], seg.Pts[2]
+			for _, t := range append(cubicExtrema(p0.X, p1.X, p2.X, p3.X), cubicExtrema(p0.Y, p1.Y, p2.Y, p3.Y)...) {
+				add(cubicPoint(p0, p1, p2, p3, t))
+			}
+			add(p3)
+			cur = p3
+		}
+	}
+	return b, found
+}
+
+// quadExtrema returns the parameters in (0, 1) where a quadratic Bézier
+// coordinate has zero derivative.
+func quadExtrema(a, b, c float64) []float64 {
+	den := a - 2*b + c
+	if den == 0 {
+		return nil
+	}
+	t := (a - b) / den
+	if t <= 0 || t >= 1 {
+		return nil
+	}
+	return []float64{t}
+}
+
+// cubicExtrema returns the parameters in (0, 1) where a cubic Bézier
+// coordinate has zero derivative.
+func cubicExtrema(a, b, c, d float64) []float64 {
+	// The derivative is 3(qa t² + qb t + qc).
+	qa := -a + 3*b - 3*c + d
+	qb := 2 * (a - 2*b + c)
+	qc := b - a
+
+	var roots []float64
+	if math.Abs(qa) < 1e-12 {
+		if qb != 0 {
+			roots = append(roots, -qc/qb)
+		}
+	} else {
+		disc := qb*qb - 4*qa*qc
+		if disc >= 0 {
+			sq := math.Sqrt(disc)
+			roots = append(roots, (-qb+sq)/(2*qa), (-qb-sq)/(2*qa))
+		}
+	}
+
+	out := roots[:0]
+	for _, t := range roots {
+		if t > 0 && t < 1 {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func quadPoint(p0, p1, p2 Point, t float64) Point {
+	mt := 1 - t
+	return Point{
+		X: mt*mt*p0.X + 2*mt*t*p1.X + t*t*p2.X,
+		Y: mt*mt*p0.Y + 2*mt*t*p1.Y + t*t*p2.Y,
+	}
+}
+
+func cubicPoint(p0, p1, p2, p3 Point, t float64) Point {
+	mt := 1 - t
+	a, b, c, d := mt*mt*mt, 3*mt*mt*t, 3*mt*t*t, t*t*t
+	return Point{
+		X: a*p0.X + b*p1.X + c*p2.X + d*p3.X,
+		Y: a*p0.Y + b*p1.Y + c*p2.Y + d*p3.Y,
+	}
+}
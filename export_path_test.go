@@ -0,0 +1,151 @@
+package svg
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParsePathDataAbsoluteAndRelative(t *testing.T) {
+	segs, err := parsePathData("M 10 10 L 20 10 l 0 10 H 10 v -10 Z")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []pathSegment{
+		{Op: pathOpMoveTo, Pts: [3]Point{{X: 10, Y: 10}}},
+		{Op: pathOpLineTo, Pts: [3]Point{{X: 20, Y: 10}}},
+		{Op: pathOpLineTo, Pts: [3]Point{{X: 20, Y: 20}}},
+		{Op: pathOpLineTo, Pts: [3]Point{{X: 10, Y: 20}}},
+		{Op: pathOpLineTo, Pts: [3]Point{{X: 10, Y: 10}}},
+		{Op: pathOpClose},
+	}
+	if len(segs) != len(want) {
+		t.Fatalf("expected %d segments, got %d: %#v", len(want), len(segs), segs)
+	}
+	for i := range want {
+		if segs[i] != want[i] {
+			t.Fatalf("segment %d = %#v, expected %#v", i, segs[i], want[i])
+		}
+	}
+}
+
+func TestParsePathDataImplicitCommands(t *testing.T) {
+	// Implicit lineto after moveto, repeated commands, and compact numbers.
+	segs, err := parsePathData("m1,1 2,0-1.5.5L4 4 5 5")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ends := []Point{{1, 1}, {3, 1}, {1.5, 1.5}, {4, 4}, {5, 5}}
+	if len(segs) != len(ends) {
+		t.Fatalf("expected %d segments, got %d", len(ends), len(segs))
+	}
+	for i, p := range ends {
+		if segs[i].Pts[0] != p {
+			t.Fatalf("segment %d ends at %v, expected %v", i, segs[i].Pts[0], p)
+		}
+	}
+	if segs[1].Op != pathOpLineTo {
+		t.Fatalf("expected implicit lineto after moveto, got op %d", segs[1].Op)
+	}
+}
+
+func TestParsePathDataSmoothCurves(t *testing.T) {
+	segs, err := parsePathData("M0 0 C 0 10 10 10 10 0 S 20 -10 20 0 Q 25 10 30 0 T 40 0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(segs) != 5 {
+		t.Fatalf("expected 5 segments, got %d", len(segs))
+	}
+
+	// S reflects the previous second control point (10,10) about (10,0).
+	if got := segs[2].Pts[0]; got != (Point{X: 10, Y: -10}) {
+		t.Fatalf("expected reflected cubic control point (10,-10), got %v", got)
+	}
+	// T reflects the previous quadratic control point (25,10) about (30,0).
+	if segs[4].Op != pathOpQuadTo || segs[4].Pts[0] != (Point{X: 35, Y: -10}) {
+		t.Fatalf("expected reflected quadratic control point (35,-10), got %#v", segs[4])
+	}
+}
+
+func TestParsePathDataArcsBecomeCubics(t *testing.T) {
+	segs, err := parsePathData(CirclePath(50, 50, 20))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cubics := 0
+	for _, seg := range segs {
+		if seg.Op == pathOpCubeTo {
+			cubics++
+			end := seg.Pts[2]
+			if d := math.Hypot(end.X-50, end.Y-50); math.Abs(d-20) > 1e-9 {
+				t.Fatalf("arc end point %v is not on the circle (distance %f)", end, d)
+			}
+		}
+	}
+	if cubics != 4 {
+		t.Fatalf("expected two half-circle arcs to become 4 cubics, got %d", cubics)
+	}
+}
+
+func TestParsePathDataCompactArcFlags(t *testing.T) {
+	segs, err := parsePathData("M0 0a5 5 0 1110 0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	last := segs[len(segs)-1]
+	if last.Op != pathOpCubeTo || last.Pts[2] != (Point{X: 10, Y: 0}) {
+		t.Fatalf("expected arc to end at (10,0), got %#v", last)
+	}
+}
+
+func TestParsePathDataErrorKeepsPrefix(t *testing.T) {
+	segs, err := parsePathData("M 0 0 L 10 10 L 20 x")
+	if err == nil {
+		t.Fatal("expected error for malformed path data")
+	}
+	if len(segs) != 2 {
+		t.Fatalf("expected the 2 valid segments before the error, got %d", len(segs))
+	}
+
+	if _, err := parsePathData("L 10 10"); err == nil {
+		t.Fatal("expected error when path does not start with moveto")
+	}
+}
+
+func TestParsePathDataMoveAfterClose(t *testing.T) {
+	segs, err := parsePathData("M 5 5 L 10 5 Z l 0 5")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(segs) != 5 {
+		t.Fatalf("expected implicit moveto after close, got %#v", segs)
+	}
+	if segs[3].Op != pathOpMoveTo || segs[3].Pts[0] != (Point{X: 5, Y: 5}) {
+		t.Fatalf("expected moveto subpath start, got %#v", segs[3])
+	}
+	if segs[4].Pts[0] != (Point{X: 5, Y: 10}) {
+		t.Fatalf("expected relative lineto from subpath start, got %v", segs[4].Pts[0])
+	}
+}
+
+func TestSegmentsBoundsUsesCurveExtrema(t *testing.T) {
+	segs, err := parsePathData("M0 0 C 0 -10 10 -10 10 0 Q 15 10 20 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, ok := segmentsBounds(segs)
+	if !ok {
+		t.Fatal("expected bounds")
+	}
+	want := boundingBox{Min: Point{X: 0, Y: -7.5}, Max: Point{X: 20, Y: 5}}
+	if math.Abs(b.Min.X-want.Min.X) > 1e-9 || math.Abs(b.Min.Y-want.Min.Y) > 1e-9 ||
+		math.Abs(b.Max.X-want.Max.X) > 1e-9 || math.Abs(b.Max.Y-want.Max.Y) > 1e-9 {
+		t.Fatalf("segmentsBounds = %+v, want %+v", b, want)
+	}
+	if _, ok := segmentsBounds(nil); ok {
+		t.Fatal("expected empty path to have no bounds")
+	}
+}
@@ -0,0 +1,440 @@
+package svg
+
+import (
+	"math"
+
+	"golang.org/x/image/vector"
+)
+
+// flattenTolerance is the maximum distance, in device pixels, between a curve
+// and the line segments used to approximate it.
+const flattenTolerance = 0.1
+
+// strokeStyle holds resolved stroke properties used by the stroker.
+type strokeStyle struct {
+	Width      float64
+	Linecap    StrokeLinecap
+	Linejoin   StrokeLinejoin
+	MiterLimit float64
+	DashArray  []float64
+	DashOffset float64
+}
+
+// flattenedPath is a subpath approximated by straight line segments.
+type flattenedPath struct {
+	Points []Point
+	Closed bool
+}
+
+// flattenPath approximates path segments with polylines, one per subpath.
+func flattenPath(segs []pathSegment, tolerance float64) []flattenedPath {
+	var paths []flattenedPath
+	var cur *flattenedPath
+	var pen Point
+
+	for _, seg := range segs {
+		switch seg.Op {
+		case pathOpMoveTo:
+			paths = append(paths, flattenedPath{Points: []Point{seg.Pts[0]}})
+			cur = &paths[len(paths)-1]
+			pen = seg.Pts[0]
+			continue
+		case pathOpClose:
+			if cur != nil {
+				cur.Closed = true
+				pen = cur.Points[0]
+				cur = nil
+			}
+			continue
+		}
+
+		if cur == nil {
+			paths = append(paths, flattenedPath{Points: []Point{pen}})
+			cur = &paths[len(paths)-1]
+		}
+
+		switch seg.Op {
+		case pathOpLineTo:
+			cur.Points = append(cur.Points, seg.Pts[0])
+			pen = seg.Pts[0]
+		case pathOpQuadTo:
+			p0, p1, p2 := pen, seg.Pts[0], seg.Pts[1]
+			dd := math.Hypot(p0.X-2*p1.X+p2.X, p0.Y-2*p1.Y+p2.Y)
+			n := curveSubdivisions(math.Sqrt(dd / (4 * tolerance)))
+			for i := 1; i <= n; i++ {
+				t := float64(i) / float64(n)
+				mt := 1 - t
+				cur.Points = append(cur.Points, Point{
+					X: mt*mt*p0.X + 2*mt*t*p1.X + t*t*p2.X,
+					Y: mt*mt*p0.Y + 2*mt*t*p1.Y + t*t*p2.Y,
+				})
+			}
+			pen = p2
+		case pathOpCubeTo:
+			p0, p1, p2, p3 := pen, seg.Pts[0], seg.Pts[1], seg.Pts[2]
+			dd := math.Max(
+				math.Hypot(p0.X-2*p1.X+p2.X, p0.Y-2*p1.Y+p2.Y),
+				math.Hypot(p1.X-2*p2.X+p3.X, p1.Y-2*p2.Y+p3.Y),
+			)
+			n := curveSubdivisions(math.Sqrt(0.75 * dd / tolerance))
+			for i := 1; i <= n; i++ {
+				t := float64(i) / float64(n)
+				mt := 1 - t
+				a, b, c, d := mt*mt*mt, 3*mt*mt*t, 3*mt*t*t, t*t*t
+				cur.Points = append(cur.Points, Point{
+					X: a*p0.X + b*p1.X + c*p2.X + d*p3.X,
+					Y: a*p0.Y + b*p1.Y + c*p2.Y + d*p3.Y,
+				})
+			}
+			pen = p3
+		}
+	}
+
+	return paths
+}
+
+// curveSubdivisions clamps a subdivision estimate (Wang's formula) to a sane range.
+func curveSubdivisions(estimate float64) int {
+	n := int(math.Ceil(estimate))
+	if n < 1 || math.IsNaN(estimate) {
+		return 1
+	}
+	if n > 1000 {
+		return 1000
+	}
+	return n
+}
+
+// maxDashes limits the number of dashes of one stroke. Denser patterns are
+// too fine to see and are stroked solid, as browsers do, instead of spending
+// time and memory on millions of dashes.
+const maxDashes = 100000
+
+// dashPaths splits subpaths into dashes according to the dash array.
+func dashPaths(paths []flattenedPath, dashes []float64, offset float64) []flattenedPath {
+	pattern := 0.0
+	for _, d := range dashes {
+		pattern += d
+	}
+	length := 0.0
+	for _, p := range paths {
+		length += flattenedLength(p)
+	}
+	if length/pattern*float64(len(dashes)) > maxDashes {
+		return paths
+	}
+
+	var out []flattenedPath
+	for _, p := range paths {
+		out = append(out, dashPath(p, dashes, offset)...)
+	}
+	return out
+}
+
+// flattenedLength returns the length of a subpath, including the closing
+// segment of a closed one.
+func flattenedLength(path flattenedPath) float64 {
+	pts := path.Points
+	length := 0.0
+	for i := 1; i < len(pts); i++ {
+		length += math.Hypot(pts[i].X-pts[i-1].X, pts[i].Y-pts[i-1].Y)
+	}
+	if path.Closed && len(pts) > 1 {
+		first, last := pts[0], pts[len(pts)-1]
+		length += math.Hypot(first.X-last.X, first.Y-last.Y)
+	}
+	return length
+}
+
+func dashPath(path flattenedPath, dashes []float64, offset float64) []flattenedPath {
+	pts := path.Points
+	if path.Closed && len(pts) > 0 {
+		pts = append(pts[:len(pts):len(pts)], pts[0])
+	}
+	if len(pts) < 2 {
+		return nil
+	}
+
+	total := 0.0
+	for _, d := range dashes {
+		total += d
+	}
+	offset = math.Mod(offset, total)
+	if offset < 0 {
+		offset += total
+	}
+
+	// Advance into the dash pattern by the offset.
+	idx := 0
+	remain := dashes[0]
+	for offset > 0 {
+		if offset >= remain {
+			offset -= remain
+			idx = (idx + 1) % len(dashes)
+			remain = dashes[idx]
+		} else {
+			remain -= offset
+			offset = 0
+		}
+	}
+
+	on := idx%2 == 0
+	startedOn := on
+	var out []flattenedPath
+	var cur []Point
+	if on {
+		cur = []Point{pts[0]}
+	}
+
+	for i := 0; i < len(pts)-1; i++ {
+		a, b := pts[i], pts[i+1]
+		segLen := math.Hypot(b.X-a.X, b.Y-a.Y)
+		pos := 0.0
+		for segLen-pos > remain {
+			pos += remain
+			q := lerpPoint(a, b, pos/segLen)
+			if on {
+				cur = append(cur, q)
+				out = append(out, flattenedPath{Points: cur})
+				cur = nil
+			} else {
+				cur = []Point{q}
+			}
+			on = !on
+			idx = (idx + 1) % len(dashes)
+			remain = dashes[idx]
+		}
+		remain -= segLen - pos
+		if on {
+			cur = append(cur, b)
+		}
+	}
+
+	if on && len(cur) > 1 {
+		// A dash running through the start of a closed subpath is continuous.
+		if path.Closed && startedOn && len(out) > 0 {
+			out[0].Points = append(cur, out[0].Points[1:]...)
+		} else {
+			out = append(out, flattenedPath{Points: cur})
+		}
+	}
+	return out
+}
+
+func lerpPoint(a, b Point, t float64) Point {
+	return Point{X: a.X + (b.X-a.X)*t, Y: a.Y + (b.Y-a.Y)*t}
+}
+
+// strokePolygons converts subpaths into polygons covering the stroke outline.
+// Every polygon is emitted with the same orientation so that their union can
+// be filled with the nonzero winding rule.
+func strokePolygons(paths []flattenedPath, style strokeStyle, tolerance float64) [][]Point {
+	if style.Width <= 0 {
+		return nil
+	}
+	if len(style.DashArray) > 0 {
+		paths = dashPaths(paths, style.DashArray, style.DashOffset)
+	}
+
+	hw := style.Width / 2
+	var polys [][]Point
+	add := func(poly []Point) {
+		if len(poly) >= 3 {
+			polys = append(polys, orientPolygon(poly))
+		}
+	}
+
+	for _, path := range paths {
+		pts := dedupePoints(path.Points)
+		closed := path.Closed
+		if closed && len(pts) > 1 && pts[0] == pts[len(pts)-1] {
+			pts = pts[:len(pts)-1]
+		}
+
+		// Zero-length subpaths only render their caps.
+		if len(pts) == 1 {
+			switch style.Linecap {
+			case StrokeLinecapRound:
+				add(circlePolygon(pts[0], hw, tolerance))
+			case StrokeLinecapSquare:
+				p := pts[0]
+				add([]Point{{p.X - hw, p.Y - hw}, {p.X + hw, p.Y - hw}, {p.X + hw, p.Y + hw}, {p.X - hw, p.Y + hw}})
+			}
+			continue
+		}
+		if len(pts) < 2 {
+			continue
+		}
+		if closed && len(pts) == 2 {
+			closed = false
+			pts = append(pts, pts[0])
+		}
+
+		n := len(pts)
+		segCount := n - 1
+		if closed {
+			segCount = n
+		}
+
+		for i := 0; i < segCount; i++ {
+			a, b := pts[i], pts[(i+1)%n]
+			d := unitVector(a, b)
+			nx, ny := -d.Y*hw, d.X*hw
+			add([]Point{
+				{a.X + nx, a.Y + ny},
+				{b.X + nx, b.Y + ny},
+				{b.X - nx, b.Y - ny},
+				{a.X - nx, a.Y - ny},
+			})
+		}
+
+		// Joins at interior vertices, and at every vertex of a closed subpath.
+		for i := 0; i < n; i++ {
+			if !closed && (i == 0 || i == n-1) {
+				continue
+			}
+			prev := pts[(i-1+n)%n]
+			next := pts[(i+1)%n]
+			for _, poly := range joinPolygons(prev, pts[i], next, hw, style, tolerance) {
+				add(poly)
+			}
+		}
+
+		if !closed {
+			add(capPolygon(pts[1], pts[0], hw, style.Linecap, tolerance))
+			add(capPolygon(pts[n-2], pts[n-1], hw, style.Linecap, tolerance))
+		}
+	}
+
+	return polys
+}
+
+// joinPolygons returns the polygons that fill the outer corner at p.
+func joinPolygons(prev, p, next Point, hw float64, style strokeStyle, tolerance float64) [][]Point {
+	d0 := unitVector(prev, p)
+	d1 := unitVector(p, next)
+	cross := d0.X*d1.Y - d0.Y*d1.X
+	dot := d0.X*d1.X + d0.Y*d1.Y
+	if math.Abs(cross) < 1e-12 && dot > 0 {
+		return nil
+	}
+
+	if style.Linejoin == StrokeLinejoinRound {
+		return [][]Point{circlePolygon(p, hw, tolerance)}
+	}
+
+	// The outer side of the corner is opposite to the turn direction.
+	side := 1.0
+	if cross > 0 {
+		side = -1
+	}
+	n0 := Point{X: -d0.Y * side * hw, Y: d0.X * side * hw}
+	n1 := Point{X: -d1.Y * side * hw, Y: d1.X * side * hw}
+	a := addPoint(p, n0)
+	b := addPoint(p, n1)
+
+	if style.Linejoin != StrokeLinejoinBevel {
+		sinHalf := math.Sqrt((1 + dot) / 2)
+		if sinHalf > 1e-12 && 1/sinHalf <= style.MiterLimit {
+			bis := Point{X: n0.X + n1.X, Y: n0.Y + n1.Y}
+			l := math.Hypot(bis.X, bis.Y)
+			if l > 0 {
+				scale := hw / sinHalf / l
+				tip := Point{X: p.X + bis.X*scale, Y: p.Y + bis.Y*scale}
+				return [][]Point{{p, a, tip, b}}
+			}
+		}
+	}
+
+	return [][]Point{{p, a, b}}
+}
+
+// capPolygon returns the cap polygon for the end point p of a segment from prev.
+func capPolygon(prev, p Point, hw float64, linecap StrokeLinecap, tolerance float64) []Point {
+	switch linecap {
+	case StrokeLinecapRound:
+		return circlePolygon(p, hw, tolerance)
+	case StrokeLinecapSquare:
+		d := unitVector(prev, p)
+		nx, ny := -d.Y*hw, d.X*hw
+		ex, ey := d.X*hw, d.Y*hw
+		return []Point{
+			{p.X + nx, p.Y + ny},
+			{p.X + nx + ex, p.Y + ny + ey},
+			{p.X - nx + ex, p.Y - ny + ey},
+			{p.X - nx, p.Y - ny},
+		}
+	}
+	return nil
+}
+
+// circlePolygon approximates a circle within the given tolerance.
+func circlePolygon(c Point, r, tolerance float64) []Point {
+	n := 8
+	if r > tolerance {
+		step := 2 * math.Acos(1-tolerance/r)
+		if est := int(math.Ceil(2 * math.Pi / step)); est > n {
+			n = est
+		}
+	}
+	if n > 1000 {
+		n = 1000
+	}
+	poly := make([]Point, n)
+	for i := range poly {
+		sin, cos := math.Sincos(2 * math.Pi * float64(i) / float64(n))
+		poly[i] = Point{X: c.X + r*cos, Y: c.Y + r*sin}
+	}
+	return poly
+}
+
+func unitVector(a, b Point) Point {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	l := math.Hypot(dx, dy)
+	if l == 0 {
+		return Point{X: 1}
+	}
+	return Point{X: dx / l, Y: dy / l}
+}
+
+func dedupePoints(pts []Point) []Point {
+	out := make([]Point, 0, len(pts))
+	for i, p := range pts {
+		if i > 0 && p == out[len(out)-1] {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// orientPolygon returns poly with a positive signed area, reversing it if needed.
+func orientPolygon(poly []Point) []Point {
+	area := 0.0
+	for i := range poly {
+		a, b := poly[i], poly[(i+1)%len(poly)]
+		area += a.X*b.Y - b.X*a.Y
+	}
+	if area >= 0 {
+		return poly
+	}
+	out := make([]Point, len(poly))
+	for i, p := range poly {
+		out[len(poly)-1-i] = p
+	}
+	return out
+}
+
+// addPolygonsToRasterizer feeds closed polygons to the rasterizer.
+func addPolygonsToRasterizer(r *vector.Rasterizer, polys [][]Point) {
+	for _, poly := range polys {
+		if len(poly) < 3 {
+			continue
+		}
+		r.MoveTo(float32(poly[0].X), float32(poly[0].Y))
+		for _, p := range poly[1:] {
+			r.LineTo(float32(p.X), float32(p.Y))
+		}
+		r.ClosePath()
+	}
+}
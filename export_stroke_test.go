@@ -0,0 +1,148 @@
+package svg
+
+import (
+	"math"
+	"testing"
+)
+
+func exportVisiblePixels(t *testing.T, svgData string, width, height int) int {
+	t.Helper()
+
+	result, err := Export(svgData, ExportOptions{
+		Format: FormatPNG,
+		Width:  width,
+		Height: height,
+	})
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	return countVisiblePixelsFromPNG(t, result)
+}
+
+func TestExportStrokedRect(t *testing.T) {
+	svgData := `<svg width="100" height="100">` +
+		Rect(20, 20, 60, 60, Style{Fill: "none", Stroke: "#000000", StrokeWidth: 4}) +
+		`</svg>`
+
+	// Outer 64x64 minus inner 56x56 = 960 px.
+	visible := exportVisiblePixels(t, svgData, 100, 100)
+	if visible < 900 || visible > 1100 {
+		t.Fatalf("expected stroked outline around 960 px, got %d", visible)
+	}
+}
+
+func TestExportStrokeLinecaps(t *testing.T) {
+	line := func(cap StrokeLinecap) string {
+		return `<svg width="100" height="100">` +
+			Line(20, 50, 80, 50, Style{Stroke: "#000000", StrokeWidth: 10, StrokeLinecap: cap}) +
+			`</svg>`
+	}
+
+	butt := exportVisiblePixels(t, line(StrokeLinecapButt), 100, 100)
+	round := exportVisiblePixels(t, line(StrokeLinecapRound), 100, 100)
+	square := exportVisiblePixels(t, line(StrokeLinecapSquare), 100, 100)
+
+	// Butt: 60x10. Round adds a full 5px-radius disc, square a 10x10 box.
+	if butt < 580 || butt > 640 {
+		t.Fatalf("expected butt-capped line around 600 px, got %d", butt)
+	}
+	if !(butt < round && round < square) {
+		t.Fatalf("expected butt < round < square coverage, got %d, %d, %d", butt, round, square)
+	}
+	if square < 680 || square > 740 {
+		t.Fatalf("expected square-capped line around 700 px, got %d", square)
+	}
+}
+
+func TestExportStrokeDashArray(t *testing.T) {
+	solid := `<svg width="100" height="100">` +
+		Line(0, 50, 100, 50, Style{Stroke: "#000000", StrokeWidth: 4}) +
+		`</svg>`
+	dashed := `<svg width="100" height="100">` +
+		Line(0, 50, 100, 50, Style{Stroke: "#000000", StrokeWidth: 4, StrokeDashArray: "10,10"}) +
+		`</svg>`
+
+	solidPixels := exportVisiblePixels(t, solid, 100, 100)
+	dashedPixels := exportVisiblePixels(t, dashed, 100, 100)
+	if math.Abs(float64(dashedPixels)-float64(solidPixels)/2) > 20 {
+		t.Fatalf("expected dashes to cover half the solid line (%d px), got %d", solidPixels, dashedPixels)
+	}
+}
+
+func TestExportStrokeDenseDashArrayIsSolid(t *testing.T) {
+	solid := `<svg width="100" height="100"><path d="M1 1 L95 95" stroke="red" stroke-width="4"/></svg>`
+	dense := `<svg width="100" height="100"><path d="M1 1 L95 95" stroke="red" stroke-width="4" stroke-dasharray="0.0000001"/></svg>`
+
+	// Too many dashes to draw: the stroke is drawn solid instead
+	if got, want := exportVisiblePixels(t, dense, 100, 100), exportVisiblePixels(t, solid, 100, 100); got != want {
+		t.Fatalf("expected the dense dashes drawn solid (%d px), got %d", want, got)
+	}
+}
+
+func TestExportStrokeInheritedFromGroup(t *testing.T) {
+	svgData := `<svg width="100" height="100">` +
+		Group(Polyline([]Point{{X: 10, Y: 10}, {X: 90, Y: 10}, {X: 90, Y: 90}}, Style{}), "",
+			Style{Fill: "none", Stroke: "#ff0000", StrokeWidth: 2}) +
+		`</svg>`
+
+	if visible := exportVisiblePixels(t, svgData, 100, 100); visible < 300 {
+		t.Fatalf("expected inherited stroke to render, got %d px", visible)
+	}
+}
+
+func TestExportZeroStrokeWidthDisablesStroke(t *testing.T) {
+	svgData := `<svg width="100" height="100">
+		<line x1="10" y1="10" x2="90" y2="90" stroke="#000000" stroke-width="0"/>
+	</svg>`
+
+	if visible := exportVisiblePixels(t, svgData, 100, 100); visible != 0 {
+		t.Fatalf("expected zero-width stroke to be invisible, got %d px", visible)
+	}
+}
+
+func TestExportDefaultFillIsBlack(t *testing.T) {
+	svgData := `<svg width="10" height="10"><polygon points="0,0 10,0 10,10 0,10"/></svg>`
+
+	if visible := exportVisiblePixels(t, svgData, 10, 10); visible != 100 {
+		t.Fatalf("expected unfilled polygon to use the default black fill, got %d px", visible)
+	}
+}
+
+func TestParseDashArray(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []float64
+	}{
+		{"none", nil},
+		{"5,5", []float64{5, 5}},
+		{"5 10 15", []float64{5, 10, 15, 5, 10, 15}},
+		{"0 0", nil},
+		{"5,-1", nil},
+	}
+
+	for _, tt := range tests {
+		got := parseDashArray(tt.input, 96, 100)
+		if len(got) != len(tt.expected) {
+			t.Fatalf("parseDashArray(%q) = %v, expected %v", tt.input, got, tt.expected)
+		}
+		for i := range got {
+			if got[i] != tt.expected[i] {
+				t.Fatalf("parseDashArray(%q) = %v, expected %v", tt.input, got, tt.expected)
+			}
+		}
+	}
+}
+
+func TestStrokePolygonsMiterLimitFallsBackToBevel(t *testing.T) {
+	// A very sharp corner exceeds the default miter limit of 4.
+	path := []flattenedPath{{Points: []Point{{X: 0, Y: 0}, {X: 100, Y: 5}, {X: 0, Y: 10}}}}
+	style := strokeStyle{Width: 4, Linejoin: StrokeLinejoinMiter, MiterLimit: 4}
+
+	for _, poly := range strokePolygons(path, style, flattenTolerance) {
+		for _, p := range poly {
+			if p.X > 110 {
+				t.Fatalf("expected miter to be beveled, found point %v", p)
+			}
+		}
+	}
+}
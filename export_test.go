@@ -2,6 +2,7 @@ package svg
 
 import (
 	"bytes"
+	"image"
 	"image/color"
 	"image/png"
 	"math"
@@ -138,7 +139,7 @@ func TestExportMalformedSVGReturnsError(t *testing.T) {
 }
 
 func TestExportUnsupportedElementsReturnErrorByDefault(t *testing.T) {
-	svgData := `<svg width="100" height="100"><text x="10" y="20">hello</text></svg>`
+	svgData := `<svg width="100" height="100"><image x="10" y="20" width="10" height="10" href="a.png"/></svg>`
 
 	_, err := Export(svgData, ExportOptions{
 		Format: FormatPNG,
@@ -153,14 +154,14 @@ func TestExportUnsupportedElementsReturnErrorByDefault(t *testing.T) {
 	if !ok {
 		t.Fatalf("expected UnsupportedElementsError, got %T: %v", err, err)
 	}
-	if len(unsupportedErr.Elements) == 0 || unsupportedErr.Elements[0] != "text" {
-		t.Fatalf("expected text to be reported unsupported, got %#v", unsupportedErr.Elements)
+	if len(unsupportedErr.Elements) == 0 || unsupportedErr.Elements[0] != "image" {
+		t.Fatalf("expected image to be reported unsupported, got %#v", unsupportedErr.Elements)
 	}
 }
 
 func TestExportUnsupportedElementsCanBeIgnored(t *testing.T) {
 	svgData := `<svg width="100" height="100">
-		<text x="10" y="20">hello</text>
+		<image x="10" y="20" width="10" height="10" href="a.png"/>
 		<rect x="0" y="0" width="10" height="10" fill="#ff0000"/>
 	</svg>`
 
@@ -533,3 +534,53 @@ func colorDistance(a, b color.RGBA) int {
 	}
 	return max
 }
+
+func TestExportPathFill(t *testing.T) {
+	svgData := `<svg width="100" height="100">` +
+		Path(RectPath(10, 10, 50, 40), Style{Fill: "#ff0000"}) +
+		`</svg>`
+
+	result, err := Export(svgData, ExportOptions{
+		Format: FormatPNG,
+		Width:  100,
+		Height: 100,
+	})
+	if err != nil {
+		t.Fatalf("path export failed: %v", err)
+	}
+
+	// Expected area is 50 * 40 = 2000 px.
+	visible := countVisiblePixelsFromPNG(t, result)
+	if visible < 1900 || visible > 2100 {
+		t.Fatalf("expected visible pixels around 2000, got %d", visible)
+	}
+}
+
+func TestExportAreaPathRenders(t *testing.T) {
+	points := []Point{{X: 0, Y: 50}, {X: 25, Y: 20}, {X: 50, Y: 40}, {X: 100, Y: 10}}
+	svgData := `<svg width="100" height="100">` +
+		Path(SmoothAreaPath(points, 100, 0.3), Style{Fill: "#0000ff"}) +
+		`</svg>`
+
+	result, err := Export(svgData, ExportOptions{
+		Format: FormatPNG,
+		Width:  100,
+		Height: 100,
+	})
+	if err != nil {
+		t.Fatalf("area path export failed: %v", err)
+	}
+	if countVisiblePixelsFromPNG(t, result) == 0 {
+		t.Fatal("expected area path to render")
+	}
+}
+
+func decodePNG(t *testing.T, pngData []byte) image.Image {
+	t.Helper()
+
+	img, err := png.Decode(bytes.NewReader(pngData))
+	if err != nil {
+		t.Fatalf("failed to decode PNG: %v", err)
+	}
+	return img
+}
@@ -0,0 +1,454 @@
+package svg
+
+import (
+	"image"
+	"strconv"
+	"strings"
+
+	"github.com/SCKelemen/units"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+const defaultFontSize = 16.0
+
+// fontSizeKeywords maps the CSS absolute-size keywords to pixels.
+var fontSizeKeywords = map[string]float64{
+	"xx-small": 9,
+	"x-small":  10,
+	"small":    13,
+	"medium":   16,
+	"large":    18,
+	"x-large":  24,
+	"xx-large": 32,
+}
+
+// resolveFontSize converts a font-size value into pixels. Relative sizes
+// (em, ex, %, smaller, larger) resolve against the parent font size.
+// Invalid values inherit the parent size.
+func resolveFontSize(value string, parent, dpi float64) float64 {
+	token := strings.ToLower(strings.TrimSpace(value))
+	if px, ok := fontSizeKeywords[token]; ok {
+		return px
+	}
+	switch token {
+	case "smaller":
+		return parent / 1.2
+	case "larger":
+		return parent * 1.2
+	}
+	if strings.HasSuffix(token, "%") {
+		return parseLengthFloatWithReference(token, dpi, parent)
+	}
+
+	matches := cssLengthTokenPattern.FindStringSubmatch(token)
+	if len(matches) != 3 {
+		return parent
+	}
+	val, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil || val < 0 {
+		return parent
+	}
+
+	var length units.Length
+	switch matches[2] {
+	case "em":
+		length = units.Em(val)
+	case "rem":
+		length = units.Rem(val)
+	case "ex":
+		length = units.Ex(val)
+	default:
+		if _, ok := absoluteLengthFromUnit(val, matches[2]); !ok && matches[2] != "" {
+			return parent
+		}
+		return parseLengthFloatWithReference(token, dpi, 0)
+	}
+	px, err := length.Resolve(&units.Context{
+		FontSize:     parent,
+		RootFontSize: defaultFontSize,
+		XHeight:      parent / 2,
+	})
+	if err != nil {
+		return parent
+	}
+	return px.Value
+}
+
+// fontSizeFromProps returns the resolved font size in computed properties.
+func fontSizeFromProps(props map[string]string, dpi float64) float64 {
+	v, ok := props["font-size"]
+	if !ok {
+		return defaultFontSize
+	}
+	return resolveFontSize(v, defaultFontSize, dpi)
+}
+
+// parseFontWeight converts a font-weight value to a numeric CSS weight.
+func parseFontWeight(s string) int {
+	switch strings.TrimSpace(s) {
+	case "", "normal":
+		return 400
+	case "bold", "bolder":
+		return 700
+	case "lighter":
+		return 300
+	}
+	w, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || w < 1 || w > 1000 {
+		return 400
+	}
+	return w
+}
+
+func isItalic(props map[string]string) bool {
+	switch strings.TrimSpace(props["font-style"]) {
+	case "italic", "oblique":
+		return true
+	}
+	return false
+}
+
+// textChar is an addressable character of a text element together with the
+// style of the element that contains it and its explicit positioning.
+type textChar struct {
+	r      rune
+	props  map[string]string
+	x, y   *float64
+	dx, dy float64
+}
+
+// textCollector gathers the characters of a <text> element in document order,
+// applying xml:space whitespace handling across nested <tspan> elements.
+type textCollector struct {
+	state    *rasterRenderState
+	dpi      float64
+	viewport Point
+	chars    []textChar
+}
+
+func (c *textCollector) collect(elem *svgElement, preserve bool) {
+	props := c.state.computedStyle(elem)
+	if v, ok := elem.Attributes["space"]; ok {
+		preserve = v == "preserve"
+	}
+
+	start := len(c.chars)
+	c.state.pushStyle(elem)
+	for _, child := range elem.Children {
+		switch child.Tag {
+		case charDataTag:
+			c.appendText(child.Text, props, preserve)
+		case "tspan", "a":
+			c.collect(child, preserve)
+		case "textPath":
+			c.state.addUnsupported(child.Tag)
+		}
+	}
+	c.state.popStyle()
+
+	c.applyPositions(elem, start)
+}
+
+func (c *textCollector) appendText(text string, props map[string]string, preserve bool) {
+	for _, r := range text {
+		switch r {
+		case '\n', '\r', '\t':
+			r = ' '
+		}
+		if r == ' ' && !preserve {
+			if len(c.chars) == 0 || c.chars[len(c.chars)-1].r == ' ' {
+				continue
+			}
+		}
+		c.chars = append(c.chars, textChar{r: r, props: props})
+	}
+}
+
+// applyPositions assigns the x, y, dx and dy lists of elem to its characters
+// starting at index start. Descendants are processed first, so their values
+// take precedence over the ones of their ancestors.
+func (c *textCollector) applyPositions(elem *svgElement, start int) {
+	chars := c.chars[start:]
+	for i, v := range parseLengthList(elem.Attributes["x"], c.dpi, c.viewport.X) {
+		if i < len(chars) && chars[i].x == nil {
+			v := v
+			chars[i].x = &v
+		}
+	}
+	for i, v := range parseLengthList(elem.Attributes["y"], c.dpi, c.viewport.Y) {
+		if i < len(chars) && chars[i].y == nil {
+			v := v
+			chars[i].y = &v
+		}
+	}
+	for i, v := range parseLengthList(elem.Attributes["dx"], c.dpi, c.viewport.X) {
+		if i < len(chars) {
+			chars[i].dx += v
+		}
+	}
+	for i, v := range parseLengthList(elem.Attributes["dy"], c.dpi, c.viewport.Y) {
+		if i < len(chars) {
+			chars[i].dy += v
+		}
+	}
+}
+
+// parseLengthList parses a whitespace or comma separated list of lengths.
+func parseLengthList(s string, dpi, reference float64) []float64 {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	if len(fields) == 0 {
+		return nil
+	}
+	out := make([]float64, len(fields))
+	for i, f := range fields {
+		out[i] = parseLengthFloatWithReference(f, dpi, reference)
+	}
+	return out
+}
+
+// fontFace is a resolved font at a specific size.
+type fontFace struct {
+	font  *opentype.Font
+	size  float64
+	scale float64 // pixels per font unit
+	ppem  fixed.Int26_6
+}
+
+func newFontFace(f *opentype.Font, size float64) fontFace {
+	upem := float64(f.UnitsPerEm())
+	return fontFace{
+		font:  f,
+		size:  size,
+		scale: size / upem,
+		ppem:  fixed.I(int(upem)),
+	}
+}
+
+// baselineShift returns the vertical offset from the alphabetic baseline to
+// the requested dominant-baseline.
+func (f fontFace) baselineShift(buf *sfnt.Buffer, baseline string) float64 {
+	metrics, err := f.font.Metrics(buf, f.ppem, font.HintingNone)
+	if err != nil {
+		return 0
+	}
+	ascent := fixedToFloat(metrics.Ascent) * f.scale
+	descent := fixedToFloat(metrics.Descent) * f.scale
+	xHeight := fixedToFloat(metrics.XHeight) * f.scale
+	if xHeight <= 0 {
+		xHeight = f.size / 2
+	}
+
+	switch strings.TrimSpace(baseline) {
+	case "middle":
+		return xHeight / 2
+	case "central":
+		return (ascent - descent) / 2
+	case "hanging":
+		return 0.8 * ascent
+	case "mathematical":
+		return 0.5 * ascent
+	case "text-top", "text-before-edge":
+		return ascent
+	case "text-bottom", "text-after-edge", "ideographic":
+		return -descent
+	}
+	return 0
+}
+
+func fixedToFloat(v fixed.Int26_6) float64 {
+	return float64(v) / 64
+}
+
+// placedGlyph is a glyph positioned on the page in user space.
+type placedGlyph struct {
+	face   fontFace
+	index  sfnt.GlyphIndex
+	origin Point
+	props  map[string]string
+}
+
+// textRun is the outline of consecutive glyphs that share a computed style.
+type textRun struct {
+	segs  []pathSegment
+	props map[string]string
+}
+
+// renderText lays out and paints a <text> element.
+func renderText(elem *svgElement, img *image.RGBA, rasterizer *vector.Rasterizer, dpi float64, state *rasterRenderState) error {
+	if img.Bounds().Empty() {
+		return nil
+	}
+
+	runs := layoutText(elem, dpi, state)
+	// Paint servers use the bounding box of the whole text element.
+	bbox, _ := textRunsBounds(runs)
+	for _, r := range runs {
+		if len(r.segs) > 0 {
+			paintPath(r.segs, bbox, r.props, true, img, rasterizer, dpi, state)
+		}
+	}
+	return nil
+}
+
+// textRunsBounds returns the bounding box of the glyph outlines.
+func textRunsBounds(runs []textRun) (boundingBox, bool) {
+	var all []pathSegment
+	for _, r := range runs {
+		all = append(all, r.segs...)
+	}
+	return segmentsBounds(all)
+}
+
+// layoutText positions the characters of a <text> element in user space and
+// returns their outlines. Each character is shaped with the font resolved
+// from its computed font properties; glyphs missing from that font fall back
+// to the embedded Go fonts.
+func layoutText(elem *svgElement, dpi float64, state *rasterRenderState) []textRun {
+	collector := &textCollector{state: state, dpi: dpi, viewport: state.viewport()}
+	collector.collect(elem, false)
+	chars := collector.chars
+	if n := len(chars); n > 0 && chars[n-1].r == ' ' && chars[n-1].x == nil && chars[n-1].y == nil {
+		chars = chars[:n-1]
+	}
+	if len(chars) == 0 {
+		return nil
+	}
+
+	var buf sfnt.Buffer
+	glyphs := make([]placedGlyph, 0, len(chars))
+	var pen Point
+	chunkStart := 0
+	prev := -1
+
+	finishChunk := func() {
+		if chunkStart >= len(glyphs) {
+			return
+		}
+		first := glyphs[chunkStart]
+		width := pen.X - first.origin.X
+		var shift float64
+		switch strings.TrimSpace(first.props["text-anchor"]) {
+		case "middle":
+			shift = -width / 2
+		case "end":
+			shift = -width
+		}
+		for i := chunkStart; i < len(glyphs); i++ {
+			glyphs[i].origin.X += shift
+		}
+		chunkStart = len(glyphs)
+	}
+
+	for _, ch := range chars {
+		if ch.x != nil || ch.y != nil {
+			finishChunk()
+			prev = -1
+			if ch.x != nil {
+				pen.X = *ch.x
+			}
+			if ch.y != nil {
+				pen.Y = *ch.y
+			}
+		}
+		pen.X += ch.dx
+		pen.Y += ch.dy
+
+		size := fontSizeFromProps(ch.props, dpi)
+		weight := parseFontWeight(ch.props["font-weight"])
+		italic := isItalic(ch.props)
+		f := state.fonts.resolve(ch.props["font-family"], weight, italic)
+		idx, err := f.GlyphIndex(&buf, ch.r)
+		if err != nil || idx == 0 {
+			fallback := goFontRegistry().ResolveFont("Go", weight, italic)
+			if fi, ferr := fallback.GlyphIndex(&buf, ch.r); ferr == nil && fi != 0 {
+				f, idx = fallback, fi
+			}
+		}
+		face := newFontFace(f, size)
+
+		if prev >= 0 && glyphs[prev].face == face {
+			if kern, err := f.Kern(&buf, glyphs[prev].index, idx, face.ppem, font.HintingNone); err == nil {
+				pen.X += fixedToFloat(kern) * face.scale
+			}
+		}
+
+		g := placedGlyph{face: face, index: idx, origin: pen, props: ch.props}
+		g.origin.Y += face.baselineShift(&buf, ch.props["dominant-baseline"])
+		glyphs = append(glyphs, g)
+		prev = len(glyphs) - 1
+
+		if adv, err := f.GlyphAdvance(&buf, idx, face.ppem, font.HintingNone); err == nil {
+			pen.X += fixedToFloat(adv) * face.scale
+		}
+	}
+	finishChunk()
+
+	// Group glyphs that share the style of the same element.
+	var runs []textRun
+	for start := 0; start < len(glyphs); {
+		end := start + 1
+		for end < len(glyphs) && sameProps(glyphs[end].props, glyphs[start].props) {
+			end++
+		}
+		var segs []pathSegment
+		for _, g := range glyphs[start:end] {
+			segs = append(segs, glyphSegments(&buf, g)...)
+		}
+		runs = append(runs, textRun{segs: segs, props: glyphs[start].props})
+		start = end
+	}
+	return runs
+}
+
+// sameProps reports whether a and b are the same computed style map.
+func sameProps(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// glyphSegments returns the outline of a placed glyph as closed path
+// segments in user space.
+func glyphSegments(buf *sfnt.Buffer, g placedGlyph) []pathSegment {
+	outline, err := g.face.font.LoadGlyph(buf, g.index, g.face.ppem, nil)
+	if err != nil || len(outline) == 0 {
+		return nil
+	}
+
+	pt := func(p fixed.Point26_6) Point {
+		return Point{
+			X: g.origin.X + fixedToFloat(p.X)*g.face.scale,
+			Y: g.origin.Y + fixedToFloat(p.Y)*g.face.scale,
+		}
+	}
+
+	segs := make([]pathSegment, 0, len(outline)+1)
+	for _, s := range outline {
+		switch s.Op {
+		case sfnt.SegmentOpMoveTo:
+			if len(segs) > 0 {
+				segs = append(segs, pathSegment{Op: pathOpClose})
+			}
+			segs = append(segs, pathSegment{Op: pathOpMoveTo, Pts: [3]Point{pt(s.Args[0])}})
+		case sfnt.SegmentOpLineTo:
+			segs = append(segs, pathSegment{Op: pathOpLineTo, Pts: [3]Point{pt(s.Args[0])}})
+		case sfnt.SegmentOpQuadTo:
+			segs = append(segs, pathSegment{Op: pathOpQuadTo, Pts: [3]Point{pt(s.Args[0]), pt(s.Args[1])}})
+		case sfnt.SegmentOpCubeTo:
+			segs = append(segs, pathSegment{Op: pathOpCubeTo, Pts: [3]Point{pt(s.Args[0]), pt(s.Args[1]), pt(s.Args[2])}})
+		}
+	}
+	return append(segs, pathSegment{Op: pathOpClose})
+}
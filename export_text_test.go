@@ -0,0 +1,219 @@
+package svg
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/opentype"
+)
+
+// exportBounds returns the bounding box of the visible pixels of the export.
+func exportBounds(t *testing.T, svgData string, width, height int) image.Rectangle {
+	t.Helper()
+
+	result, err := Export(svgData, ExportOptions{Format: FormatPNG, Width: width, Height: height})
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	img := decodePNG(t, result)
+	var bounds image.Rectangle
+	for y := img.Bounds().Min.Y; y < img.Bounds().Max.Y; y++ {
+		for x := img.Bounds().Min.X; x < img.Bounds().Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a > 0x8000 {
+				bounds = bounds.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return bounds
+}
+
+func TestExportTextRenders(t *testing.T) {
+	svgData := `<svg width="200" height="50">` +
+		Text("Hello", 10, 30, Style{Fill: "#000000"}) +
+		`</svg>`
+
+	bounds := exportBounds(t, svgData, 200, 50)
+	if bounds.Empty() {
+		t.Fatal("expected text to render")
+	}
+	if bounds.Min.X < 9 || bounds.Max.Y > 31 || bounds.Min.Y < 15 {
+		t.Fatalf("expected glyphs above the baseline right of x=10, got %v", bounds)
+	}
+}
+
+func TestExportTextAnchor(t *testing.T) {
+	text := func(anchor TextAnchor) string {
+		return `<svg width="200" height="50">` +
+			Text("Anchor", 100, 30, Style{TextAnchor: anchor}) +
+			`</svg>`
+	}
+
+	start := exportBounds(t, text(TextAnchorStart), 200, 50)
+	middle := exportBounds(t, text(TextAnchorMiddle), 200, 50)
+	end := exportBounds(t, text(TextAnchorEnd), 200, 50)
+
+	if start.Min.X < 99 {
+		t.Fatalf("start-anchored text should begin at x=100, got %v", start)
+	}
+	if end.Max.X > 101 {
+		t.Fatalf("end-anchored text should end at x=100, got %v", end)
+	}
+	center := float64(middle.Min.X+middle.Max.X) / 2
+	if math.Abs(center-100) > 2 {
+		t.Fatalf("middle-anchored text should be centered on x=100, got %v", middle)
+	}
+}
+
+func TestExportTextFontSize(t *testing.T) {
+	text := func(size string) string {
+		return `<svg width="300" height="100"><g font-size="20"><text x="10" y="80" font-size="` +
+			size + `">H</text></g></svg>`
+	}
+
+	small := exportBounds(t, text("1em"), 300, 100)
+	large := exportBounds(t, text("2em"), 300, 100)
+	if large.Dy() < 2*small.Dy()-2 || large.Dy() > 2*small.Dy()+2 {
+		t.Fatalf("expected 2em glyph to be twice as tall as 1em, got %d and %d", large.Dy(), small.Dy())
+	}
+}
+
+func TestExportTextSpanOffsets(t *testing.T) {
+	svgData := `<svg width="200" height="100">` +
+		TextWithSpans(10, 30, Style{}, []string{TSpan("A", Style{}, 0, 0), TSpan("B", Style{}, 0, 40)}) +
+		`</svg>`
+
+	bounds := exportBounds(t, svgData, 200, 100)
+	if bounds.Max.Y < 65 {
+		t.Fatalf("expected dy to move the second span down, got %v", bounds)
+	}
+}
+
+func TestExportTextDominantBaseline(t *testing.T) {
+	text := func(baseline DominantBaseline) string {
+		return `<svg width="200" height="100">` +
+			Text("Hx", 10, 50, Style{DominantBaseline: baseline}) +
+			`</svg>`
+	}
+
+	alphabetic := exportBounds(t, text(DominantBaselineAlphabetic), 200, 100)
+	hanging := exportBounds(t, text(DominantBaselineHanging), 200, 100)
+	if alphabetic.Max.Y > 51 {
+		t.Fatalf("alphabetic text should sit on the baseline, got %v", alphabetic)
+	}
+	if hanging.Min.Y < 48 {
+		t.Fatalf("hanging text should hang below the baseline, got %v", hanging)
+	}
+}
+
+func TestTextCollectorWhitespace(t *testing.T) {
+	root, err := parseSVG(`<svg><text>  a
+	b  <tspan> c</tspan></text><text xml:space="preserve"> a  b</text></svg>`)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	tests := []struct {
+		elem *svgElement
+		want string
+	}{
+		{root.Children[0], "a b c"},
+		{root.Children[1], " a  b"},
+	}
+	for _, tt := range tests {
+		c := &textCollector{state: newRasterRenderState(ExportOptions{}), dpi: defaultRasterDPI}
+		c.collect(tt.elem, false)
+		var got []rune
+		for _, ch := range c.chars {
+			got = append(got, ch.r)
+		}
+		if string(got) != tt.want {
+			t.Errorf("collected %q, want %q", string(got), tt.want)
+		}
+	}
+}
+
+func TestResolveFontSize(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+	}{
+		{"12", 12},
+		{"12px", 12},
+		{"12pt", 16},
+		{"2em", 40},
+		{"1.5rem", 24},
+		{"50%", 10},
+		{"large", 18},
+		{"bogus", 20},
+	}
+	for _, tt := range tests {
+		if got := resolveFontSize(tt.value, 20, defaultRasterDPI); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("resolveFontSize(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFontRegistryResolvesClosestFace(t *testing.T) {
+	regular, err := opentype.Parse(gomono.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bold, err := opentype.Parse(gomono.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewFontRegistry()
+	r.RegisterFont("Brand Sans", 400, false, regular)
+	r.RegisterFont("Brand Sans", 700, false, bold)
+
+	if got := r.ResolveFont("brand sans", 600, false); got != bold {
+		t.Error("expected weight 600 to resolve to the bold face")
+	}
+	if got := r.ResolveFont("'Brand Sans'", 300, true); got != regular {
+		t.Error("expected weight 300 to resolve to the regular face")
+	}
+	if got := r.ResolveFont("Other", 400, false); got != nil {
+		t.Error("expected unknown family to resolve to nil")
+	}
+	if err := r.Register("Broken", 400, false, []byte("not a font")); err == nil {
+		t.Error("expected invalid font data to fail")
+	}
+}
+
+func TestExportTextUsesFontResolver(t *testing.T) {
+	mono, err := opentype.Parse(gomono.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fonts := NewFontRegistry()
+	fonts.RegisterFont("Brand", 400, false, mono)
+
+	export := func(opts ExportOptions) image.Image {
+		svgData := `<svg width="300" height="50"><text x="0" y="30" font-family="Brand, sans-serif">iiiiiiii</text></svg>`
+		opts.Format = FormatPNG
+		result, err := Export(svgData, opts)
+		if err != nil {
+			t.Fatalf("export failed: %v", err)
+		}
+		return decodePNG(t, result)
+	}
+	width := func(img image.Image) int {
+		maxX := 0
+		for y := 0; y < img.Bounds().Dy(); y++ {
+			for x := 0; x < img.Bounds().Dx(); x++ {
+				if _, _, _, a := img.At(x, y).RGBA(); a > 0 && x > maxX {
+					maxX = x
+				}
+			}
+		}
+		return maxX
+	}
+
+	// Monospaced "i" glyphs are much wider than proportional ones.
+	if proportional, monospaced := width(export(ExportOptions{})), width(export(ExportOptions{Fonts: fonts})); monospaced <= proportional {
+		t.Fatalf("expected registered monospace font to be used, got widths %d and %d", proportional, monospaced)
+	}
+}
@@ -0,0 +1,129 @@
+package svg
+
+import (
+	"math"
+	"strings"
+)
+
+// transformSegments applies m to every point of the path segments. Affine
+// transforms map Bézier curves onto Bézier curves, so this is exact.
+func transformSegments(segs []pathSegment, m Matrix) []pathSegment {
+	if m.IsIdentity() {
+		return segs
+	}
+	out := make([]pathSegment, len(segs))
+	for i, seg := range segs {
+		out[i] = seg
+		for j, p := range seg.Pts {
+			out[i].Pts[j] = m.Apply(p)
+		}
+	}
+	return out
+}
+
+func transformPolygons(polys [][]Point, m Matrix) [][]Point {
+	if m.IsIdentity() {
+		return polys
+	}
+	for _, poly := range polys {
+		for i, p := range poly {
+			poly[i] = m.Apply(p)
+		}
+	}
+	return polys
+}
+
+// parseViewBox parses a viewBox attribute into min-x, min-y, width and height.
+func parseViewBox(s string) ([4]float64, bool) {
+	var vb [4]float64
+	sc := &pathDataScanner{s: s}
+	for i := range vb {
+		v, err := sc.number()
+		if err != nil {
+			return vb, false
+		}
+		vb[i] = v
+	}
+	sc.skipSeparators()
+	if !sc.done() || vb[2] <= 0 || vb[3] <= 0 {
+		return vb, false
+	}
+	return vb, true
+}
+
+// viewBoxTransform maps a viewBox onto a viewport of the given size according
+// to a preserveAspectRatio value.
+func viewBoxTransform(vb [4]float64, preserveAspectRatio string, width, height float64) Matrix {
+	sx := width / vb[2]
+	sy := height / vb[3]
+
+	fields := strings.Fields(preserveAspectRatio)
+	if len(fields) > 0 && fields[0] == "defer" {
+		fields = fields[1:]
+	}
+	align := "xMidYMid"
+	slice := false
+	if len(fields) > 0 {
+		align = fields[0]
+	}
+	if len(fields) > 1 && fields[1] == "slice" {
+		slice = true
+	}
+
+	if align == "none" {
+		return ScaleMatrix(sx, sy).Multiply(TranslateMatrix(-vb[0], -vb[1]))
+	}
+
+	s := math.Min(sx, sy)
+	if slice {
+		s = math.Max(sx, sy)
+	}
+
+	tx := -vb[0] * s
+	ty := -vb[1] * s
+	extraX := width - vb[2]*s
+	extraY := height - vb[3]*s
+
+	switch {
+	case strings.HasPrefix(align, "xMid"):
+		tx += extraX / 2
+	case strings.HasPrefix(align, "xMax"):
+		tx += extraX
+	}
+	switch {
+	case strings.HasSuffix(align, "YMid"):
+		ty += extraY / 2
+	case strings.HasSuffix(align, "YMax"):
+		ty += extraY
+	}
+
+	return Matrix{A: s, D: s, E: tx, F: ty}
+}
+
+// svgViewport returns the transform that establishes the user coordinate
+// system of an <svg> element and the size of its viewport in user units.
+// The outermost <svg> fills the output canvas; nested elements are positioned
+// with x/y/width/height relative to the parent viewport.
+func svgViewport(elem *svgElement, root bool, parent Point, canvasWidth, canvasHeight int, dpi float64) (Matrix, Point) {
+	x, y := 0.0, 0.0
+	w, h := float64(canvasWidth), float64(canvasHeight)
+	if !root {
+		x = parseLengthFloatWithReference(elem.Attributes["x"], dpi, parent.X)
+		y = parseLengthFloatWithReference(elem.Attributes["y"], dpi, parent.Y)
+		w, h = parent.X, parent.Y
+		if v, ok := elem.Attributes["width"]; ok {
+			w = parseLengthFloatWithReference(v, dpi, parent.X)
+		}
+		if v, ok := elem.Attributes["height"]; ok {
+			h = parseLengthFloatWithReference(v, dpi, parent.Y)
+		}
+	}
+
+	m := TranslateMatrix(x, y)
+	vb, ok := parseViewBox(elem.Attributes["viewBox"])
+	if !ok || w <= 0 || h <= 0 {
+		return m, Point{X: w, Y: h}
+	}
+	m = m.Multiply(viewBoxTransform(vb, elem.Attributes["preserveAspectRatio"], w, h))
+	return m, Point{X: vb[2], Y: vb[3]}
+}
@@ -0,0 +1,68 @@
+package svg
+
+import "testing"
+
+func TestViewBoxTransform(t *testing.T) {
+	vb := [4]float64{0, 0, 100, 50}
+
+	meet := viewBoxTransform(vb, "", 200, 200)
+	if !matrixApproxEqual(meet, Matrix{A: 2, D: 2, E: 0, F: 50}) {
+		t.Fatalf("unexpected xMidYMid meet transform: %+v", meet)
+	}
+
+	slice := viewBoxTransform(vb, "xMinYMin slice", 200, 200)
+	if !matrixApproxEqual(slice, Matrix{A: 4, D: 4}) {
+		t.Fatalf("unexpected xMinYMin slice transform: %+v", slice)
+	}
+
+	none := viewBoxTransform([4]float64{10, 10, 100, 50}, "none", 200, 200)
+	if !matrixApproxEqual(none, Matrix{A: 2, D: 4, E: -20, F: -40}) {
+		t.Fatalf("unexpected none transform: %+v", none)
+	}
+}
+
+func TestExportViewBoxScalesContent(t *testing.T) {
+	svgData := `<svg width="100" height="100" viewBox="0 0 50 50">
+		<rect x="0" y="0" width="25" height="25" fill="#ff0000"/>
+	</svg>`
+
+	// The 25x25 user-unit rect covers 50x50 device pixels.
+	visible := exportVisiblePixels(t, svgData, 100, 100)
+	if visible < 2450 || visible > 2550 {
+		t.Fatalf("expected viewBox to scale content to about 2500 px, got %d", visible)
+	}
+}
+
+func TestExportGroupTransform(t *testing.T) {
+	svgData := `<svg width="100" height="100">` +
+		Group(Rect(0, 0, 10, 10, Style{Fill: "#ff0000"}), "translate(50,50) scale(2)", Style{}) +
+		`</svg>`
+
+	result, err := Export(svgData, ExportOptions{Format: FormatPNG, Width: 100, Height: 100})
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	img := decodePNG(t, result)
+
+	if _, _, _, a := img.At(5, 5).RGBA(); a != 0 {
+		t.Fatal("expected untransformed position to stay empty")
+	}
+	if _, _, _, a := img.At(65, 65).RGBA(); a == 0 {
+		t.Fatal("expected transformed rect to cover (65,65)")
+	}
+	if visible := countVisiblePixelsFromPNG(t, result); visible < 380 || visible > 420 {
+		t.Fatalf("expected scaled rect to cover about 400 px, got %d", visible)
+	}
+}
+
+func TestExportNonUniformScaleStretchesStroke(t *testing.T) {
+	// A horizontal line scaled 1x4 should produce a 4px-tall stroke.
+	svgData := `<svg width="100" height="100">
+		<line x1="10" y1="10" x2="90" y2="10" stroke="#000000" stroke-width="1" transform="scale(1,4)"/>
+	</svg>`
+
+	visible := exportVisiblePixels(t, svgData, 100, 100)
+	if visible < 300 || visible > 340 {
+		t.Fatalf("expected stroke stretched to about 320 px, got %d", visible)
+	}
+}
@@ -0,0 +1,181 @@
+package svg
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/gomedium"
+	"golang.org/x/image/font/gofont/gomediumitalic"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/gofont/gomonobolditalic"
+	"golang.org/x/image/font/gofont/gomonoitalic"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+)
+
+// FontResolver resolves a font family to a parsed font for text rasterization.
+// weight is a CSS numeric weight (100-900). Implementations return nil when
+// they have no font for the family, letting the exporter try the next family
+// in the font-family list.
+type FontResolver interface {
+	ResolveFont(family string, weight int, italic bool) *opentype.Font
+}
+
+// FontRegistry is a FontResolver backed by font files registered by family name.
+// It is safe for concurrent use.
+type FontRegistry struct {
+	mu    sync.RWMutex
+	faces map[string][]registeredFont
+}
+
+type registeredFont struct {
+	weight int
+	italic bool
+	font   *opentype.Font
+}
+
+// NewFontRegistry creates an empty font registry
+func NewFontRegistry() *FontRegistry {
+	return &FontRegistry{
+		faces: make(map[string][]registeredFont),
+	}
+}
+
+// Register parses a TTF/OTF file and registers it under the given family name
+// with its weight (100-900, 0 means 400) and style.
+func (r *FontRegistry) Register(family string, weight int, italic bool, data []byte) error {
+	f, err := opentype.Parse(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse font %q: %w", family, err)
+	}
+	r.RegisterFont(family, weight, italic, f)
+	return nil
+}
+
+// RegisterFont registers an already parsed font under the given family name.
+func (r *FontRegistry) RegisterFont(family string, weight int, italic bool, f *opentype.Font) {
+	if weight == 0 {
+		weight = 400
+	}
+	key := normalizeFontFamily(family)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.faces[key] = append(r.faces[key], registeredFont{weight: weight, italic: italic, font: f})
+}
+
+// ResolveFont returns the registered face of family that best matches the
+// requested weight and style, or nil if the family is unknown.
+func (r *FontRegistry) ResolveFont(family string, weight int, italic bool) *opentype.Font {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	faces := r.faces[normalizeFontFamily(family)]
+	var best *registeredFont
+	bestScore := 0
+	for i := range faces {
+		face := &faces[i]
+		score := fontMatchScore(face.weight, face.italic, weight, italic)
+		if best == nil || score < bestScore {
+			best, bestScore = face, score
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return best.font
+}
+
+// fontMatchScore ranks a face against a request; lower is better. A style
+// mismatch outweighs any weight difference.
+func fontMatchScore(faceWeight int, faceItalic bool, weight int, italic bool) int {
+	score := faceWeight - weight
+	if score < 0 {
+		score = -score
+	}
+	if faceItalic != italic {
+		score += 1000
+	}
+	return score
+}
+
+func normalizeFontFamily(family string) string {
+	return strings.ToLower(strings.Trim(strings.TrimSpace(family), `"'`))
+}
+
+// goFonts lazily parses the embedded Go font family used as the fallback.
+var goFonts = struct {
+	once     sync.Once
+	registry *FontRegistry
+}{}
+
+func goFontRegistry() *FontRegistry {
+	goFonts.once.Do(func() {
+		r := NewFontRegistry()
+		fonts := []struct {
+			family string
+			weight int
+			italic bool
+			data   []byte
+		}{
+			{"Go", 400, false, goregular.TTF},
+			{"Go", 400, true, goitalic.TTF},
+			{"Go", 500, false, gomedium.TTF},
+			{"Go", 500, true, gomediumitalic.TTF},
+			{"Go", 700, false, gobold.TTF},
+			{"Go", 700, true, gobolditalic.TTF},
+			{"Go Mono", 400, false, gomono.TTF},
+			{"Go Mono", 400, true, gomonoitalic.TTF},
+			{"Go Mono", 700, false, gomonobold.TTF},
+			{"Go Mono", 700, true, gomonobolditalic.TTF},
+		}
+		for _, f := range fonts {
+			// The embedded fonts are known to be valid.
+			if err := r.Register(f.family, f.weight, f.italic, f.data); err != nil {
+				panic(err)
+			}
+		}
+		goFonts.registry = r
+	})
+	return goFonts.registry
+}
+
+// fontSet resolves font-family lists using an optional user resolver with the
+// Go fonts as fallback.
+type fontSet struct {
+	resolver FontResolver
+}
+
+func newFontSet(resolver FontResolver) *fontSet {
+	return &fontSet{resolver: resolver}
+}
+
+// resolve returns the first font in the comma-separated family list that is
+// available. The generic "monospace" family maps to Go Mono, and anything
+// else falls back to Go.
+func (s *fontSet) resolve(families string, weight int, italic bool) *opentype.Font {
+	builtin := goFontRegistry()
+	for _, family := range strings.Split(families, ",") {
+		family = strings.Trim(strings.TrimSpace(family), `"'`)
+		if family == "" {
+			continue
+		}
+		if s.resolver != nil {
+			if f := s.resolver.ResolveFont(family, weight, italic); f != nil {
+				return f
+			}
+		}
+		if normalizeFontFamily(family) == "monospace" {
+			family = "go mono"
+		}
+		if f := builtin.ResolveFont(family, weight, italic); f != nil {
+			return f
+		}
+	}
+	return builtin.ResolveFont("Go", weight, italic)
+}
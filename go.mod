@@ -8,6 +8,8 @@ require (
 	golang.org/x/image v0.35.0
 )
 
+require golang.org/x/text v0.33.0 // indirect
+
 require (
 	github.com/SCKelemen/layout v1.1.3
 	github.com/SCKelemen/text v1.1.3 // indirect
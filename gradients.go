@@ -40,6 +40,7 @@ type LinearGradientDef struct {
 	Stops        []GradientStop
 	Units        GradientUnits
 	SpreadMethod GradientSpreadMethod
+	NumberFormat NumberFormat // How stop opacities are written
 }
 
 // RadialGradientDef represents a radial gradient definition
@@ -52,6 +53,7 @@ type RadialGradientDef struct {
 	Stops        []GradientStop
 	Units        GradientUnits
 	SpreadMethod GradientSpreadMethod
+	NumberFormat NumberFormat // How stop opacities are written
 }
 
 // LinearGradient creates a linear gradient definition (for use in <defs>)
@@ -86,9 +88,9 @@ func LinearGradient(def LinearGradientDef) string {
 	for _, stop := range def.Stops {
 		b.WriteString(fmt.Sprintf(`  <stop offset="%s" stop-color="%s"`, escapeAttr(stop.Offset), escapeAttr(stop.Color)))
 		if stop.OpacitySet {
-			b.WriteString(fmt.Sprintf(` stop-opacity="%.2f"`, clamp01(stop.Opacity)))
+			b.WriteString(fmt.Sprintf(` stop-opacity="%s"`, def.NumberFormat.Format(clamp01(stop.Opacity))))
 		} else if stop.Opacity > 0 && stop.Opacity < 1 {
-			b.WriteString(fmt.Sprintf(` stop-opacity="%.2f"`, stop.Opacity))
+			b.WriteString(fmt.Sprintf(` stop-opacity="%s"`, def.NumberFormat.Format(stop.Opacity)))
 		}
 		b.WriteString(`/>`)
 		b.WriteString("\n")
@@ -136,9 +138,9 @@ func RadialGradient(def RadialGradientDef) string {
 	for _, stop := range def.Stops {
 		b.WriteString(fmt.Sprintf(`  <stop offset="%s" stop-color="%s"`, escapeAttr(stop.Offset), escapeAttr(stop.Color)))
 		if stop.OpacitySet {
-			b.WriteString(fmt.Sprintf(` stop-opacity="%.2f"`, clamp01(stop.Opacity)))
+			b.WriteString(fmt.Sprintf(` stop-opacity="%s"`, def.NumberFormat.Format(clamp01(stop.Opacity))))
 		} else if stop.Opacity > 0 && stop.Opacity < 1 {
-			b.WriteString(fmt.Sprintf(` stop-opacity="%.2f"`, stop.Opacity))
+			b.WriteString(fmt.Sprintf(` stop-opacity="%s"`, def.NumberFormat.Format(stop.Opacity)))
 		}
 		b.WriteString(`/>`)
 		b.WriteString("\n")
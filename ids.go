@@ -0,0 +1,117 @@
+package svg
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+)
+
+// IDMode selects how an IDAllocator derives IDs
+type IDMode int
+
+const (
+	// IDSequential numbers IDs of each kind in allocation order ("clip-1", "clip-2")
+	IDSequential IDMode = iota
+	// IDContentHash derives IDs from the content they name, so identical
+	// definitions get identical IDs in every document ("clip-3f2a9c01")
+	IDContentHash
+)
+
+// IDAllocator generates document-unique IDs for definitions such as
+// clipPaths and gradients. Each renderer owns an allocator, so rendering the
+// same input always produces the same IDs. The prefix keeps the IDs of
+// several SVGs embedded in one HTML page apart. It is safe for concurrent use.
+type IDAllocator struct {
+	prefix string
+	mode   IDMode
+
+	mu       sync.Mutex
+	counters map[string]int    // kind -> last sequence number
+	used     map[string]string // ID -> content it was allocated for
+}
+
+// NewIDAllocator creates an allocator that starts every ID with prefix
+func NewIDAllocator(prefix string, mode IDMode) *IDAllocator {
+	return &IDAllocator{
+		prefix:   prefix,
+		mode:     mode,
+		counters: make(map[string]int),
+		used:     make(map[string]string),
+	}
+}
+
+// Prefix returns the prefix of the allocated IDs
+func (a *IDAllocator) Prefix() string {
+	return a.prefix
+}
+
+// Mode returns how the allocator derives IDs
+func (a *IDAllocator) Mode() IDMode {
+	return a.mode
+}
+
+// Next returns a new sequential ID of the given kind, such as "clip-1"
+func (a *IDAllocator) Next(kind string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.next(kind, "")
+}
+
+// ForContent returns an ID of the given kind for a definition. In
+// IDContentHash mode the ID is a hash of content; otherwise it is the next
+// sequential ID. Callers deduplicate content themselves.
+func (a *IDAllocator) ForContent(kind, content string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.mode != IDContentHash {
+		return a.next(kind, content)
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(kind))
+	h.Write([]byte{0})
+	h.Write([]byte(content))
+	id := fmt.Sprintf("%s%s-%08x", a.prefix, kind, h.Sum32())
+
+	// Hash collisions between different content get a numeric suffix
+	base := id
+	for i := 2; ; i++ {
+		existing, ok := a.used[id]
+		if !ok || existing == content {
+			break
+		}
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+	a.reserve(id, content)
+	return id
+}
+
+// Reset forgets all allocated IDs and restarts the sequences
+func (a *IDAllocator) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	clear(a.counters)
+	clear(a.used)
+}
+
+// next returns the next sequential ID of kind, skipping IDs already in use
+func (a *IDAllocator) next(kind, content string) string {
+	if a.counters == nil {
+		a.counters = make(map[string]int)
+	}
+	for {
+		a.counters[kind]++
+		id := fmt.Sprintf("%s%s-%d", a.prefix, kind, a.counters[kind])
+		if _, ok := a.used[id]; !ok {
+			a.reserve(id, content)
+			return id
+		}
+	}
+}
+
+func (a *IDAllocator) reserve(id, content string) {
+	if a.used == nil {
+		a.used = make(map[string]string)
+	}
+	a.used[id] = content
+}
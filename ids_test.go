@@ -0,0 +1,90 @@
+package svg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/SCKelemen/layout"
+)
+
+func TestIDAllocator_Sequential(t *testing.T) {
+	ids := NewIDAllocator("chart-", IDSequential)
+	got := []string{ids.Next("clip"), ids.Next("clip"), ids.Next("grad"), ids.ForContent("clip", "<rect/>")}
+	want := []string{"chart-clip-1", "chart-clip-2", "chart-grad-1", "chart-clip-3"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ID %d: expected %s, got %s", i, want[i], got[i])
+		}
+	}
+
+	ids.Reset()
+	if id := ids.Next("clip"); id != "chart-clip-1" {
+		t.Errorf("expected the sequence to restart after Reset, got %s", id)
+	}
+}
+
+func TestIDAllocator_ContentHash(t *testing.T) {
+	a := NewIDAllocator("", IDContentHash)
+	b := NewIDAllocator("", IDContentHash)
+
+	rect := a.ForContent("clip", `<rect width="10"/>`)
+	if rect != b.ForContent("clip", `<rect width="10"/>`) {
+		t.Error("identical content should get identical IDs across allocators")
+	}
+	if rect == a.ForContent("clip", `<rect width="20"/>`) {
+		t.Error("different content should get different IDs")
+	}
+	if rect == a.ForContent("mask", `<rect width="10"/>`) {
+		t.Error("different kinds should get different IDs")
+	}
+	if !strings.HasPrefix(rect, "clip-") || len(rect) != len("clip-")+8 {
+		t.Errorf("expected clip-<8 hex digits>, got %s", rect)
+	}
+}
+
+func TestIDAllocator_ContentHashCollision(t *testing.T) {
+	ids := NewIDAllocator("", IDContentHash)
+	first := ids.ForContent("clip", "a")
+	// Claim the hash of "b" for other content to force a collision
+	ids.reserve(NewIDAllocator("", IDContentHash).ForContent("clip", "b"), "c")
+
+	second := ids.ForContent("clip", "b")
+	if second == first || !strings.HasSuffix(second, "-2") {
+		t.Errorf("expected a suffixed ID for colliding content, got %s", second)
+	}
+	if again := ids.ForContent("clip", "b"); again != second {
+		t.Errorf("expected the same ID for the same content, got %s and %s", second, again)
+	}
+}
+
+func TestRenderer_DeterministicClipIDs(t *testing.T) {
+	node := boxNode(0, 0, 0, 0)
+	node.Children = []*layout.Node{{Rect: layout.Rect{Width: 200, Height: 200}}}
+	opts := DefaultOptions()
+	opts.BoxStyleFunc = func(n *layout.Node, depth int) BoxStyle {
+		return BoxStyle{Overflow: OverflowHidden}
+	}
+
+	first := RenderToSVG(node, opts)
+	if second := RenderToSVG(node, opts); first != second {
+		t.Errorf("expected identical output for identical input:\n%s\n%s", first, second)
+	}
+	if !strings.Contains(first, `<clipPath id="clip-1">`) {
+		t.Errorf("expected renderer-scoped IDs starting at clip-1:\n%s", first)
+	}
+
+	opts.IDPrefix = "inline-a-"
+	if svg := RenderToSVG(node, opts); !strings.Contains(svg, `<clipPath id="inline-a-clip-1">`) ||
+		!strings.Contains(svg, `url(#inline-a-clip-1)`) {
+		t.Errorf("expected prefixed IDs:\n%s", svg)
+	}
+
+	opts.IDMode = IDContentHash
+	renderer := NewRenderer(opts)
+	svg := renderer.Render(node)
+	id := renderer.GetClipPathManager().paths[0].ID
+	if id != NewIDAllocator("inline-a-", IDContentHash).ForContent("clip", renderer.GetClipPathManager().paths[0].Path) ||
+		!strings.Contains(svg, `url(#`+id+`)`) {
+		t.Errorf("expected a content-hash ID, got %s:\n%s", id, svg)
+	}
+}
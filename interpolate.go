@@ -0,0 +1,322 @@
+package svg
+
+import "math"
+
+// Interpolator draws a curve through points with a PathBuilder. The pen is
+// expected at points[0]; the interpolator draws from there to the last
+// point. Interpolators work with CurveLinePath and CurveAreaPath, or with
+// any PathBuilder:
+//
+//	pb := NewPathBuilder().MoveTo(points[0].X, points[0].Y)
+//	MonotoneCurve(pb, points)
+type Interpolator func(pb *PathBuilder, points []Point)
+
+// CurveLinePath creates an open path through points using curve
+func CurveLinePath(points []Point, curve Interpolator) string {
+	if len(points) == 0 {
+		return ""
+	}
+	pb := NewPathBuilder()
+	pb.MoveTo(points[0].X, points[0].Y)
+	curve(pb, points)
+	return pb.String()
+}
+
+// CurveAreaPath creates a filled area between two lines, such as a band or
+// one layer of a stacked chart. top and bottom list points in the same
+// order, usually one pair per x; bottom is drawn back from its last point
+// to its first. Use Baseline for an area down to a fixed y.
+func CurveAreaPath(top, bottom []Point, curve Interpolator) string {
+	if len(top) == 0 || len(bottom) == 0 {
+		return ""
+	}
+	reversed := make([]Point, len(bottom))
+	for i, p := range bottom {
+		reversed[len(bottom)-1-i] = p
+	}
+
+	pb := NewPathBuilder()
+	pb.MoveTo(top[0].X, top[0].Y)
+	curve(pb, top)
+	pb.LineTo(reversed[0].X, reversed[0].Y)
+	curve(pb, reversed)
+	pb.Close()
+	return pb.String()
+}
+
+// Baseline returns the points projected onto the horizontal line at y, the
+// lower boundary of an area that is not stacked
+func Baseline(points []Point, y float64) []Point {
+	out := make([]Point, len(points))
+	for i, p := range points {
+		out[i] = Point{X: p.X, Y: y}
+	}
+	return out
+}
+
+// LinearCurve joins the points with straight lines
+func LinearCurve(pb *PathBuilder, points []Point) {
+	for _, p := range points[min(1, len(points)):] {
+		pb.LineTo(p.X, p.Y)
+	}
+}
+
+// StepCurve changes y halfway between the x of adjacent points
+func StepCurve(pb *PathBuilder, points []Point) {
+	for i := 1; i < len(points); i++ {
+		prev, p := points[i-1], points[i]
+		pb.HorizontalLineTo((prev.X + p.X) / 2)
+		pb.VerticalLineTo(p.Y)
+		pb.HorizontalLineTo(p.X)
+	}
+}
+
+// StepBeforeCurve changes y at the x of the earlier point of each pair
+func StepBeforeCurve(pb *PathBuilder, points []Point) {
+	for _, p := range points[min(1, len(points)):] {
+		pb.VerticalLineTo(p.Y)
+		pb.HorizontalLineTo(p.X)
+	}
+}
+
+// StepAfterCurve changes y at the x of the later point of each pair
+func StepAfterCurve(pb *PathBuilder, points []Point) {
+	for _, p := range points[min(1, len(points)):] {
+		pb.HorizontalLineTo(p.X)
+		pb.VerticalLineTo(p.Y)
+	}
+}
+
+// MonotoneCurve draws a cubic spline that preserves the monotonicity of the
+// data (Fritsch–Carlson): it never overshoots between samples, so flat runs
+// stay flat and non-negative data stays non-negative. The points must be
+// ordered by x, increasing or decreasing.
+func MonotoneCurve(pb *PathBuilder, points []Point) {
+	n := len(points)
+	if n < 3 {
+		LinearCurve(pb, points)
+		return
+	}
+
+	// Secant slopes, and tangents averaging them where the data does not
+	// turn
+	secants := make([]float64, n-1)
+	for i := range secants {
+		if dx := points[i+1].X - points[i].X; dx != 0 {
+			secants[i] = (points[i+1].Y - points[i].Y) / dx
+		}
+	}
+	tangents := make([]float64, n)
+	tangents[0], tangents[n-1] = secants[0], secants[n-2]
+	for i := 1; i < n-1; i++ {
+		if secants[i-1]*secants[i] > 0 {
+			tangents[i] = (secants[i-1] + secants[i]) / 2
+		}
+	}
+
+	// Limit the tangents to the region that keeps each segment monotone
+	for i, d := range secants {
+		if d == 0 {
+			tangents[i], tangents[i+1] = 0, 0
+			continue
+		}
+		a, b := tangents[i]/d, tangents[i+1]/d
+		if s := a*a + b*b; s > 9 {
+			tau := 3 / math.Sqrt(s)
+			tangents[i], tangents[i+1] = tau*a*d, tau*b*d
+		}
+	}
+
+	for i := 0; i < n-1; i++ {
+		p0, p1 := points[i], points[i+1]
+		h := (p1.X - p0.X) / 3
+		pb.CurveTo(p0.X+h, p0.Y+tangents[i]*h, p1.X-h, p1.Y-tangents[i+1]*h, p1.X, p1.Y)
+	}
+}
+
+// CardinalCurve returns a cardinal spline through the points. tension 0
+// gives a uniform Catmull-Rom spline and tension 1 straight lines.
+func CardinalCurve(tension float64) Interpolator {
+	k := (1 - tension) / 6
+	return func(pb *PathBuilder, points []Point) {
+		if len(points) < 3 {
+			LinearCurve(pb, points)
+			return
+		}
+		for i := 0; i < len(points)-1; i++ {
+			p0, p1, p2, p3 := splineNeighbors(points, i)
+			pb.CurveTo(
+				p1.X+k*(p2.X-p0.X), p1.Y+k*(p2.Y-p0.Y),
+				p2.X-k*(p3.X-p1.X), p2.Y-k*(p3.Y-p1.Y),
+				p2.X, p2.Y,
+			)
+		}
+	}
+}
+
+// CatmullRomCurve returns a Catmull-Rom spline parameterized by distance to
+// the power alpha: 0 is uniform, 0.5 centripetal and 1 chordal. The
+// centripetal spline has no cusps or self-intersections within a segment,
+// even with unevenly spaced points.
+func CatmullRomCurve(alpha float64) Interpolator {
+	if alpha == 0 {
+		return CardinalCurve(0)
+	}
+	const epsilon = 1e-12
+	return func(pb *PathBuilder, points []Point) {
+		if len(points) < 3 {
+			LinearCurve(pb, points)
+			return
+		}
+		for i := 0; i < len(points)-1; i++ {
+			p0, p1, p2, p3 := splineNeighbors(points, i)
+			l01 := math.Pow(math.Hypot(p1.X-p0.X, p1.Y-p0.Y), alpha)
+			l12 := math.Pow(math.Hypot(p2.X-p1.X, p2.Y-p1.Y), alpha)
+			l23 := math.Pow(math.Hypot(p3.X-p2.X, p3.Y-p2.Y), alpha)
+
+			c1, c2 := p1, p2
+			if l01 > epsilon {
+				a := 2*l01*l01 + 3*l01*l12 + l12*l12
+				n := 3 * l01 * (l01 + l12)
+				c1 = Point{
+					X: (p1.X*a - p0.X*l12*l12 + p2.X*l01*l01) / n,
+					Y: (p1.Y*a - p0.Y*l12*l12 + p2.Y*l01*l01) / n,
+				}
+			}
+			if l23 > epsilon {
+				b := 2*l23*l23 + 3*l23*l12 + l12*l12
+				m := 3 * l23 * (l23 + l12)
+				c2 = Point{
+					X: (p2.X*b + p1.X*l23*l23 - p3.X*l12*l12) / m,
+					Y: (p2.Y*b + p1.Y*l23*l23 - p3.Y*l12*l12) / m,
+				}
+			}
+			pb.CurveTo(c1.X, c1.Y, c2.X, c2.Y, p2.X, p2.Y)
+		}
+	}
+}
+
+// splineNeighbors returns the points around segment i, repeating the end
+// points where the neighbors run out
+func splineNeighbors(points []Point, i int) (p0, p1, p2, p3 Point) {
+	p1, p2 = points[i], points[i+1]
+	p0, p3 = p1, p2
+	if i > 0 {
+		p0 = points[i-1]
+	}
+	if i+2 < len(points) {
+		p3 = points[i+2]
+	}
+	return p0, p1, p2, p3
+}
+
+// NaturalCurve draws a natural cubic spline: it passes through every point
+// with continuous second derivatives, which are zero at the ends
+func NaturalCurve(pb *PathBuilder, points []Point) {
+	if len(points) < 3 {
+		LinearCurve(pb, points)
+		return
+	}
+	xs := make([]float64, len(points))
+	ys := make([]float64, len(points))
+	for i, p := range points {
+		xs[i], ys[i] = p.X, p.Y
+	}
+	ax, bx := naturalControlPoints(xs)
+	ay, by := naturalControlPoints(ys)
+	for i := range ax {
+		pb.CurveTo(ax[i], ay[i], bx[i], by[i], points[i+1].X, points[i+1].Y)
+	}
+}
+
+// naturalControlPoints returns the first and second Bézier control
+// coordinates of each segment of a natural spline through x, solving the
+// tridiagonal system for C² continuity with the Thomas algorithm
+func naturalControlPoints(x []float64) ([]float64, []float64) {
+	n := len(x) - 1
+	a := make([]float64, n)
+	b := make([]float64, n)
+	r := make([]float64, n)
+
+	a[0], b[0], r[0] = 0, 2, x[0]+2*x[1]
+	for i := 1; i < n-1; i++ {
+		a[i], b[i], r[i] = 1, 4, 4*x[i]+2*x[i+1]
+	}
+	a[n-1], b[n-1], r[n-1] = 2, 7, 8*x[n-1]+x[n]
+
+	for i := 1; i < n; i++ {
+		m := a[i] / b[i-1]
+		b[i] -= m
+		r[i] -= m * r[i-1]
+	}
+	a[n-1] = r[n-1] / b[n-1]
+	for i := n - 2; i >= 0; i-- {
+		a[i] = (r[i] - a[i+1]) / b[i]
+	}
+	b[n-1] = (x[n] + a[n-1]) / 2
+	for i := 0; i < n-1; i++ {
+		b[i] = 2*x[i+1] - a[i+1]
+	}
+	return a, b
+}
+
+// BasisCurve draws a uniform cubic B-spline. It starts and ends at the end
+// points but only approaches the points in between, smoothing noisy data.
+func BasisCurve(pb *PathBuilder, points []Point) {
+	n := len(points)
+	if n < 3 {
+		LinearCurve(pb, points)
+		return
+	}
+	p0, p1 := points[0], points[1]
+	pb.LineTo((5*p0.X+p1.X)/6, (5*p0.Y+p1.Y)/6)
+
+	// Each point after the second adds a segment; the last point is
+	// repeated to pull the curve onto it
+	bezier := func(p0, p1, p Point) {
+		pb.CurveTo(
+			(2*p0.X+p1.X)/3, (2*p0.Y+p1.Y)/3,
+			(p0.X+2*p1.X)/3, (p0.Y+2*p1.Y)/3,
+			(p0.X+4*p1.X+p.X)/6, (p0.Y+4*p1.Y+p.Y)/6,
+		)
+	}
+	for _, p := range points[2:] {
+		bezier(p0, p1, p)
+		p0, p1 = p1, p
+	}
+	bezier(p0, p1, p1)
+	pb.LineTo(p1.X, p1.Y)
+}
+
+// SmoothCurve returns the curve of SmoothLinePath. tension controls how far
+// the control points reach towards the neighbors (0 = straight lines). It
+// can overshoot; MonotoneCurve does not.
+func SmoothCurve(tension float64) Interpolator {
+	return func(pb *PathBuilder, points []Point) {
+		for i := 0; i < len(points)-1; i++ {
+			var cp1x, cp1y, cp2x, cp2y float64
+
+			if i == 0 {
+				// First point
+				cp1x = points[i].X + (points[i+1].X-points[i].X)*tension
+				cp1y = points[i].Y + (points[i+1].Y-points[i].Y)*tension
+			} else {
+				// Calculate control point based on previous point
+				cp1x = points[i].X + (points[i+1].X-points[i-1].X)*tension
+				cp1y = points[i].Y + (points[i+1].Y-points[i-1].Y)*tension
+			}
+
+			if i == len(points)-2 {
+				// Last segment
+				cp2x = points[i+1].X - (points[i+1].X-points[i].X)*tension
+				cp2y = points[i+1].Y - (points[i+1].Y-points[i].Y)*tension
+			} else {
+				// Calculate control point based on next point
+				cp2x = points[i+1].X - (points[i+2].X-points[i].X)*tension
+				cp2y = points[i+1].Y - (points[i+2].Y-points[i].Y)*tension
+			}
+
+			pb.CurveTo(cp1x, cp1y, cp2x, cp2y, points[i+1].X, points[i+1].Y)
+		}
+	}
+}
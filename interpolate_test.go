@@ -0,0 +1,146 @@
+package svg
+
+import (
+	"math"
+	"testing"
+)
+
+// curveString draws points with curve using compact numbers
+func curveString(curve Interpolator, points []Point) string {
+	pb := NewPathBuilder().SetNumberFormat(ShortestRoundTrip())
+	pb.MoveTo(points[0].X, points[0].Y)
+	curve(pb, points)
+	return pb.String()
+}
+
+func TestInterpolators_Steps(t *testing.T) {
+	points := []Point{{0, 0}, {10, 5}, {20, 0}}
+	tests := []struct {
+		name     string
+		curve    Interpolator
+		expected string
+	}{
+		{"linear", LinearCurve, "M 0 0 L 10 5 L 20 0"},
+		{"step", StepCurve, "M 0 0 H 5 V 5 H 10 H 15 V 0 H 20"},
+		{"step-before", StepBeforeCurve, "M 0 0 V 5 H 10 V 0 H 20"},
+		{"step-after", StepAfterCurve, "M 0 0 H 10 V 5 H 20 V 0"},
+		{"basis", BasisCurve, "M 0 0 L 1.6666666666666667 0.8333333333333334 C 3.3333333333333335 1.6666666666666667, 6.666666666666667 3.3333333333333335, 10 3.3333333333333335 C 13.333333333333334 3.3333333333333335, 16.666666666666668 1.6666666666666667, 18.333333333333332 0.8333333333333334 L 20 0"},
+		{"cardinal-1", CardinalCurve(1), "M 0 0 C 0 0, 10 5, 10 5 C 10 5, 20 0, 20 0"},
+	}
+	for _, tt := range tests {
+		if got := curveString(tt.curve, points); got != tt.expected {
+			t.Errorf("%s: expected\n%s\ngot\n%s", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestInterpolators_FewPoints(t *testing.T) {
+	curves := []Interpolator{LinearCurve, StepCurve, MonotoneCurve, NaturalCurve, BasisCurve, CardinalCurve(0.5), CatmullRomCurve(0.5), SmoothCurve(0.3)}
+	for i, curve := range curves {
+		if got := CurveLinePath([]Point{{1, 2}}, curve); got != "M 1.00 2.00" {
+			t.Errorf("curve %d: expected a lone moveto, got %s", i, got)
+		}
+		if got := CurveLinePath(nil, curve); got != "" {
+			t.Errorf("curve %d: expected an empty path, got %s", i, got)
+		}
+	}
+}
+
+// sampleCurve returns the path drawn by curve and its y range
+func sampleCurve(t *testing.T, curve Interpolator, points []Point) (PathData, float64, float64) {
+	t.Helper()
+	path := mustParsePath(t, CurveLinePath(points, curve))
+	box, _ := path.Bounds()
+	return path, box.Y, box.Y + box.Height
+}
+
+func TestMonotoneCurve_DoesNotOvershoot(t *testing.T) {
+	// Latency samples with a flat run at zero
+	points := []Point{{0, 40}, {10, 0}, {20, 0}, {30, 0}, {40, 35}, {50, 36}, {60, 80}}
+
+	if _, minY, _ := sampleCurve(t, SmoothCurve(0.3), points); minY >= 0 {
+		t.Fatalf("expected SmoothCurve to overshoot below zero, got min %v", minY)
+	}
+	path, minY, maxY := sampleCurve(t, MonotoneCurve, points)
+	if minY < -1e-9 || maxY > 80+1e-9 {
+		t.Errorf("expected the curve to stay within [0, 80], got [%v, %v]", minY, maxY)
+	}
+	// Each segment stays between its end points
+	for i, seg := range path[1:] {
+		p0, p1 := points[i], points[i+1]
+		box, _ := PathData{{Command: PathMoveTo, Points: [3]Point{p0}}, seg}.Bounds()
+		if box.Y < math.Min(p0.Y, p1.Y)-1e-9 || box.Y+box.Height > math.Max(p0.Y, p1.Y)+1e-9 {
+			t.Errorf("segment %d overshoots: %+v", i, box)
+		}
+	}
+}
+
+func TestInterpolators_PassThroughPoints(t *testing.T) {
+	points := []Point{{0, 0}, {1, 10}, {8, 12}, {9, 0}, {20, 5}}
+	curves := map[string]Interpolator{
+		"monotone":    MonotoneCurve,
+		"natural":     NaturalCurve,
+		"cardinal":    CardinalCurve(0.5),
+		"catmull-rom": CatmullRomCurve(0.5),
+	}
+	for name, curve := range curves {
+		path := mustParsePath(t, CurveLinePath(points, curve))
+		if len(path) != len(points) {
+			t.Fatalf("%s: expected one segment per point, got %s", name, path)
+		}
+		for i, seg := range path {
+			if !nearPoint(seg.End(), points[i]) {
+				t.Errorf("%s: expected segment %d to end at %v, got %v", name, i, points[i], seg.End())
+			}
+		}
+	}
+
+	if CurveLinePath(points, CatmullRomCurve(0)) != CurveLinePath(points, CardinalCurve(0)) {
+		t.Error("a uniform Catmull-Rom spline should match the cardinal spline with tension 0")
+	}
+}
+
+func TestNaturalCurve_Continuity(t *testing.T) {
+	points := []Point{{0, 0}, {10, 20}, {25, 5}, {30, 30}}
+	path := mustParsePath(t, curveString(NaturalCurve, points))
+
+	// Second derivatives are zero at both ends
+	first, last := path[1].Points, path[len(path)-1].Points
+	if d := (Point{points[0].X - 2*first[0].X + first[1].X, points[0].Y - 2*first[0].Y + first[1].Y}); !nearPoint(d, Point{}) {
+		t.Errorf("expected a zero second derivative at the start, got %v", d)
+	}
+	if d := (Point{last[0].X - 2*last[1].X + last[2].X, last[0].Y - 2*last[1].Y + last[2].Y}); !nearPoint(d, Point{}) {
+		t.Errorf("expected a zero second derivative at the end, got %v", d)
+	}
+
+	// First and second derivatives match where segments meet
+	for i := 1; i < len(path)-1; i++ {
+		a, b := path[i].Points, path[i+1].Points
+		if d1, d2 := (Point{a[2].X - a[1].X, a[2].Y - a[1].Y}), (Point{b[0].X - a[2].X, b[0].Y - a[2].Y}); !nearPoint(d1, d2) {
+			t.Errorf("joint %d: first derivatives differ: %v and %v", i, d1, d2)
+		}
+		s1 := Point{a[0].X - 2*a[1].X + a[2].X, a[0].Y - 2*a[1].Y + a[2].Y}
+		s2 := Point{a[2].X - 2*b[0].X + b[1].X, a[2].Y - 2*b[0].Y + b[1].Y}
+		if !nearPoint(s1, s2) {
+			t.Errorf("joint %d: second derivatives differ: %v and %v", i, s1, s2)
+		}
+	}
+}
+
+func TestCurveAreaPath(t *testing.T) {
+	top := []Point{{0, 10}, {10, 5}, {20, 8}}
+	bottom := []Point{{0, 20}, {10, 15}, {20, 18}}
+	expected := "M 0.00 10.00 L 10.00 5.00 L 20.00 8.00 L 20.00 18.00 L 10.00 15.00 L 0.00 20.00 Z"
+	if got := CurveAreaPath(top, bottom, LinearCurve); got != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+
+	expected = "M 0.00 10.00 V 5.00 H 10.00 V 8.00 H 20.00 L 20.00 30.00 V 30.00 H 10.00 V 30.00 H 0.00 Z"
+	if got := CurveAreaPath(top, Baseline(top, 30), StepBeforeCurve); got != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+
+	if got := CurveAreaPath(nil, bottom, LinearCurve); got != "" {
+		t.Errorf("expected an empty path, got %s", got)
+	}
+}
@@ -31,7 +31,8 @@ type MarkerDef struct {
 	MarkerHeight float64      // Height of marker viewport
 	Orient       MarkerOrient // auto, auto-start-reverse, or angle
 	MarkerUnits  MarkerUnits
-	Content      string // SVG content inside the marker
+	Content      string       // SVG content inside the marker
+	NumberFormat NumberFormat // How refX, refY and the marker size are written
 }
 
 // Marker creates a marker definition (for use in <defs>)
@@ -44,13 +45,14 @@ func Marker(def MarkerDef) string {
 		b.WriteString(fmt.Sprintf(` viewBox="%s"`, escapeAttr(def.ViewBox)))
 	}
 
-	b.WriteString(fmt.Sprintf(` refX="%.2f" refY="%.2f"`, def.RefX, def.RefY))
+	n := def.NumberFormat
+	b.WriteString(fmt.Sprintf(` refX="%s" refY="%s"`, n.Format(def.RefX), n.Format(def.RefY)))
 
 	if def.MarkerWidth > 0 {
-		b.WriteString(fmt.Sprintf(` markerWidth="%.2f"`, def.MarkerWidth))
+		b.WriteString(fmt.Sprintf(` markerWidth="%s"`, n.Format(def.MarkerWidth)))
 	}
 	if def.MarkerHeight > 0 {
-		b.WriteString(fmt.Sprintf(` markerHeight="%.2f"`, def.MarkerHeight))
+		b.WriteString(fmt.Sprintf(` markerHeight="%s"`, n.Format(def.MarkerHeight)))
 	}
 
 	if def.Orient != "" {
@@ -167,9 +169,11 @@ func TriangleMarker(id string, color string) string {
 	})
 }
 
-// CrossMarker creates a cross/plus marker
-func CrossMarker(id string, color string, strokeWidth float64) string {
-	content := fmt.Sprintf(`<path d="M 5 1 L 5 9 M 1 5 L 9 5" stroke="%s" stroke-width="%.1f" stroke-linecap="round"/>`, escapeAttr(color), strokeWidth)
+// CrossMarker creates a cross/plus marker. An optional NumberFormat applies
+// to the stroke width and the marker attributes.
+func CrossMarker(id string, color string, strokeWidth float64, format ...NumberFormat) string {
+	n, attrs := markerFormat(format)
+	content := fmt.Sprintf(`<path d="M 5 1 L 5 9 M 1 5 L 9 5" stroke="%s" stroke-width="%s" stroke-linecap="round"/>`, escapeAttr(color), n.Format(strokeWidth))
 	return Marker(MarkerDef{
 		ID:           id,
 		ViewBox:      "0 0 10 10",
@@ -179,12 +183,15 @@ func CrossMarker(id string, color string, strokeWidth float64) string {
 		MarkerHeight: 5,
 		Orient:       MarkerOrientAuto,
 		Content:      content,
+		NumberFormat: attrs,
 	})
 }
 
-// XMarker creates an X marker
-func XMarker(id string, color string, strokeWidth float64) string {
-	content := fmt.Sprintf(`<path d="M 2 2 L 8 8 M 8 2 L 2 8" stroke="%s" stroke-width="%.1f" stroke-linecap="round"/>`, escapeAttr(color), strokeWidth)
+// XMarker creates an X marker. An optional NumberFormat applies to the
+// stroke width and the marker attributes.
+func XMarker(id string, color string, strokeWidth float64, format ...NumberFormat) string {
+	n, attrs := markerFormat(format)
+	content := fmt.Sprintf(`<path d="M 2 2 L 8 8 M 8 2 L 2 8" stroke="%s" stroke-width="%s" stroke-linecap="round"/>`, escapeAttr(color), n.Format(strokeWidth))
 	return Marker(MarkerDef{
 		ID:           id,
 		ViewBox:      "0 0 10 10",
@@ -194,12 +201,15 @@ func XMarker(id string, color string, strokeWidth float64) string {
 		MarkerHeight: 5,
 		Orient:       MarkerOrientAuto,
 		Content:      content,
+		NumberFormat: attrs,
 	})
 }
 
-// DotMarker creates a small dot marker (good for data points)
-func DotMarker(id string, color string, radius float64) string {
-	content := fmt.Sprintf(`<circle cx="5" cy="5" r="%.1f" fill="%s"/>`, radius, escapeAttr(color))
+// DotMarker creates a small dot marker (good for data points). An optional
+// NumberFormat applies to the radius and the marker attributes.
+func DotMarker(id string, color string, radius float64, format ...NumberFormat) string {
+	n, attrs := markerFormat(format)
+	content := fmt.Sprintf(`<circle cx="5" cy="5" r="%s" fill="%s"/>`, n.Format(radius), escapeAttr(color))
 	return Marker(MarkerDef{
 		ID:           id,
 		ViewBox:      "0 0 10 10",
@@ -209,9 +219,20 @@ func DotMarker(id string, color string, radius float64) string {
 		MarkerHeight: 4,
 		Orient:       MarkerOrientAuto,
 		Content:      content,
+		NumberFormat: attrs,
 	})
 }
 
+// markerFormat returns the number formats of a marker helper's content and
+// attributes. Without a format, the content keeps one decimal and the
+// attributes use the default format.
+func markerFormat(format []NumberFormat) (content, attrs NumberFormat) {
+	if len(format) > 0 {
+		return format[0], format[0]
+	}
+	return FixedDecimals(1), NumberFormat{}
+}
+
 // Helper to apply markers to path style attributes
 func applyMarkers(style Style, markerStart, markerMid, markerEnd string) string {
 	if markerStart != "" {
@@ -235,8 +256,9 @@ func PathWithMarkers(d string, style Style, markerStart, markerMid, markerEnd st
 // LineWithMarkers renders a line with marker references
 func LineWithMarkers(x1, y1, x2, y2 float64, style Style, markerStart, markerEnd string) string {
 	attrs := applyMarkers(style, markerStart, "", markerEnd)
-	return fmt.Sprintf(`<line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f"%s/>`,
-		x1, y1, x2, y2, attrs)
+	n := style.NumberFormat
+	return fmt.Sprintf(`<line x1="%s" y1="%s" x2="%s" y2="%s"%s/>`,
+		n.Format(x1), n.Format(y1), n.Format(x2), n.Format(y2), attrs)
 }
 
 // PolylineWithMarkers renders a polyline with marker references
@@ -245,14 +267,6 @@ func PolylineWithMarkers(points []Point, style Style, markerStart, markerMid, ma
 		return ""
 	}
 
-	var pointsStr strings.Builder
-	for i, p := range points {
-		if i > 0 {
-			pointsStr.WriteString(" ")
-		}
-		fmt.Fprintf(&pointsStr, "%.2f,%.2f", p.X, p.Y)
-	}
-
 	attrs := applyMarkers(style, markerStart, markerMid, markerEnd)
-	return fmt.Sprintf(`<polyline points="%s"%s/>`, pointsStr.String(), attrs)
+	return fmt.Sprintf(`<polyline points="%s"%s/>`, formatPoints(points, style.NumberFormat), attrs)
 }
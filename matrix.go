@@ -0,0 +1,251 @@
+package svg
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/SCKelemen/layout"
+)
+
+// Matrix is a 2D affine transform using the SVG matrix(a,b,c,d,e,f)
+// layout:
+//
+//	[a c e]
+//	[b d f]
+//	[0 0 1]
+//
+// The zero Matrix maps every point to the origin; use IdentityMatrix for no
+// transform.
+type Matrix struct {
+	A, B, C, D, E, F float64
+}
+
+// IdentityMatrix returns the transform that leaves points unchanged
+func IdentityMatrix() Matrix {
+	return Matrix{A: 1, D: 1}
+}
+
+// TranslateMatrix returns a translation, like translate(tx, ty)
+func TranslateMatrix(tx, ty float64) Matrix {
+	return Matrix{A: 1, D: 1, E: tx, F: ty}
+}
+
+// ScaleMatrix returns a scale, like scale(sx, sy)
+func ScaleMatrix(sx, sy float64) Matrix {
+	return Matrix{A: sx, D: sy}
+}
+
+// RotateMatrix returns a rotation by deg degrees, like rotate(deg)
+func RotateMatrix(deg float64) Matrix {
+	sin, cos := math.Sincos(deg * math.Pi / 180)
+	return Matrix{A: cos, B: sin, C: -sin, D: cos}
+}
+
+// SkewXMatrix returns a skew along the x axis, like skewX(deg)
+func SkewXMatrix(deg float64) Matrix {
+	return Matrix{A: 1, C: math.Tan(deg * math.Pi / 180), D: 1}
+}
+
+// SkewYMatrix returns a skew along the y axis, like skewY(deg)
+func SkewYMatrix(deg float64) Matrix {
+	return Matrix{A: 1, B: math.Tan(deg * math.Pi / 180), D: 1}
+}
+
+// MatrixFromLayout converts a layout transform. The zero Transform, which
+// nodes without a transform have, is treated as the identity.
+func MatrixFromLayout(t layout.Transform) Matrix {
+	if t == (layout.Transform{}) {
+		return IdentityMatrix()
+	}
+	return Matrix{A: t.A, B: t.B, C: t.C, D: t.D, E: t.E, F: t.F}
+}
+
+// Layout converts m to a layout transform
+func (m Matrix) Layout() layout.Transform {
+	return layout.Transform{A: m.A, B: m.B, C: m.C, D: m.D, E: m.E, F: m.F}
+}
+
+// Multiply returns m × n, the transform that applies n first and then m.
+// This is the order of an SVG transform list: "m n".
+func (m Matrix) Multiply(n Matrix) Matrix {
+	return Matrix{
+		A: m.A*n.A + m.C*n.B,
+		B: m.B*n.A + m.D*n.B,
+		C: m.A*n.C + m.C*n.D,
+		D: m.B*n.C + m.D*n.D,
+		E: m.A*n.E + m.C*n.F + m.E,
+		F: m.B*n.E + m.D*n.F + m.F,
+	}
+}
+
+// Apply transforms a point
+func (m Matrix) Apply(p Point) Point {
+	return Point{
+		X: m.A*p.X + m.C*p.Y + m.E,
+		Y: m.B*p.X + m.D*p.Y + m.F,
+	}
+}
+
+// IsIdentity reports whether m leaves points unchanged
+func (m Matrix) IsIdentity() bool {
+	return m == IdentityMatrix()
+}
+
+// Determinant returns the determinant of the linear part of m. It is
+// negative when m mirrors and zero when m is singular.
+func (m Matrix) Determinant() float64 {
+	return m.A*m.D - m.B*m.C
+}
+
+// maxScale returns the largest factor by which m stretches any vector, used
+// to convert device-space tolerances to user space.
+func (m Matrix) maxScale() float64 {
+	sum := m.A*m.A + m.B*m.B + m.C*m.C + m.D*m.D
+	det := m.Determinant()
+	disc := math.Sqrt(math.Max(0, sum*sum-4*det*det))
+	return math.Sqrt((sum + disc) / 2)
+}
+
+// Invert returns the inverse of m, or false if m is singular.
+func (m Matrix) Invert() (Matrix, bool) {
+	det := m.Determinant()
+	if det == 0 || math.IsNaN(det) || math.IsInf(det, 0) {
+		return Matrix{}, false
+	}
+	return Matrix{
+		A: m.D / det,
+		B: -m.B / det,
+		C: -m.C / det,
+		D: m.A / det,
+		E: (m.C*m.F - m.D*m.E) / det,
+		F: (m.B*m.E - m.A*m.F) / det,
+	}, true
+}
+
+// String formats m as an SVG transform attribute value, using translate,
+// scale or rotate when m is one of them and matrix otherwise. The identity
+// formats as "".
+func (m Matrix) String() string {
+	n := ShortestRoundTrip()
+	switch {
+	case m.IsIdentity():
+		return ""
+	case m.A == 1 && m.B == 0 && m.C == 0 && m.D == 1:
+		if m.F == 0 {
+			return fmt.Sprintf("translate(%s)", n.Format(m.E))
+		}
+		return fmt.Sprintf("translate(%s,%s)", n.Format(m.E), n.Format(m.F))
+	case m.B == 0 && m.C == 0 && m.E == 0 && m.F == 0:
+		if m.A == m.D {
+			return fmt.Sprintf("scale(%s)", n.Format(m.A))
+		}
+		return fmt.Sprintf("scale(%s,%s)", n.Format(m.A), n.Format(m.D))
+	case m.A == m.D && m.B == -m.C && m.E == 0 && m.F == 0 && math.Abs(m.A*m.A+m.B*m.B-1) < 1e-12:
+		// Prefer a rounded angle, and keep the exact matrix if no angle
+		// round-trips
+		deg := math.Atan2(m.B, m.A) * 180 / math.Pi
+		for _, a := range []float64{math.Round(deg*1e9) / 1e9, deg} {
+			if RotateMatrix(a) == m {
+				return fmt.Sprintf("rotate(%s)", n.Format(a))
+			}
+		}
+	}
+	return fmt.Sprintf("matrix(%s,%s,%s,%s,%s,%s)",
+		n.Format(m.A), n.Format(m.B), n.Format(m.C), n.Format(m.D), n.Format(m.E), n.Format(m.F))
+}
+
+// ParseTransform parses an SVG transform attribute such as
+// "translate(10,20) rotate(45 5 5) scale(2)".
+func ParseTransform(s string) (Matrix, error) {
+	m := IdentityMatrix()
+	rest := strings.TrimSpace(s)
+
+	for rest != "" {
+		open := strings.IndexByte(rest, '(')
+		if open < 0 {
+			return IdentityMatrix(), fmt.Errorf("transform: missing '(' in %q", s)
+		}
+		closeIdx := strings.IndexByte(rest, ')')
+		if closeIdx < open {
+			return IdentityMatrix(), fmt.Errorf("transform: missing ')' in %q", s)
+		}
+
+		name := strings.TrimSpace(rest[:open])
+		args, err := parseTransformArgs(rest[open+1 : closeIdx])
+		if err != nil {
+			return IdentityMatrix(), err
+		}
+		t, err := transformFunction(name, args)
+		if err != nil {
+			return IdentityMatrix(), err
+		}
+		m = m.Multiply(t)
+
+		rest = strings.TrimLeft(rest[closeIdx+1:], " \t\r\n,")
+	}
+
+	return m, nil
+}
+
+func parseTransformArgs(s string) ([]float64, error) {
+	sc := &pathDataScanner{s: s}
+	var args []float64
+	for {
+		sc.skipSeparators()
+		if sc.done() {
+			return args, nil
+		}
+		v, err := sc.number()
+		if err != nil {
+			return nil, fmt.Errorf("transform: %w", err)
+		}
+		args = append(args, v)
+	}
+}
+
+func transformFunction(name string, args []float64) (Matrix, error) {
+	argErr := fmt.Errorf("transform: invalid arguments for %s: %v", name, args)
+
+	switch name {
+	case "matrix":
+		if len(args) != 6 {
+			return Matrix{}, argErr
+		}
+		return Matrix{A: args[0], B: args[1], C: args[2], D: args[3], E: args[4], F: args[5]}, nil
+	case "translate":
+		switch len(args) {
+		case 1:
+			return TranslateMatrix(args[0], 0), nil
+		case 2:
+			return TranslateMatrix(args[0], args[1]), nil
+		}
+	case "scale":
+		switch len(args) {
+		case 1:
+			return ScaleMatrix(args[0], args[0]), nil
+		case 2:
+			return ScaleMatrix(args[0], args[1]), nil
+		}
+	case "rotate":
+		switch len(args) {
+		case 1:
+			return RotateMatrix(args[0]), nil
+		case 3:
+			return TranslateMatrix(args[1], args[2]).
+				Multiply(RotateMatrix(args[0])).
+				Multiply(TranslateMatrix(-args[1], -args[2])), nil
+		}
+	case "skewX":
+		if len(args) == 1 {
+			return SkewXMatrix(args[0]), nil
+		}
+	case "skewY":
+		if len(args) == 1 {
+			return SkewYMatrix(args[0]), nil
+		}
+	default:
+		return Matrix{}, fmt.Errorf("transform: unknown function %q", name)
+	}
+	return Matrix{}, argErr
+}
@@ -0,0 +1,160 @@
+package svg
+
+import (
+	"math"
+	"testing"
+
+	"github.com/SCKelemen/layout"
+)
+
+func matrixApproxEqual(a, b Matrix) bool {
+	const eps = 1e-9
+	return math.Abs(a.A-b.A) < eps && math.Abs(a.B-b.B) < eps &&
+		math.Abs(a.C-b.C) < eps && math.Abs(a.D-b.D) < eps &&
+		math.Abs(a.E-b.E) < eps && math.Abs(a.F-b.F) < eps
+}
+
+func TestParseTransform(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Matrix
+	}{
+		{"translate(10,20)", Matrix{A: 1, D: 1, E: 10, F: 20}},
+		{"translate(10)", Matrix{A: 1, D: 1, E: 10}},
+		{"scale(2)", Matrix{A: 2, D: 2}},
+		{"scale(2 3)", Matrix{A: 2, D: 3}},
+		{"rotate(90)", Matrix{A: 0, B: 1, C: -1, D: 0}},
+		{"rotate(90 10 10)", Matrix{A: 0, B: 1, C: -1, D: 0, E: 20, F: 0}},
+		{"skewX(45)", Matrix{A: 1, C: 1, D: 1}},
+		{"skewY(45)", Matrix{A: 1, B: 1, D: 1}},
+		{"matrix(1,2,3,4,5,6)", Matrix{A: 1, B: 2, C: 3, D: 4, E: 5, F: 6}},
+		{"translate(10,0) scale(2)", Matrix{A: 2, D: 2, E: 10}},
+		{"matrix(1 0 0 1 0 0), translate(-1e1 .5)", Matrix{A: 1, D: 1, E: -10, F: 0.5}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTransform(tt.input)
+		if err != nil {
+			t.Fatalf("ParseTransform(%q) error: %v", tt.input, err)
+		}
+		if !matrixApproxEqual(got, tt.expected) {
+			t.Fatalf("ParseTransform(%q) = %+v, expected %+v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestParseTransformInvalid(t *testing.T) {
+	for _, input := range []string{"translate(1,2", "spin(45)", "matrix(1,2,3)", "rotate(1,2)"} {
+		if _, err := ParseTransform(input); err == nil {
+			t.Fatalf("expected error for %q", input)
+		}
+	}
+}
+
+func TestMatrixInvert(t *testing.T) {
+	m, err := ParseTransform("translate(10 20) rotate(30) scale(2 3) skewX(10)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inv, ok := m.Invert()
+	if !ok {
+		t.Fatal("expected matrix to be invertible")
+	}
+	if got := m.Multiply(inv); !matrixApproxEqual(got, IdentityMatrix()) {
+		t.Fatalf("m × m⁻¹ = %+v, want identity", got)
+	}
+	if _, ok := ScaleMatrix(0, 1).Invert(); ok {
+		t.Fatal("expected singular matrix to fail")
+	}
+}
+
+func TestMatrix_String(t *testing.T) {
+	tests := []struct {
+		m        Matrix
+		expected string
+	}{
+		{IdentityMatrix(), ""},
+		{TranslateMatrix(10, 0), "translate(10)"},
+		{TranslateMatrix(10, -2.5), "translate(10,-2.5)"},
+		{ScaleMatrix(2, 2), "scale(2)"},
+		{ScaleMatrix(2, 3), "scale(2,3)"},
+		{RotateMatrix(90), "rotate(90)"},
+		{RotateMatrix(30), "rotate(30)"},
+		{Matrix{A: 1, B: 2, C: 3, D: 4, E: 5, F: 6}, "matrix(1,2,3,4,5,6)"},
+		{TranslateMatrix(10, 0).Multiply(ScaleMatrix(2, 2)), "matrix(2,0,0,2,10,0)"},
+	}
+	for _, tt := range tests {
+		got := tt.m.String()
+		if got != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, got)
+			continue
+		}
+		if back, err := ParseTransform(got); err != nil || !matrixApproxEqual(back, tt.m) {
+			t.Errorf("%q does not parse back to %+v: %+v, %v", got, tt.m, back, err)
+		}
+	}
+}
+
+func TestMatrix_Layout(t *testing.T) {
+	if !MatrixFromLayout(layout.Transform{}).IsIdentity() {
+		t.Error("the zero layout transform should convert to the identity")
+	}
+
+	lt := layout.Translate(10, 20).Multiply(layout.RotateDegrees(30))
+	m := TranslateMatrix(10, 20).Multiply(RotateMatrix(30))
+	if !matrixApproxEqual(MatrixFromLayout(lt), m) {
+		t.Errorf("expected %+v, got %+v", m, MatrixFromLayout(lt))
+	}
+	p := m.Apply(Point{X: 3, Y: 4})
+	lp := m.Layout().Apply(layout.Point{X: 3, Y: 4})
+	if math.Abs(p.X-lp.X) > 1e-9 || math.Abs(p.Y-lp.Y) > 1e-9 {
+		t.Errorf("expected matching points, got %v and %v", p, lp)
+	}
+	if back, err := ParseTransform(m.Layout().ToSVGString()); err != nil || !matrixApproxEqual(back, m) {
+		t.Errorf("expected the layout SVG string to parse back, got %+v, %v", back, err)
+	}
+}
+
+func TestPathData_Transform(t *testing.T) {
+	square := mustParsePath(t, "M 0 0 h 10 v 10 z")
+	if got := square.Transform(TranslateMatrix(5, 5)).String(); got != "M 5.00 5.00 L 15.00 5.00 L 15.00 15.00 Z" {
+		t.Errorf("unexpected translated path: %s", got)
+	}
+
+	arc := mustParsePath(t, "M 0 0 a 10 10 0 0 1 20 0")
+	tests := []struct {
+		name     string
+		m        Matrix
+		expected string
+	}{
+		{"scale", ScaleMatrix(2, 1), "M 0.00 0.00 A 20.00 10.00 0.00 0 1 40.00 0.00"},
+		{"rotate", RotateMatrix(90).Multiply(ScaleMatrix(2, 1)), "M 0.00 0.00 A 20.00 10.00 90.00 0 1 0.00 40.00"},
+		{"mirror", ScaleMatrix(1, -1), "M 0.00 0.00 A 10.00 10.00 0.00 0 0 20.00 0.00"},
+	}
+	for _, tt := range tests {
+		if got := arc.Transform(tt.m).String(); got != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.expected, got)
+		}
+	}
+
+	// Under a skew the arc must still pass through the transformed points
+	// of the original
+	m := SkewXMatrix(30).Multiply(RotateMatrix(20)).Multiply(ScaleMatrix(1.5, 0.5))
+	path := mustParsePath(t, "M 10 10 A 20 10 30 1 0 40 30")
+	_, want := path.geometry()
+	_, got := path.Transform(m).geometry()
+	if len(got) != 1 || got[0].kind != curveArc {
+		t.Fatalf("expected a single arc, got %+v", got)
+	}
+	for _, pt := range []float64{0.1, 0.3, 0.5, 0.7, 0.9} {
+		p := m.Apply(want[0].point(pt))
+		// Map back onto the unit circle of the transformed ellipse
+		a := got[0].arc
+		dx, dy := p.X-a.Center.X, p.Y-a.Center.Y
+		x := (a.Cos*dx + a.Sin*dy) / a.RX
+		y := (-a.Sin*dx + a.Cos*dy) / a.RY
+		if r := math.Hypot(x, y); math.Abs(r-1) > 1e-9 {
+			t.Errorf("t=%v: transformed point %v is off the arc (r = %v)", pt, p, r)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package svg
+
+import (
+	"strconv"
+	"strings"
+)
+
+// NumberMode selects how a NumberFormat writes numbers
+type NumberMode int
+
+const (
+	// NumberDefault writes two fixed decimals ("10.00")
+	NumberDefault NumberMode = iota
+	// NumberFixed writes NumberFormat.Decimals fixed decimals
+	NumberFixed
+	// NumberShortest writes the shortest representation that parses back
+	// to the same float64
+	NumberShortest
+)
+
+// NumberFormat controls how numbers are written to SVG output. The zero
+// value writes two fixed decimals.
+type NumberFormat struct {
+	Mode NumberMode
+	// Decimals is the number of decimals in NumberFixed mode
+	Decimals int
+	// TrimZeros strips trailing zeros and a trailing decimal point
+	// ("10.50" becomes "10.5", "10.00" becomes "10")
+	TrimZeros bool
+}
+
+// FixedDecimals writes numbers with n decimals
+func FixedDecimals(n int) NumberFormat {
+	return NumberFormat{Mode: NumberFixed, Decimals: n}
+}
+
+// TrimmedDecimals writes numbers rounded to at most n decimals, without
+// trailing zeros
+func TrimmedDecimals(n int) NumberFormat {
+	return NumberFormat{Mode: NumberFixed, Decimals: n, TrimZeros: true}
+}
+
+// ShortestRoundTrip writes the shortest representation of each number that
+// parses back to the same value
+func ShortestRoundTrip() NumberFormat {
+	return NumberFormat{Mode: NumberShortest}
+}
+
+// Format formats v
+func (f NumberFormat) Format(v float64) string {
+	var s string
+	switch f.Mode {
+	case NumberFixed:
+		decimals := f.Decimals
+		if decimals < 0 {
+			decimals = 0
+		}
+		s = strconv.FormatFloat(v, 'f', decimals, 64)
+	case NumberShortest:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		s = strconv.FormatFloat(v, 'f', 2, 64)
+	}
+
+	if f.TrimZeros && strings.IndexByte(s, '.') >= 0 {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if f.Mode != NumberDefault && (s == "-0" || strings.TrimRight(s, "0.") == "-") {
+		s = s[1:]
+	}
+	return s
+}
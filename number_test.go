@@ -0,0 +1,152 @@
+package svg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/SCKelemen/layout"
+)
+
+func TestNumberFormat_Format(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   NumberFormat
+		value    float64
+		expected string
+	}{
+		{"default", NumberFormat{}, 10, "10.00"},
+		{"default rounds", NumberFormat{}, 0.125, "0.12"},
+		{"default keeps negative zero", NumberFormat{}, -0.001, "-0.00"},
+		{"fixed", FixedDecimals(3), 1.5, "1.500"},
+		{"fixed zero decimals", FixedDecimals(0), 12.7, "13"},
+		{"fixed drops negative zero", FixedDecimals(2), -0.001, "0.00"},
+		{"trimmed", TrimmedDecimals(3), 1.5, "1.5"},
+		{"trimmed integer", TrimmedDecimals(3), 10, "10"},
+		{"trimmed rounds", TrimmedDecimals(3), 0.12345, "0.123"},
+		{"trimmed negative zero", TrimmedDecimals(2), -0.001, "0"},
+		{"trimmed keeps integer zeros", TrimmedDecimals(2), 100, "100"},
+		{"shortest", ShortestRoundTrip(), 0.1, "0.1"},
+		{"shortest small", ShortestRoundTrip(), 0.0625, "0.0625"},
+		{"shortest integer", ShortestRoundTrip(), 42, "42"},
+		{"shortest negative", ShortestRoundTrip(), -2.5, "-2.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.format.Format(tt.value); got != tt.expected {
+				t.Errorf("Format(%v) = %q, want %q", tt.value, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNumberFormat_Elements(t *testing.T) {
+	style := Style{Fill: "red", NumberFormat: TrimmedDecimals(3)}
+
+	tests := []struct {
+		name     string
+		got      string
+		expected string
+	}{
+		{"Rect", Rect(0.125, 0, 0.5, 1, style), `<rect x="0.125" y="0" width="0.5" height="1" fill="red"/>`},
+		{"Circle", Circle(0.5, 0.5, 0.25, style), `<circle cx="0.5" cy="0.5" r="0.25" fill="red"/>`},
+		{"Polygon", Polygon([]Point{{0, 0}, {1, 0.5}}, style), `<polygon points="0,0 1,0.5" fill="red"/>`},
+		{"RectElement", RectElement(0.125, 0, 0.5, 1, style).String(), `<rect x="0.125" y="0" width="0.5" height="1" fill="red"/>`},
+		{"LineWithMarkers", LineWithMarkers(0, 0, 1.5, 2, Style{NumberFormat: ShortestRoundTrip()}, "", ""), `<line x1="0" y1="0" x2="1.5" y2="2"/>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.expected {
+				t.Errorf("got %s, want %s", tt.got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNumberFormat_PathBuilder(t *testing.T) {
+	d := NewPathBuilder().
+		SetNumberFormat(TrimmedDecimals(4)).
+		MoveTo(0, 0.125).
+		LineTo(1, 0.33333).
+		ArcTo(0.5, 0.5, 0, 0, 1, 0, 0).
+		Close().
+		String()
+
+	expected := "M 0 0.125 L 1 0.3333 A 0.5 0.5 0 0 1 0 0 Z"
+	if d != expected {
+		t.Errorf("got %q, want %q", d, expected)
+	}
+}
+
+func TestNumberFormat_Markers(t *testing.T) {
+	def := MarkerDef{ID: "m", RefX: 5, RefY: 2.5, MarkerWidth: 10, NumberFormat: TrimmedDecimals(2)}
+	got := Marker(def)
+	if !strings.Contains(got, `refX="5" refY="2.5" markerWidth="10"`) {
+		t.Errorf("marker numbers not formatted: %s", got)
+	}
+	if elem := MarkerElement(def).String(); !strings.Contains(elem, `refX="5" refY="2.5" markerWidth="10"`) {
+		t.Errorf("marker element numbers not formatted: %s", elem)
+	}
+
+	shapes := []struct {
+		name, got, want string
+	}{
+		{"CrossMarker", CrossMarker("c", "red", 1.25, ShortestRoundTrip()), `stroke-width="1.25"`},
+		{"XMarker", XMarker("x", "red", 0.126, TrimmedDecimals(2)), `stroke-width="0.13"`},
+		{"DotMarker", DotMarker("d", "red", 2.5, ShortestRoundTrip()), `r="2.5"`},
+		{"DotMarker default", DotMarker("d", "red", 2.5), `r="2.5"`},
+		{"XMarker default", XMarker("x", "red", 2), `stroke-width="2.0"`},
+	}
+	for _, tt := range shapes {
+		if !strings.Contains(tt.got, tt.want) {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, tt.got)
+		}
+	}
+	if got := CrossMarker("c", "red", 1, ShortestRoundTrip()); !strings.Contains(got, `refX="5" refY="5"`) {
+		t.Errorf("CrossMarker: expected the format to apply to the marker attributes, got %s", got)
+	}
+}
+
+func TestNumberFormat_ClipPathManager(t *testing.T) {
+	m := NewClipPathManager()
+	m.SetNumberFormat(ShortestRoundTrip())
+	m.AddRoundedRect(0, 0, 10.5, 20, 4)
+	m.AddCircle(5, 5, 2.25)
+
+	defs := m.ToSVGDefs()
+	for _, want := range []string{
+		`<rect x="0" y="0" width="10.5" height="20" rx="4" ry="4"/>`,
+		`<circle cx="5" cy="5" r="2.25"/>`,
+	} {
+		if !strings.Contains(defs, want) {
+			t.Errorf("defs missing %s:\n%s", want, defs)
+		}
+	}
+}
+
+func TestNumberFormat_RendererOption(t *testing.T) {
+	root := &layout.Node{Rect: layout.Rect{X: 10, Y: 20, Width: 100, Height: 50.5}}
+
+	opts := DefaultOptions()
+	opts.NumberFormat = TrimmedDecimals(2)
+	svg := RenderToSVG(root, opts)
+	if !strings.Contains(svg, `<rect x="10" y="20" width="100" height="50.5"`) {
+		t.Errorf("renderer did not apply NumberFormat:\n%s", svg)
+	}
+
+	// A format set by StyleNodeFunc wins over the renderer option.
+	opts.StyleNodeFunc = func(node *layout.Node, depth int) Style {
+		return Style{Fill: "blue", NumberFormat: FixedDecimals(1)}
+	}
+	svg = RenderToSVG(root, opts)
+	if !strings.Contains(svg, `<rect x="10.0" y="20.0" width="100.0" height="50.5" fill="blue"/>`) {
+		t.Errorf("style NumberFormat not preferred:\n%s", svg)
+	}
+
+	// The document tree variant writes the same numbers.
+	doc := NewRenderer(opts).RenderDocument(root)
+	if !strings.Contains(doc.String(), `width="100.0"`) {
+		t.Errorf("RenderDocument did not apply NumberFormat:\n%s", doc.String())
+	}
+}
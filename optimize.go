@@ -0,0 +1,750 @@
+package svg
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// OptimizeOptions selects the passes run by Optimize
+type OptimizeOptions struct {
+	// RemoveWhitespace drops indentation between elements. Whitespace inside
+	// text content elements is kept.
+	RemoveWhitespace bool
+
+	// TrimNumbers rewrites numbers in geometry attributes without redundant
+	// zeros ("10.00" becomes "10").
+	TrimNumbers bool
+	// Precision rounds numbers to this many decimals when TrimNumbers is set.
+	// A negative value keeps every digit, which leaves the geometry unchanged.
+	Precision int
+
+	// RemoveUnusedIDs drops id attributes that nothing references. url(),
+	// href, ARIA id lists and animation begin/end values such as "a.end"
+	// count as references.
+	RemoveUnusedIDs bool
+
+	// RemoveDefaults drops attributes set to their initial value
+	RemoveDefaults bool
+
+	// CollapseGroups replaces <g> elements without attributes by their children
+	CollapseGroups bool
+
+	// RemoveEmptyDefs drops <defs> elements without child elements
+	RemoveEmptyDefs bool
+
+	// ShapesToPaths converts rect, line, polyline and polygon elements to
+	// <path> when the path data is shorter
+	ShapesToPaths bool
+
+	// MergePaths joins adjacent sibling paths with identical attributes whose
+	// painted areas cannot overlap
+	MergePaths bool
+}
+
+// DefaultOptimizeOptions enables every pass with lossless number trimming
+func DefaultOptimizeOptions() OptimizeOptions {
+	return OptimizeOptions{
+		RemoveWhitespace: true,
+		TrimNumbers:      true,
+		Precision:        -1,
+		RemoveUnusedIDs:  true,
+		RemoveDefaults:   true,
+		CollapseGroups:   true,
+		RemoveEmptyDefs:  true,
+		ShapesToPaths:    true,
+		MergePaths:       true,
+	}
+}
+
+// Optimize shrinks a document in place. With a negative Precision the passes
+// only make changes that leave the rasterized output unchanged. Stylesheets
+// are not analyzed, so CSS selectors that depend on element types or nesting
+// may match differently after ShapesToPaths or CollapseGroups.
+func Optimize(doc *Document, opts OptimizeOptions) {
+	if doc == nil || doc.Root == nil {
+		return
+	}
+	root := doc.Root
+
+	if opts.RemoveWhitespace {
+		removeWhitespace(root)
+	}
+	if opts.TrimNumbers {
+		format := ShortestRoundTrip()
+		if opts.Precision >= 0 {
+			format = TrimmedDecimals(opts.Precision)
+		}
+		trimNumbers(root, format)
+	}
+	if opts.RemoveUnusedIDs {
+		removeUnusedIDs(root)
+	}
+	if opts.RemoveDefaults {
+		// Content referenced by <use> inherits from the <use> element, which
+		// is not an ancestor in the tree, and stylesheet rules may set
+		// properties that an explicit initial value overrides.
+		keepInherited := len(root.FindAll("use")) > 0 || len(root.FindAll("style")) > 0
+		removeDefaults(root, nil, keepInherited)
+	}
+	if opts.CollapseGroups {
+		collapseGroups(root)
+	}
+	if opts.RemoveEmptyDefs {
+		removeEmptyDefs(root)
+	}
+	if opts.ShapesToPaths {
+		shapesToPaths(root, nil)
+	}
+	if opts.MergePaths {
+		mergePaths(root, nil)
+	}
+}
+
+// OptimizeString parses SVG markup, optimizes it and serializes the result
+func OptimizeString(svg string, opts OptimizeOptions) (string, error) {
+	doc, err := Parse(strings.NewReader(svg))
+	if err != nil {
+		return "", err
+	}
+	Optimize(doc, opts)
+	return doc.String(), nil
+}
+
+// textContentElements are the elements whose character data is rendered or
+// otherwise meaningful, so their whitespace is kept.
+var textContentElements = map[string]bool{
+	"text":          true,
+	"tspan":         true,
+	"textPath":      true,
+	"title":         true,
+	"desc":          true,
+	"style":         true,
+	"script":        true,
+	"metadata":      true,
+	"foreignObject": true,
+}
+
+func removeWhitespace(e *Element) {
+	if textContentElements[localName(e.Tag)] {
+		return
+	}
+	children := e.Children[:0]
+	for _, child := range e.Children {
+		if c, ok := child.(*CharData); ok && strings.TrimSpace(c.Data) == "" {
+			continue
+		}
+		if el, ok := child.(*Element); ok {
+			removeWhitespace(el)
+		}
+		children = append(children, child)
+	}
+	e.Children = children
+}
+
+// numericAttributes lists the attributes whose numbers TrimNumbers rewrites.
+// Path data is handled separately because arc flags may be written without
+// separators.
+var numericAttributes = map[string]bool{
+	"x": true, "y": true, "width": true, "height": true,
+	"cx": true, "cy": true, "r": true, "rx": true, "ry": true,
+	"x1": true, "y1": true, "x2": true, "y2": true,
+	"fx": true, "fy": true, "fr": true, "dx": true, "dy": true,
+	"points": true, "viewBox": true, "transform": true, "gradientTransform": true,
+	"stroke-width": true, "stroke-dasharray": true, "stroke-dashoffset": true, "stroke-miterlimit": true,
+	"opacity": true, "fill-opacity": true, "stroke-opacity": true, "stop-opacity": true,
+	"offset": true, "refX": true, "refY": true, "markerWidth": true, "markerHeight": true,
+	"font-size": true,
+}
+
+var numberPattern = regexp.MustCompile(`[-+]?(?:\d+\.?\d*|\.\d+)(?:[eE][-+]?\d+)?`)
+
+func trimNumbers(e *Element, format NumberFormat) {
+	for i, attr := range e.Attrs {
+		switch {
+		case attr.Name == "d":
+			if d, ok := formatPathData(attr.Value, format); ok {
+				e.Attrs[i].Value = d
+			}
+		case numericAttributes[attr.Name]:
+			e.Attrs[i].Value = numberPattern.ReplaceAllStringFunc(attr.Value, func(s string) string {
+				v, err := strconv.ParseFloat(s, 64)
+				if err != nil {
+					return s
+				}
+				out := format.Format(v)
+				// "0.5.5" is two numbers; keep the leading dot so the second
+				// one does not merge into the first.
+				if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "-.") || strings.HasPrefix(s, "+.") {
+					out = strings.Replace(out, "0.", ".", 1)
+				}
+				return out
+			})
+		}
+	}
+	for _, child := range e.ChildElements() {
+		trimNumbers(child, format)
+	}
+}
+
+// pathArgCounts is the number of arguments each path command takes
+var pathArgCounts = map[byte]int{
+	'M': 2, 'L': 2, 'T': 2, 'H': 1, 'V': 1, 'C': 6, 'S': 4, 'Q': 4, 'A': 7, 'Z': 0,
+}
+
+// formatPathData rewrites the numbers of path data, keeping its commands.
+// It reports false if the data cannot be parsed.
+func formatPathData(d string, format NumberFormat) (string, bool) {
+	sc := &pathDataScanner{s: d}
+	var b strings.Builder
+	var cmd byte
+	for {
+		sc.skipSeparators()
+		if sc.done() {
+			break
+		}
+		c := sc.s[sc.pos]
+		switch {
+		case isPathCommand(c):
+			cmd = c
+			sc.pos++
+			b.WriteByte(c)
+		case cmd == 0 || !sc.atNumber():
+			return "", false
+		}
+
+		upper := cmd &^ 0x20
+		n := pathArgCounts[upper]
+		if n == 0 {
+			if !isPathCommand(c) {
+				return "", false
+			}
+			continue
+		}
+		for i := 0; i < n; i++ {
+			if i > 0 || !isPathCommand(c) {
+				b.WriteByte(' ')
+			}
+			if upper == 'A' && (i == 3 || i == 4) {
+				f, err := sc.flag()
+				if err != nil {
+					return "", false
+				}
+				if f {
+					b.WriteByte('1')
+				} else {
+					b.WriteByte('0')
+				}
+				continue
+			}
+			v, err := sc.number()
+			if err != nil {
+				return "", false
+			}
+			b.WriteString(format.Format(v))
+		}
+	}
+	return b.String(), true
+}
+
+var (
+	urlReferencePattern = regexp.MustCompile(`url\(\s*['"]?#([^)'"\s]+)`)
+	idListAttributes    = map[string]bool{"aria-labelledby": true, "aria-describedby": true, "aria-owns": true, "aria-controls": true}
+
+	// timingReferencePattern matches the element of a SMIL syncbase or event
+	// value, such as "a.end" or "b.click+1s"; dots in the id are escaped
+	timingReferencePattern = regexp.MustCompile(`^([A-Za-z_](?:\\.|[^.\\;\s()+])*)\.`)
+)
+
+// timingReferences returns the ids referenced by the SMIL begin or end
+// value list v
+func timingReferences(v string) []string {
+	var ids []string
+	for _, value := range strings.Split(v, ";") {
+		if m := timingReferencePattern.FindStringSubmatch(strings.TrimSpace(value)); m != nil {
+			ids = append(ids, strings.ReplaceAll(m[1], `\.`, "."))
+		}
+	}
+	return ids
+}
+
+func removeUnusedIDs(root *Element) {
+	// Scripts may look elements up by id.
+	if len(root.FindAll("script")) > 0 {
+		return
+	}
+
+	referenced := make(map[string]bool)
+	var opaque []string
+	var collect func(e *Element)
+	collect = func(e *Element) {
+		for _, attr := range e.Attrs {
+			for _, m := range urlReferencePattern.FindAllStringSubmatch(attr.Value, -1) {
+				referenced[m[1]] = true
+			}
+			switch {
+			case localName(attr.Name) == "href" && strings.HasPrefix(attr.Value, "#"):
+				referenced[attr.Value[1:]] = true
+			case idListAttributes[attr.Name]:
+				for _, id := range strings.Fields(attr.Value) {
+					referenced[id] = true
+				}
+			case attr.Name == "begin" || attr.Name == "end":
+				for _, id := range timingReferences(attr.Value) {
+					referenced[id] = true
+				}
+			}
+		}
+		for _, child := range e.Children {
+			switch c := child.(type) {
+			case *Element:
+				collect(c)
+			case *Raw:
+				opaque = append(opaque, c.XML)
+			case *CharData:
+				if e.Tag == "style" {
+					opaque = append(opaque, c.Data)
+				}
+			}
+		}
+	}
+	collect(root)
+
+	root.Walk(func(e *Element) bool {
+		id, ok := e.Attr("id")
+		if !ok || referenced[id] {
+			return true
+		}
+		for _, text := range opaque {
+			if strings.Contains(text, "#"+id) {
+				return true
+			}
+		}
+		e.RemoveAttr("id")
+		return true
+	})
+}
+
+// inheritedDefaults are the initial values of inherited properties. They
+// are only dropped when no ancestor declares the property.
+var inheritedDefaults = map[string]string{
+	"fill-opacity":      "1",
+	"fill-rule":         "nonzero",
+	"clip-rule":         "nonzero",
+	"stroke":            "none",
+	"stroke-width":      "1",
+	"stroke-opacity":    "1",
+	"stroke-linecap":    "butt",
+	"stroke-linejoin":   "miter",
+	"stroke-miterlimit": "4",
+	"stroke-dasharray":  "none",
+	"stroke-dashoffset": "0",
+	"marker-start":      "none",
+	"marker-mid":        "none",
+	"marker-end":        "none",
+	"visibility":        "visible",
+	"font-style":        "normal",
+	"text-anchor":       "start",
+}
+
+// elementDefaults are the initial values of attributes that are not
+// inherited, by element.
+var elementDefaults = map[string]map[string]string{
+	"*":              {"opacity": "1", "clip-path": "none", "transform": ""},
+	"svg":            {"x": "0", "y": "0", "preserveAspectRatio": "xMidYMid meet"},
+	"rect":           {"x": "0", "y": "0"},
+	"use":            {"x": "0", "y": "0"},
+	"image":          {"x": "0", "y": "0"},
+	"circle":         {"cx": "0", "cy": "0"},
+	"ellipse":        {"cx": "0", "cy": "0"},
+	"line":           {"x1": "0", "y1": "0", "x2": "0", "y2": "0"},
+	"stop":           {"stop-opacity": "1"},
+	"linearGradient": {"gradientUnits": "objectBoundingBox", "spreadMethod": "pad"},
+	"radialGradient": {"gradientUnits": "objectBoundingBox", "spreadMethod": "pad"},
+	"clipPath":       {"clipPathUnits": "userSpaceOnUse"},
+	"marker":         {"markerUnits": "strokeWidth"},
+}
+
+// removeDefaults drops attributes set to their initial value. Inherited
+// properties are kept when keepInherited is set, or when an ancestor sets
+// the property or has a class that a stylesheet could match.
+func removeDefaults(e *Element, ancestors []map[string]string, keepInherited bool) {
+	tag := localName(e.Tag)
+	attrs := e.Attrs[:0]
+	for _, attr := range e.Attrs {
+		value := strings.TrimSpace(attr.Value)
+		if isDefaultValue(tag, attr.Name, value, ancestors, keepInherited) {
+			continue
+		}
+		attrs = append(attrs, attr)
+	}
+	e.Attrs = attrs
+
+	ancestors = append(ancestors, elementProperties(e))
+	for _, child := range e.ChildElements() {
+		removeDefaults(child, ancestors, keepInherited)
+	}
+}
+
+func isDefaultValue(tag, name, value string, ancestors []map[string]string, keepInherited bool) bool {
+	if def, ok := elementDefaults[tag][name]; ok {
+		return sameValue(value, def)
+	}
+	if def, ok := elementDefaults["*"][name]; ok {
+		return sameValue(value, def)
+	}
+	if def, ok := inheritedDefaults[name]; ok {
+		if keepInherited || ancestorDeclares(ancestors, name) || ancestorDeclares(ancestors, "class") {
+			return false
+		}
+		return sameValue(value, def)
+	}
+	return false
+}
+
+func sameValue(value, def string) bool {
+	if value == def {
+		return true
+	}
+	v, err1 := strconv.ParseFloat(value, 64)
+	d, err2 := strconv.ParseFloat(def, 64)
+	return err1 == nil && err2 == nil && v == d
+}
+
+func ancestorDeclares(ancestors []map[string]string, name string) bool {
+	for _, props := range ancestors {
+		if _, ok := props[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// inheritedValue returns the value of name declared by e or its closest
+// ancestor that declares it.
+func inheritedValue(e *Element, ancestors []map[string]string, name string) (string, bool) {
+	if v, ok := elementProperties(e)[name]; ok {
+		return v, true
+	}
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		if v, ok := ancestors[i][name]; ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// elementProperties returns the presentation attributes and style
+// declarations of an element.
+func elementProperties(e *Element) map[string]string {
+	props := make(map[string]string, len(e.Attrs))
+	style := ""
+	for _, attr := range e.Attrs {
+		if attr.Name == "style" {
+			style = attr.Value
+			continue
+		}
+		props[attr.Name] = strings.TrimSpace(attr.Value)
+	}
+	for _, decl := range strings.Split(style, ";") {
+		name, value, ok := strings.Cut(decl, ":")
+		if ok {
+			props[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(value)
+		}
+	}
+	return props
+}
+
+func collapseGroups(e *Element) {
+	var children []Node
+	for _, child := range e.Children {
+		el, ok := child.(*Element)
+		if !ok {
+			children = append(children, child)
+			continue
+		}
+		collapseGroups(el)
+		// <switch> renders only its first valid child, so the group is
+		// what makes its children render together
+		if el.Tag == "g" && len(el.Attrs) == 0 && localName(e.Tag) != "switch" {
+			for _, grandchild := range el.Children {
+				if gc, ok := grandchild.(*Element); ok {
+					gc.parent = e
+				}
+			}
+			children = append(children, el.Children...)
+			continue
+		}
+		children = append(children, child)
+	}
+	e.Children = children
+}
+
+func removeEmptyDefs(e *Element) {
+	for _, child := range e.ChildElements() {
+		if child.Tag == "defs" && len(child.ChildElements()) == 0 && !hasOpaqueContent(child) {
+			e.RemoveChild(child)
+			continue
+		}
+		removeEmptyDefs(child)
+	}
+}
+
+// hasOpaqueContent reports whether e holds raw markup that may define elements
+func hasOpaqueContent(e *Element) bool {
+	for _, child := range e.Children {
+		if r, ok := child.(*Raw); ok && strings.TrimSpace(r.XML) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// markersInEffect reports whether e would draw markers if it were a path
+func markersInEffect(e *Element, ancestors []map[string]string) bool {
+	for _, name := range []string{"marker-start", "marker-mid", "marker-end"} {
+		if v, ok := inheritedValue(e, ancestors, name); ok && v != "none" {
+			return true
+		}
+	}
+	return false
+}
+
+func shapesToPaths(e *Element, ancestors []map[string]string) {
+	ancestors = append(ancestors, elementProperties(e))
+	for _, child := range e.ChildElements() {
+		shapesToPaths(child, ancestors)
+		if markersInEffect(child, ancestors) {
+			// Only paths and lines of certain kinds draw markers, and the
+			// rect would start drawing them.
+			continue
+		}
+		d, names, ok := shapePathData(child)
+		if !ok {
+			continue
+		}
+
+		removed := 0
+		for _, name := range names {
+			if v, ok := child.Attr(name); ok {
+				removed += len(name) + len(v) + 4
+			}
+		}
+		if len(d)+5 >= removed {
+			continue
+		}
+		for _, name := range names {
+			child.RemoveAttr(name)
+		}
+		child.Tag = "path"
+		child.Attrs = append([]Attr{{Name: "d", Value: d}}, child.Attrs...)
+	}
+}
+
+// shapePathData returns the path data equivalent to a basic shape and the
+// geometry attributes it replaces.
+func shapePathData(e *Element) (string, []string, bool) {
+	num := func(name string) (float64, bool) {
+		v, ok := e.Attr(name)
+		if !ok {
+			return 0, true
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	f := ShortestRoundTrip().Format
+
+	switch e.Tag {
+	case "rect":
+		if _, ok := e.Attr("rx"); ok {
+			return "", nil, false
+		}
+		if _, ok := e.Attr("ry"); ok {
+			return "", nil, false
+		}
+		x, ok1 := num("x")
+		y, ok2 := num("y")
+		w, ok3 := num("width")
+		h, ok4 := num("height")
+		if !ok1 || !ok2 || !ok3 || !ok4 || w <= 0 || h <= 0 {
+			return "", nil, false
+		}
+		d := "M" + f(x) + " " + f(y) + "H" + f(x+w) + "V" + f(y+h) + "H" + f(x) + "Z"
+		return d, []string{"x", "y", "width", "height"}, true
+
+	case "line":
+		x1, ok1 := num("x1")
+		y1, ok2 := num("y1")
+		x2, ok3 := num("x2")
+		y2, ok4 := num("y2")
+		if !ok1 || !ok2 || !ok3 || !ok4 {
+			return "", nil, false
+		}
+		d := "M" + f(x1) + " " + f(y1) + "L" + f(x2) + " " + f(y2)
+		return d, []string{"x1", "y1", "x2", "y2"}, true
+
+	case "polyline", "polygon":
+		points, _ := e.Attr("points")
+		sc := &pathDataScanner{s: points}
+		var coords []string
+		for {
+			sc.skipSeparators()
+			if sc.done() {
+				break
+			}
+			v, err := sc.number()
+			if err != nil {
+				return "", nil, false
+			}
+			coords = append(coords, f(v))
+		}
+		if len(coords) < 4 || len(coords)%2 != 0 {
+			return "", nil, false
+		}
+		var b strings.Builder
+		for i := 0; i < len(coords); i += 2 {
+			if i == 0 {
+				b.WriteString("M")
+			} else if i == 2 {
+				b.WriteString("L")
+			} else {
+				b.WriteString(" ")
+			}
+			b.WriteString(coords[i] + " " + coords[i+1])
+		}
+		if e.Tag == "polygon" {
+			b.WriteString("Z")
+		}
+		return b.String(), []string{"points"}, true
+	}
+	return "", nil, false
+}
+
+// mergeBlockingAttributes are attributes whose effect depends on the
+// element's own geometry or identity, so paths carrying them stay separate.
+var mergeBlockingAttributes = map[string]bool{
+	"id": true, "clip-path": true, "mask": true, "filter": true, "style": true,
+	"marker-start": true, "marker-mid": true, "marker-end": true, "pathLength": true,
+}
+
+func mergePaths(e *Element, ancestors []map[string]string) {
+	ancestors = append(ancestors, elementProperties(e))
+	for _, child := range e.ChildElements() {
+		mergePaths(child, ancestors)
+	}
+
+	var prev *Element
+	var prevBox boundingBox
+	children := e.Children[:0]
+	for _, child := range e.Children {
+		el, ok := child.(*Element)
+		if !ok {
+			if c, isText := child.(*CharData); isText && strings.TrimSpace(c.Data) == "" {
+				children = append(children, child)
+				continue
+			}
+			prev = nil
+			children = append(children, child)
+			continue
+		}
+
+		box, ok := mergeablePathBounds(el, ancestors)
+		if !ok {
+			prev = nil
+			children = append(children, child)
+			continue
+		}
+		if prev != nil && sameAttrsExceptD(prev, el) && !boxesOverlap(prevBox, box) {
+			d1, _ := prev.Attr("d")
+			d2, _ := el.Attr("d")
+			prev.SetAttr("d", d1+" "+d2)
+			prevBox = unionBounds(prevBox, box)
+			el.parent = nil
+			continue
+		}
+		prev, prevBox = el, box
+		children = append(children, child)
+	}
+	e.Children = children
+}
+
+// mergeablePathBounds returns the bounds of a path's fill and stroke, or
+// false if merging it with a neighbour could change how it renders.
+func mergeablePathBounds(e *Element, ancestors []map[string]string) (boundingBox, bool) {
+	if e.Tag != "path" || len(e.Children) > 0 || markersInEffect(e, ancestors) {
+		return boundingBox{}, false
+	}
+	for _, attr := range e.Attrs {
+		if mergeBlockingAttributes[attr.Name] {
+			return boundingBox{}, false
+		}
+	}
+	// Bounding-box relative paint servers depend on the element's bounds.
+	for _, name := range []string{"fill", "stroke"} {
+		if v, ok := inheritedValue(e, ancestors, name); ok && strings.Contains(v, "url(") {
+			return boundingBox{}, false
+		}
+	}
+
+	d, _ := e.Attr("d")
+	d = strings.TrimSpace(d)
+	// A leading relative moveto would become relative to the previous path.
+	if !strings.HasPrefix(d, "M") {
+		return boundingBox{}, false
+	}
+	segs, err := parsePathData(d)
+	if err != nil {
+		return boundingBox{}, false
+	}
+	box, ok := segmentsBounds(segs)
+	if !ok {
+		return boundingBox{}, false
+	}
+
+	if stroke, ok := inheritedValue(e, ancestors, "stroke"); ok && stroke != "none" {
+		width := 1.0
+		if v, ok := inheritedValue(e, ancestors, "stroke-width"); ok {
+			w, err := strconv.ParseFloat(strings.TrimSuffix(v, "px"), 64)
+			if err != nil {
+				return boundingBox{}, false
+			}
+			width = w
+		}
+		miter := 4.0
+		if v, ok := inheritedValue(e, ancestors, "stroke-miterlimit"); ok {
+			m, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return boundingBox{}, false
+			}
+			miter = m
+		}
+		// Miter joins reach at most miterlimit × half the width; square
+		// caps reach half the width times √2.
+		pad := width / 2 * math.Max(miter, math.Sqrt2)
+		box.Min.X -= pad
+		box.Min.Y -= pad
+		box.Max.X += pad
+		box.Max.Y += pad
+	}
+	return box, true
+}
+
+func sameAttrsExceptD(a, b *Element) bool {
+	if len(a.Attrs) != len(b.Attrs) {
+		return false
+	}
+	for _, attr := range a.Attrs {
+		if attr.Name == "d" {
+			continue
+		}
+		if v, ok := b.Attr(attr.Name); !ok || v != attr.Value {
+			return false
+		}
+	}
+	return true
+}
+
+func boxesOverlap(a, b boundingBox) bool {
+	return a.Min.X <= b.Max.X && b.Min.X <= a.Max.X && a.Min.Y <= b.Max.Y && b.Min.Y <= a.Max.Y
+}
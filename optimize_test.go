@@ -0,0 +1,187 @@
+package svg
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/SCKelemen/layout"
+)
+
+// expectSameRaster checks that optimizing svgData leaves its PNG export
+// byte for byte unchanged and returns the optimized markup.
+func expectSameRaster(t *testing.T, svgData string, opts OptimizeOptions) string {
+	t.Helper()
+	optimized, err := OptimizeString(svgData, opts)
+	if err != nil {
+		t.Fatalf("OptimizeString failed: %v", err)
+	}
+
+	exportOpts := ExportOptions{Format: FormatPNG, Width: 100, Height: 100, IgnoreUnsupported: true}
+	before, err := Export(svgData, exportOpts)
+	if err != nil {
+		t.Fatalf("export before failed: %v", err)
+	}
+	after, err := Export(optimized, exportOpts)
+	if err != nil {
+		t.Fatalf("export after failed: %v\n%s", err, optimized)
+	}
+	if !bytes.Equal(before, after) {
+		t.Fatalf("optimization changed the rasterized output:\n%s", optimized)
+	}
+	return optimized
+}
+
+func TestOptimizeTrimsNumbers(t *testing.T) {
+	out := expectSameRaster(t, `<svg width="100" height="100"><rect x="10.00" y="20.50" width="30.00" height="40.00" fill="#e0e0e0"/>`+
+		`<path d="M10.00,10.00 a5.00 5.00 0 01 10.00 0.00 L.5.5" stroke="#000"/></svg>`, OptimizeOptions{TrimNumbers: true, Precision: -1})
+
+	for _, want := range []string{`x="10" y="20.5" width="30" height="40" fill="#e0e0e0"`, `d="M10 10a5 5 0 0 1 10 0L0.5 0.5"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in %s", want, out)
+		}
+	}
+}
+
+func TestOptimizePrecisionRounds(t *testing.T) {
+	out, err := OptimizeString(`<svg><circle cx="1.23456" cy=".5.25" r="-0.0001"/></svg>`, OptimizeOptions{TrimNumbers: true, Precision: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<circle cx="1.23" cy=".5.25" r="0"/>`; !strings.Contains(out, want) {
+		t.Errorf("expected %s in %s", want, out)
+	}
+}
+
+func TestOptimizeRemovesStructure(t *testing.T) {
+	svgData := `<svg width="100" height="100">
+  <defs>
+  </defs>
+  <g>
+    <g id="unused" opacity="1">
+      <rect x="0" y="0" width="50" height="50" fill="#ff0000" stroke-width="1"/>
+    </g>
+  </g>
+  <text x="10" y="90"> a  <tspan>b</tspan></text>
+</svg>`
+	out := expectSameRaster(t, svgData, DefaultOptimizeOptions())
+
+	want := `<svg width="100" height="100"><path d="M0 0H50V50H0Z" fill="#ff0000"/><text x="10" y="90"> a  <tspan>b</tspan></text></svg>`
+	if out != want {
+		t.Errorf("got  %s\nwant %s", out, want)
+	}
+}
+
+func TestOptimizeKeepsReferencedIDsAndInheritedOverrides(t *testing.T) {
+	svgData := `<svg width="100" height="100"><defs><linearGradient id="g"><stop offset="0" stop-color="#f00"/></linearGradient></defs>` +
+		`<g fill-opacity="0.5"><rect id="r" width="50" height="50" fill="url(#g)" fill-opacity="1"/></g>` +
+		`<use href="#r"/></svg>`
+	out := expectSameRaster(t, svgData, DefaultOptimizeOptions())
+
+	for _, want := range []string{`id="g"`, `id="r"`, `fill-opacity="1"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in %s", want, out)
+		}
+	}
+}
+
+func TestOptimizeKeepsInheritedDefaultsUnderStylesheets(t *testing.T) {
+	for _, svgData := range []string{
+		`<svg><style>.a{stroke:red}</style><g class="a"><rect width="10" height="10" stroke="none"/></g></svg>`,
+		// An external stylesheet could match the class too
+		`<svg><g class="a"><rect width="10" height="10" stroke="none"/></g></svg>`,
+	} {
+		out, err := OptimizeString(svgData, DefaultOptimizeOptions())
+		if err != nil {
+			t.Fatalf("OptimizeString failed: %v", err)
+		}
+		if !strings.Contains(out, `stroke="none"`) {
+			t.Errorf("expected the explicit stroke kept: %s", out)
+		}
+	}
+}
+
+func TestOptimizeKeepsGroupsInSwitch(t *testing.T) {
+	svgData := `<svg><switch><g><rect width="10" height="10"/><circle r="5"/></g></switch></svg>`
+	out, err := OptimizeString(svgData, DefaultOptimizeOptions())
+	if err != nil {
+		t.Fatalf("OptimizeString failed: %v", err)
+	}
+	if !strings.Contains(out, "<switch><g>") {
+		t.Errorf("expected the group in <switch> kept: %s", out)
+	}
+}
+
+func TestOptimizeKeepsAnimationTimingReferences(t *testing.T) {
+	svgData := `<svg width="100" height="100">` +
+		`<rect id="box" width="10" height="10"><animate id="a" attributeName="opacity" to="0" dur="1s"/></rect>` +
+		`<rect id="b" width="10" height="10"/><rect id="c.d" width="10" height="10"/><rect id="unused" width="10" height="10"/>` +
+		`<circle r="5"><animate attributeName="r" to="10" begin="a.end; 2s" end="b.click+1s;c\.d.repeat(2)"/></circle></svg>`
+	out, err := OptimizeString(svgData, DefaultOptimizeOptions())
+	if err != nil {
+		t.Fatalf("OptimizeString failed: %v", err)
+	}
+
+	for _, want := range []string{`id="a"`, `id="b"`, `id="c.d"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in %s", want, out)
+		}
+	}
+	if strings.Contains(out, `id="unused"`) || strings.Contains(out, `id="box"`) {
+		t.Errorf("expected unreferenced ids removed: %s", out)
+	}
+}
+
+func TestOptimizeMergesDisjointPaths(t *testing.T) {
+	svgData := `<svg width="100" height="100">` +
+		`<path d="M10 10H30V30H10Z" fill="#00f" fill-opacity="0.5" stroke="#000" stroke-width="2"/>` +
+		`<path d="M60 60H80V80H60Z" fill="#00f" fill-opacity="0.5" stroke="#000" stroke-width="2"/>` +
+		`<path d="M70 70H90V90H70Z" fill="#00f" fill-opacity="0.5" stroke="#000" stroke-width="2"/>` +
+		`</svg>`
+	out := expectSameRaster(t, svgData, OptimizeOptions{MergePaths: true})
+
+	if got := strings.Count(out, "<path"); got != 2 {
+		t.Errorf("expected the overlapping path to stay separate, got %d paths: %s", got, out)
+	}
+	if !strings.Contains(out, `d="M10 10H30V30H10Z M60 60H80V80H60Z"`) {
+		t.Errorf("expected the disjoint paths to merge: %s", out)
+	}
+}
+
+func TestOptimizeShapesToPathsSkipsMarkersAndRoundedRects(t *testing.T) {
+	svgData := `<svg width="100" height="100"><defs><marker id="m"><rect width="3" height="3"/></marker></defs>` +
+		`<polyline points="10,10 50,50 90,10" fill="none" stroke="#000" marker-mid="url(#m)"/>` +
+		`<rect x="10" y="60" width="30" height="30" rx="5"/>` +
+		`<polygon points="60,60 90,60 90,90"/></svg>`
+	out := expectSameRaster(t, svgData, OptimizeOptions{ShapesToPaths: true})
+
+	for _, want := range []string{`<polyline`, `rx="5"`, `<path d="M60 60L90 60 90 90Z"/>`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in %s", want, out)
+		}
+	}
+}
+
+func TestOptimizeRendererOutput(t *testing.T) {
+	root := &layout.Node{
+		Rect: layout.Rect{Width: 100, Height: 50},
+		Children: []*layout.Node{
+			{Rect: layout.Rect{X: 0, Y: 0, Width: 40, Height: 50}},
+			{Rect: layout.Rect{X: 60, Y: 0, Width: 40, Height: 50}},
+		},
+	}
+	opts := DefaultOptions()
+	opts.Width, opts.Height = 100, 100
+	opts.StyleSheet = nil
+	svgData := RenderToSVG(root, opts)
+
+	out := expectSameRaster(t, svgData, DefaultOptimizeOptions())
+	if len(out) >= len(svgData) {
+		t.Errorf("expected optimized output to be smaller: %d >= %d", len(out), len(svgData))
+	}
+	for _, unwanted := range []string{".00", "<defs>", "<g>", "\n"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("expected %q to be removed: %s", unwanted, out)
+		}
+	}
+}
@@ -36,6 +36,37 @@ type Options struct {
 	// If both are set, StyleNodeFunc takes precedence.
 	StyleNodeFunc func(node *layout.Node, depth int) Style
 
+	// NumberFormat controls how coordinates are written (optional)
+	// Applies to nodes whose style leaves NumberFormat unset and to clipPaths.
+	// The zero value writes two fixed decimals.
+	NumberFormat NumberFormat
+
+	// BoxStyleFunc supplies border colors, border styles and corner radii
+	// per node (optional). Border widths come from the layout style.
+	BoxStyleFunc func(node *layout.Node, depth int) BoxStyle
+
+	// DebugBoxes overlays the padding and content boxes of every node
+	DebugBoxes bool
+
+	// IDPrefix starts every generated ID, such as clipPath IDs (optional).
+	// Use distinct prefixes for SVGs embedded inline in the same HTML page.
+	IDPrefix string
+
+	// IDMode selects sequential or content-hash IDs
+	IDMode IDMode
+
+	// Defs is a registry of definitions shared between renderers (optional).
+	// If nil, each renderer creates its own using IDPrefix and IDMode.
+	Defs *Defs
+
+	// DocumentOrder paints nodes in tree order, ignoring z-index, positioning
+	// and stacking contexts
+	DocumentOrder bool
+
+	// LayoutContext resolves padding and border lengths (optional)
+	// If nil, the viewport is Width x Height with a 16px root font size.
+	LayoutContext *layout.LayoutContext
+
 	// RenderFunc allows custom rendering per node type
 	// If it returns non-empty string, that's used instead of default rendering
 	RenderFunc func(node interface{}, depth int) string
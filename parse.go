@@ -0,0 +1,112 @@
+package svg
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Parse reads an SVG document into a document tree. Elements, character data,
+// comments and processing instructions are kept in document order, and
+// namespace prefixes are kept in tag and attribute names (e.g., "xlink:href",
+// "xml:space"), so the document serializes back with the same structure.
+func Parse(r io.Reader) (*Document, error) {
+	decoder := xml.NewDecoder(r)
+	doc := &Document{}
+	var stack []*Element
+
+	for {
+		// RawToken leaves prefixes untranslated, which keeps names as written.
+		token, err := decoder.RawToken()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("SVG parse error: %w", err)
+		}
+
+		var node Node
+		switch t := token.(type) {
+		case xml.StartElement:
+			elem := NewElement(qualifiedName(t.Name))
+			for _, attr := range t.Attr {
+				elem.Attrs = append(elem.Attrs, Attr{Name: qualifiedName(attr.Name), Value: attr.Value})
+			}
+			if len(stack) == 0 {
+				if doc.Root != nil {
+					return nil, fmt.Errorf("SVG parse error: multiple root elements")
+				}
+				doc.Root = elem
+			} else {
+				stack[len(stack)-1].AppendChild(elem)
+			}
+			stack = append(stack, elem)
+			continue
+
+		case xml.EndElement:
+			name := qualifiedName(t.Name)
+			if len(stack) == 0 || stack[len(stack)-1].Tag != name {
+				return nil, fmt.Errorf("SVG parse error: unexpected end element </%s>", name)
+			}
+			stack = stack[:len(stack)-1]
+			continue
+
+		case xml.CharData:
+			if len(stack) == 0 {
+				// Whitespace around the root element is not significant.
+				if strings.TrimSpace(string(t)) != "" {
+					return nil, fmt.Errorf("SVG parse error: text outside the root element")
+				}
+				continue
+			}
+			node = &CharData{Data: string(t)}
+
+		case xml.Comment:
+			node = &Comment{Data: string(t)}
+
+		case xml.ProcInst:
+			node = &ProcInst{Target: t.Target, Inst: strings.TrimSpace(string(t.Inst))}
+
+		case xml.Directive:
+			node = &Raw{XML: "<!" + string(t) + ">"}
+
+		default:
+			continue
+		}
+
+		switch {
+		case len(stack) > 0:
+			stack[len(stack)-1].AppendChild(node)
+		case doc.Root == nil:
+			doc.Prolog = append(doc.Prolog, node)
+		default:
+			doc.Epilog = append(doc.Epilog, node)
+		}
+	}
+
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("SVG parse error: unclosed element <%s>", stack[len(stack)-1].Tag)
+	}
+	if doc.Root == nil {
+		return nil, fmt.Errorf("no SVG root element found")
+	}
+	return doc, nil
+}
+
+// qualifiedName joins a raw XML name with its namespace prefix
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// localName strips the namespace prefix from a qualified name
+func localName(name string) string {
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,99 @@
+package svg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/SCKelemen/layout"
+)
+
+func TestParseRoundTrip(t *testing.T) {
+	input := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+		`<!-- icon -->` + "\n" +
+		`<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" viewBox="0 0 24 24">` +
+		`<?app hint?><!-- shapes --><use xlink:href="#dot"/>` +
+		`<text xml:space="preserve">a <tspan>b</tspan> c &amp; d</text></svg>` + "\n" +
+		`<!-- end -->`
+
+	doc, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := doc.String(); got != input {
+		t.Errorf("round trip changed the document:\n got %s\nwant %s", got, input)
+	}
+}
+
+func TestParseKeepsMixedContentOrder(t *testing.T) {
+	doc, err := Parse(strings.NewReader(`<svg><text>one<tspan>two</tspan>three</text></svg>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text := doc.Root.FindAll("text")[0]
+	if len(text.Children) != 3 {
+		t.Fatalf("expected 3 children, got %d", len(text.Children))
+	}
+	if c, ok := text.Children[0].(*CharData); !ok || c.Data != "one" {
+		t.Errorf("first child = %#v", text.Children[0])
+	}
+	if e, ok := text.Children[1].(*Element); !ok || e.Tag != "tspan" || e.Parent() != text {
+		t.Errorf("second child = %#v", text.Children[1])
+	}
+	if c, ok := text.Children[2].(*CharData); !ok || c.Data != "three" {
+		t.Errorf("third child = %#v", text.Children[2])
+	}
+	if got := text.TextContent(); got != "onetwothree" {
+		t.Errorf("TextContent() = %q", got)
+	}
+}
+
+func TestParseNamespacedAttributes(t *testing.T) {
+	doc, err := Parse(strings.NewReader(`<svg xmlns:xlink="http://www.w3.org/1999/xlink"><use xlink:href="#a" xml:space="preserve"/></svg>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	use := doc.Root.FindAll("use")[0]
+	if v, ok := use.Attr("xlink:href"); !ok || v != "#a" {
+		t.Errorf(`Attr("xlink:href") = %q, %v`, v, ok)
+	}
+	if v, ok := use.Attr("xml:space"); !ok || v != "preserve" {
+		t.Errorf(`Attr("xml:space") = %q, %v`, v, ok)
+	}
+	if v, ok := doc.Root.Attr("xmlns:xlink"); !ok || v != "http://www.w3.org/1999/xlink" {
+		t.Errorf(`Attr("xmlns:xlink") = %q, %v`, v, ok)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		``,
+		`<!-- only a comment -->`,
+		`<svg><g></svg>`,
+		`<svg><g>`,
+		`<svg/><svg/>`,
+		`text<svg/>`,
+	}
+	for _, input := range tests {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", input)
+		}
+	}
+}
+
+func TestParsedIconComposesIntoRenderedDocument(t *testing.T) {
+	icon, err := Parse(strings.NewReader(`<svg viewBox="0 0 24 24"><path d="M0 0L24 24"/></svg>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := NewRenderer(DefaultOptions()).RenderDocument(&layout.Node{
+		Rect: layout.Rect{Width: 24, Height: 24},
+	})
+	doc.Root.AppendChild(GroupElement("translate(10,10)", Style{}, icon.Root))
+
+	if !strings.Contains(doc.String(), `<g transform="translate(10,10)"><svg viewBox="0 0 24 24"><path d="M0 0L24 24"/></svg></g>`) {
+		t.Errorf("expected icon inside the rendered document, got: %s", doc.String())
+	}
+}
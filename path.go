@@ -0,0 +1,412 @@
+package svg
+
+import "fmt"
+
+// PathCommand identifies the kind of a path segment. Its value is the
+// upper-case SVG command letter.
+type PathCommand byte
+
+const (
+	PathMoveTo                 PathCommand = 'M'
+	PathLineTo                 PathCommand = 'L'
+	PathHorizontalLineTo       PathCommand = 'H'
+	PathVerticalLineTo         PathCommand = 'V'
+	PathCurveTo                PathCommand = 'C'
+	PathSmoothCurveTo          PathCommand = 'S'
+	PathQuadraticCurveTo       PathCommand = 'Q'
+	PathSmoothQuadraticCurveTo PathCommand = 'T'
+	PathArcTo                  PathCommand = 'A'
+	PathClose                  PathCommand = 'Z'
+)
+
+// PathSegment is one command of structured path data
+type PathSegment struct {
+	Command PathCommand
+	// Relative segments hold coordinates relative to the current point
+	Relative bool
+	// Points holds the control points followed by the end point: one point
+	// for M, L, T and A, two for S and Q and three for C. H uses only
+	// Points[0].X and V only Points[0].Y.
+	Points [3]Point
+	// Arc parameters, used by A. Rotation is in degrees.
+	RX, RY   float64
+	Rotation float64
+	LargeArc bool
+	Sweep    bool
+}
+
+// End returns the end point written in the segment. H and V segments only
+// carry one coordinate, and Z has no end point.
+func (s PathSegment) End() Point {
+	if n := s.Command.pointCount(); n > 0 {
+		return s.Points[n-1]
+	}
+	return Point{}
+}
+
+// absolute returns the segment in absolute coordinates, given the current
+// point. Shorthand commands are kept.
+func (s PathSegment) absolute(cur Point) PathSegment {
+	if !s.Relative {
+		return s
+	}
+	return s.offset(cur, 1)
+}
+
+// relative returns the segment in coordinates relative to cur
+func (s PathSegment) relative(cur Point) PathSegment {
+	if s.Relative || s.Command == PathClose {
+		return s
+	}
+	return s.offset(cur, -1)
+}
+
+// offset moves the points of s by sign*d and toggles Relative
+func (s PathSegment) offset(d Point, sign float64) PathSegment {
+	s.Relative = !s.Relative
+	switch s.Command {
+	case PathClose:
+		return s
+	case PathHorizontalLineTo:
+		s.Points[0].X += sign * d.X
+	case PathVerticalLineTo:
+		s.Points[0].Y += sign * d.Y
+	default:
+		for i := range s.Command.pointCount() {
+			s.Points[i].X += sign * d.X
+			s.Points[i].Y += sign * d.Y
+		}
+	}
+	return s
+}
+
+// pointCount returns how many entries of Points a command uses
+func (c PathCommand) pointCount() int {
+	switch c {
+	case PathCurveTo:
+		return 3
+	case PathSmoothCurveTo, PathQuadraticCurveTo:
+		return 2
+	case PathClose:
+		return 0
+	}
+	return 1
+}
+
+// letter returns the SVG command letter of a segment
+func (s PathSegment) letter() byte {
+	if s.Relative {
+		return byte(s.Command) + 'a' - 'A'
+	}
+	return byte(s.Command)
+}
+
+// PathData is structured SVG path data: the segments of a path in order.
+// Parse path data with ParsePath and write it back with String or AppendTo.
+type PathData []PathSegment
+
+// ParsePath parses SVG path data, keeping each command as written. Implicit
+// repetitions ("L 1 2 3 4") become segments of their own, and the implicit
+// lineto after a moveto becomes an L segment.
+//
+// As required by the SVG specification, segments parsed before an error are
+// returned together with the error.
+func ParsePath(d string) (PathData, error) {
+	sc := &pathDataScanner{s: d}
+	var path PathData
+	var cmd byte
+
+	for {
+		sc.skipSeparators()
+		if sc.done() {
+			break
+		}
+
+		c := sc.s[sc.pos]
+		if isPathCommand(c) {
+			cmd = c
+			sc.pos++
+		} else if cmd == 0 || !sc.atNumber() {
+			return path, fmt.Errorf("path data: unexpected %q at offset %d", c, sc.pos)
+		} else if cmd == 'Z' || cmd == 'z' {
+			return path, fmt.Errorf("path data: unexpected number after close at offset %d", sc.pos)
+		}
+
+		if len(path) == 0 && cmd != 'M' && cmd != 'm' {
+			return path, fmt.Errorf("path data: must begin with a moveto command")
+		}
+
+		seg := PathSegment{Command: PathCommand(cmd &^ 0x20), Relative: cmd >= 'a' && cmd <= 'z'}
+		switch seg.Command {
+		case PathClose:
+			// No parameters
+
+		case PathHorizontalLineTo:
+			x, err := sc.number()
+			if err != nil {
+				return path, err
+			}
+			seg.Points[0].X = x
+
+		case PathVerticalLineTo:
+			y, err := sc.number()
+			if err != nil {
+				return path, err
+			}
+			seg.Points[0].Y = y
+
+		case PathArcTo:
+			var err error
+			if seg.RX, err = sc.number(); err != nil {
+				return path, err
+			}
+			if seg.RY, err = sc.number(); err != nil {
+				return path, err
+			}
+			if seg.Rotation, err = sc.number(); err != nil {
+				return path, err
+			}
+			if seg.LargeArc, err = sc.flag(); err != nil {
+				return path, err
+			}
+			if seg.Sweep, err = sc.flag(); err != nil {
+				return path, err
+			}
+			if seg.Points[0], err = sc.point(); err != nil {
+				return path, err
+			}
+
+		default:
+			pts, err := sc.points(seg.Command.pointCount())
+			if err != nil {
+				return path, err
+			}
+			copy(seg.Points[:], pts)
+		}
+		path = append(path, seg)
+
+		// Coordinate pairs after a moveto are implicit lineto commands
+		if cmd == 'M' {
+			cmd = 'L'
+		} else if cmd == 'm' {
+			cmd = 'l'
+		}
+	}
+
+	return path, nil
+}
+
+// String returns the path data with the default number format
+func (p PathData) String() string {
+	return p.AppendTo(NewPathBuilder()).String()
+}
+
+// Builder returns a new PathBuilder holding the path
+func (p PathData) Builder() *PathBuilder {
+	return p.AppendTo(NewPathBuilder())
+}
+
+// AppendTo writes the segments to pb, keeping relative commands relative
+// unless pb writes the shortest encoding
+func (p PathData) AppendTo(pb *PathBuilder) *PathBuilder {
+	for _, seg := range p {
+		pb.emit(seg)
+	}
+	return pb
+}
+
+// PathData parses the builder's path data
+func (pb *PathBuilder) PathData() (PathData, error) {
+	return ParsePath(pb.String())
+}
+
+// Absolute returns the path with every segment in absolute coordinates.
+// Commands are kept, so H, V, S, T and A remain shorthands and arcs.
+func (p PathData) Absolute() PathData {
+	return p.normalize(false, false)
+}
+
+// ExpandShorthands returns the absolute path with H and V written as L,
+// S as C and T as Q
+func (p PathData) ExpandShorthands() PathData {
+	return p.normalize(true, false)
+}
+
+// ArcsToCubics returns the absolute path with each arc replaced by up to
+// four cubic Béziers (one per quarter turn). Degenerate arcs become lines or
+// are dropped, as in the SVG arc implementation notes.
+func (p PathData) ArcsToCubics() PathData {
+	return p.normalize(false, true)
+}
+
+// Normalize returns the absolute path made of M, L, C, Q and Z segments only
+func (p PathData) Normalize() PathData {
+	return p.normalize(true, true)
+}
+
+// normalize converts the path to absolute coordinates, optionally expanding
+// the shorthand commands and converting arcs to cubics
+func (p PathData) normalize(expand, arcs bool) PathData {
+	out := make(PathData, 0, len(p))
+	var cur, start, lastCtrl Point
+	var prev PathCommand
+
+	for _, seg := range p {
+		var base Point
+		if seg.Relative {
+			base = cur
+		}
+		abs := seg
+		abs.Relative = false
+
+		switch seg.Command {
+		case PathClose:
+			cur = start
+
+		case PathMoveTo:
+			abs.Points[0] = addPoint(seg.Points[0], base)
+			cur, start = abs.Points[0], abs.Points[0]
+
+		case PathLineTo:
+			abs.Points[0] = addPoint(seg.Points[0], base)
+			cur = abs.Points[0]
+
+		case PathHorizontalLineTo:
+			x := seg.Points[0].X + base.X
+			if expand {
+				abs = PathSegment{Command: PathLineTo, Points: [3]Point{{X: x, Y: cur.Y}}}
+			} else {
+				abs.Points[0] = Point{X: x}
+			}
+			cur.X = x
+
+		case PathVerticalLineTo:
+			y := seg.Points[0].Y + base.Y
+			if expand {
+				abs = PathSegment{Command: PathLineTo, Points: [3]Point{{X: cur.X, Y: y}}}
+			} else {
+				abs.Points[0] = Point{Y: y}
+			}
+			cur.Y = y
+
+		case PathCurveTo:
+			for i := range 3 {
+				abs.Points[i] = addPoint(seg.Points[i], base)
+			}
+			lastCtrl, cur = abs.Points[1], abs.Points[2]
+
+		case PathSmoothCurveTo:
+			c2, end := addPoint(seg.Points[0], base), addPoint(seg.Points[1], base)
+			if expand {
+				c1 := cur
+				if prev == PathCurveTo || prev == PathSmoothCurveTo {
+					c1 = reflectPoint(lastCtrl, cur)
+				}
+				abs = PathSegment{Command: PathCurveTo, Points: [3]Point{c1, c2, end}}
+			} else {
+				abs.Points[0], abs.Points[1] = c2, end
+			}
+			lastCtrl, cur = c2, end
+
+		case PathQuadraticCurveTo:
+			abs.Points[0], abs.Points[1] = addPoint(seg.Points[0], base), addPoint(seg.Points[1], base)
+			lastCtrl, cur = abs.Points[0], abs.Points[1]
+
+		case PathSmoothQuadraticCurveTo:
+			c1 := cur
+			if prev == PathQuadraticCurveTo || prev == PathSmoothQuadraticCurveTo {
+				c1 = reflectPoint(lastCtrl, cur)
+			}
+			end := addPoint(seg.Points[0], base)
+			if expand {
+				abs = PathSegment{Command: PathQuadraticCurveTo, Points: [3]Point{c1, end}}
+			} else {
+				abs.Points[0] = end
+			}
+			lastCtrl, cur = c1, end
+
+		case PathArcTo:
+			end := addPoint(seg.Points[0], base)
+			abs.Points[0] = end
+			if arcs {
+				for _, s := range arcToCubics(cur, seg.RX, seg.RY, seg.Rotation, seg.LargeArc, seg.Sweep, end) {
+					out = append(out, s.pathSegment())
+				}
+				cur = end
+				prev = seg.Command
+				continue
+			}
+			cur = end
+		}
+
+		out = append(out, abs)
+		prev = seg.Command
+	}
+	return out
+}
+
+// segments converts the path to the normalized segments used for
+// rasterizing and markers
+func (p PathData) segments() []pathSegment {
+	var segs []pathSegment
+	var cur, start Point
+	needMove := false
+
+	for _, seg := range p.normalize(true, false) {
+		var converted []pathSegment
+		switch seg.Command {
+		case PathMoveTo:
+			converted = []pathSegment{{Op: pathOpMoveTo, Pts: seg.Points}}
+			start = seg.Points[0]
+			needMove = false
+		case PathLineTo:
+			converted = []pathSegment{{Op: pathOpLineTo, Pts: seg.Points}}
+		case PathCurveTo:
+			converted = []pathSegment{{Op: pathOpCubeTo, Pts: seg.Points}}
+		case PathQuadraticCurveTo:
+			converted = []pathSegment{{Op: pathOpQuadTo, Pts: seg.Points}}
+		case PathArcTo:
+			converted = arcToCubics(cur, seg.RX, seg.RY, seg.Rotation, seg.LargeArc, seg.Sweep, seg.Points[0])
+		case PathClose:
+			converted = []pathSegment{{Op: pathOpClose}}
+		}
+
+		for _, s := range converted {
+			// A segment after a closepath starts at the subpath start
+			if needMove && s.Op != pathOpMoveTo {
+				segs = append(segs, pathSegment{Op: pathOpMoveTo, Pts: [3]Point{start}, Continuation: true})
+			}
+			needMove = false
+			segs = append(segs, s)
+		}
+		if seg.Command == PathClose {
+			cur = start
+			needMove = true
+		} else {
+			cur = seg.End()
+		}
+	}
+	return segs
+}
+
+// pathSegment converts a normalized segment to a PathSegment
+func (s pathSegment) pathSegment() PathSegment {
+	switch s.Op {
+	case pathOpMoveTo:
+		return PathSegment{Command: PathMoveTo, Points: s.Pts}
+	case pathOpLineTo:
+		return PathSegment{Command: PathLineTo, Points: s.Pts}
+	case pathOpQuadTo:
+		return PathSegment{Command: PathQuadraticCurveTo, Points: s.Pts}
+	case pathOpCubeTo:
+		return PathSegment{Command: PathCurveTo, Points: s.Pts}
+	}
+	return PathSegment{Command: PathClose}
+}
+
+func boolFlag(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
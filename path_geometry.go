@@ -0,0 +1,449 @@
+package svg
+
+import (
+	"math"
+
+	"github.com/SCKelemen/layout"
+)
+
+// Lengths are integrated with adaptive Simpson's rule on the speed of each
+// curve, subdividing until the estimate is within lengthTolerance.
+const (
+	lengthTolerance = 1e-9
+	lengthMinDepth  = 3
+	lengthMaxDepth  = 24
+)
+
+type curveKind int
+
+const (
+	curveLine curveKind = iota
+	curveQuad
+	curveCubic
+	curveArc
+)
+
+// curve is one drawn piece of a path in absolute coordinates: a line (or
+// closing line), a Bézier curve or an elliptical arc, parameterized over
+// t in [0, 1].
+type curve struct {
+	kind   curveKind
+	index  int      // index of the segment in the expanded path
+	pts    [4]Point // start point followed by the segment's points
+	arc    ellipticalArc
+	length float64
+}
+
+// curves returns the drawn pieces of an absolute path with expanded
+// shorthands. Zero-length arcs, which are omitted when rendering, are
+// skipped.
+func curves(p PathData) []curve {
+	var out []curve
+	var cur, start Point
+	for i, seg := range p {
+		c := curve{index: i, pts: [4]Point{cur, seg.Points[0], seg.Points[1], seg.Points[2]}}
+		switch seg.Command {
+		case PathMoveTo:
+			cur, start = seg.Points[0], seg.Points[0]
+			continue
+		case PathClose:
+			c.pts[1] = start
+		case PathQuadraticCurveTo:
+			c.kind = curveQuad
+		case PathCurveTo:
+			c.kind = curveCubic
+		case PathArcTo:
+			if cur == seg.Points[0] {
+				continue
+			}
+			var ok bool
+			if c.arc, ok = arcCenter(cur, seg.RX, seg.RY, seg.Rotation, seg.LargeArc, seg.Sweep, seg.Points[0]); ok {
+				c.kind = curveArc
+			}
+		}
+		c.length = c.lengthTo(1)
+		out = append(out, c)
+		cur = c.end()
+	}
+	return out
+}
+
+// geometry returns the path in the form the geometry methods work on
+func (p PathData) geometry() (PathData, []curve) {
+	q := p.Absolute().ExpandShorthands()
+	return q, curves(q)
+}
+
+func (c curve) end() Point {
+	switch c.kind {
+	case curveQuad:
+		return c.pts[2]
+	case curveCubic:
+		return c.pts[3]
+	}
+	return c.pts[1]
+}
+
+func (c curve) point(t float64) Point {
+	p := c.pts
+	switch c.kind {
+	case curveQuad:
+		return quadPoint(p[0], p[1], p[2], t)
+	case curveCubic:
+		return cubicPoint(p[0], p[1], p[2], p[3], t)
+	case curveArc:
+		return c.arc.point(c.arc.Theta1 + t*c.arc.DTheta)
+	}
+	return lerpPoint(p[0], p[1], t)
+}
+
+// derivative returns the derivative of the curve with respect to t
+func (c curve) derivative(t float64) Point {
+	p := c.pts
+	mt := 1 - t
+	switch c.kind {
+	case curveQuad:
+		return Point{
+			X: 2*mt*(p[1].X-p[0].X) + 2*t*(p[2].X-p[1].X),
+			Y: 2*mt*(p[1].Y-p[0].Y) + 2*t*(p[2].Y-p[1].Y),
+		}
+	case curveCubic:
+		a, b, d := 3*mt*mt, 6*mt*t, 3*t*t
+		return Point{
+			X: a*(p[1].X-p[0].X) + b*(p[2].X-p[1].X) + d*(p[3].X-p[2].X),
+			Y: a*(p[1].Y-p[0].Y) + b*(p[2].Y-p[1].Y) + d*(p[3].Y-p[2].Y),
+		}
+	case curveArc:
+		a := c.arc
+		sin, cos := math.Sincos(a.Theta1 + t*a.DTheta)
+		return Point{
+			X: a.DTheta * (-a.RX*a.Cos*sin - a.RY*a.Sin*cos),
+			Y: a.DTheta * (-a.RX*a.Sin*sin + a.RY*a.Cos*cos),
+		}
+	}
+	return Point{X: p[1].X - p[0].X, Y: p[1].Y - p[0].Y}
+}
+
+func (c curve) speed(t float64) float64 {
+	d := c.derivative(t)
+	return math.Hypot(d.X, d.Y)
+}
+
+// extrema returns the parameters in (0, 1) where the curve is horizontal
+// or vertical
+func (c curve) extrema() []float64 {
+	p := c.pts
+	switch c.kind {
+	case curveQuad:
+		return append(quadExtrema(p[0].X, p[1].X, p[2].X), quadExtrema(p[0].Y, p[1].Y, p[2].Y)...)
+	case curveCubic:
+		return append(cubicExtrema(p[0].X, p[1].X, p[2].X, p[3].X), cubicExtrema(p[0].Y, p[1].Y, p[2].Y, p[3].Y)...)
+	case curveArc:
+		// Angles where dx/dθ or dy/dθ is zero, each repeating every π
+		a := c.arc
+		var ts []float64
+		for _, theta := range []float64{
+			math.Atan2(-a.RY*a.Sin, a.RX*a.Cos),
+			math.Atan2(a.RY*a.Cos, a.RX*a.Sin),
+		} {
+			for k := -3.0; k <= 3; k++ {
+				if t := (theta + k*math.Pi - a.Theta1) / a.DTheta; t > 0 && t < 1 {
+					ts = append(ts, t)
+				}
+			}
+		}
+		return ts
+	}
+	return nil
+}
+
+// lengthTo returns the arc length of the curve from 0 to t
+func (c curve) lengthTo(t float64) float64 {
+	if t <= 0 {
+		return 0
+	}
+	if c.kind == curveLine {
+		return t * c.speed(0)
+	}
+	fa, fm, fb := c.speed(0), c.speed(t/2), c.speed(t)
+	return c.simpson(0, t, fa, fm, fb, t/6*(fa+4*fm+fb), lengthTolerance, 0)
+}
+
+// simpson refines the Simpson estimate whole of the length over [a, b]
+func (c curve) simpson(a, b, fa, fm, fb, whole, tol float64, depth int) float64 {
+	m := (a + b) / 2
+	flm, frm := c.speed((a+m)/2), c.speed((m+b)/2)
+	left := (m - a) / 6 * (fa + 4*flm + fm)
+	right := (b - m) / 6 * (fm + 4*frm + fb)
+	diff := left + right - whole
+	if depth >= lengthMaxDepth || (depth >= lengthMinDepth && math.Abs(diff) <= 15*tol) {
+		return left + right + diff/15
+	}
+	return c.simpson(a, m, fa, flm, fm, left, tol/2, depth+1) + c.simpson(m, b, fm, frm, fb, right, tol/2, depth+1)
+}
+
+// paramAt returns the t at which the arc length of the curve reaches length,
+// using Newton's method safeguarded by bisection
+func (c curve) paramAt(length float64) float64 {
+	if c.kind == curveLine || c.length == 0 {
+		return clamp01(length / math.Max(c.length, math.SmallestNonzeroFloat64))
+	}
+	lo, hi := 0.0, 1.0
+	t := length / c.length
+	for range 50 {
+		diff := c.lengthTo(t) - length
+		if math.Abs(diff) <= lengthTolerance*math.Max(1, c.length) {
+			break
+		}
+		if diff > 0 {
+			hi = t
+		} else {
+			lo = t
+		}
+		next := t - diff/c.speed(t)
+		if math.IsNaN(next) || next <= lo || next >= hi {
+			next = (lo + hi) / 2
+		}
+		t = next
+	}
+	return t
+}
+
+// tangent returns the unit direction of the curve at t. Where the
+// derivative vanishes, such as at a control point that coincides with an
+// end point, the direction is taken just inside the curve.
+func (c curve) tangent(t float64) Point {
+	for _, u := range []float64{t, t + 1e-6, t - 1e-6, 1, 0} {
+		if u < 0 || u > 1 {
+			continue
+		}
+		if d := c.derivative(u); d != (Point{}) {
+			n := math.Hypot(d.X, d.Y)
+			return Point{X: d.X / n, Y: d.Y / n}
+		}
+	}
+	end, start := c.end(), c.pts[0]
+	if n := math.Hypot(end.X-start.X, end.Y-start.Y); n > 0 {
+		return Point{X: (end.X - start.X) / n, Y: (end.Y - start.Y) / n}
+	}
+	return Point{}
+}
+
+// split returns the segments drawing the curve before and after t
+func (c curve) split(t float64, seg PathSegment) (PathSegment, PathSegment) {
+	p := c.pts
+	mid := c.point(t)
+	switch c.kind {
+	case curveQuad:
+		a, b := lerpPoint(p[0], p[1], t), lerpPoint(p[1], p[2], t)
+		return PathSegment{Command: PathQuadraticCurveTo, Points: [3]Point{a, mid}},
+			PathSegment{Command: PathQuadraticCurveTo, Points: [3]Point{b, p[2]}}
+	case curveCubic:
+		ab, bc, cd := lerpPoint(p[0], p[1], t), lerpPoint(p[1], p[2], t), lerpPoint(p[2], p[3], t)
+		abc, bcd := lerpPoint(ab, bc, t), lerpPoint(bc, cd, t)
+		return PathSegment{Command: PathCurveTo, Points: [3]Point{ab, abc, mid}},
+			PathSegment{Command: PathCurveTo, Points: [3]Point{bcd, cd, p[3]}}
+	case curveArc:
+		first, second := seg, seg
+		first.RX, first.RY = c.arc.RX, c.arc.RY
+		second.RX, second.RY = c.arc.RX, c.arc.RY
+		first.Points[0] = mid
+		first.LargeArc = math.Abs(t*c.arc.DTheta) > math.Pi
+		second.LargeArc = math.Abs((1-t)*c.arc.DTheta) > math.Pi
+		return first, second
+	}
+	return PathSegment{Command: PathLineTo, Points: [3]Point{mid}},
+		PathSegment{Command: PathLineTo, Points: [3]Point{p[1]}}
+}
+
+// locate returns the curve containing the given distance along the path and
+// the parameter of that point. It reports false if nothing is drawn.
+func locate(cs []curve, length float64) (curve, float64, bool) {
+	if len(cs) == 0 {
+		return curve{}, 0, false
+	}
+	for _, c := range cs {
+		if length <= c.length {
+			return c, c.paramAt(math.Max(length, 0)), true
+		}
+		length -= c.length
+	}
+	return cs[len(cs)-1], 1, true
+}
+
+// Bounds returns the exact bounding box of the path: curves contribute
+// their extrema rather than their control points and arcs are measured on
+// the ellipse. It reports false for an empty path.
+func (p PathData) Bounds() (layout.Rect, bool) {
+	q, cs := p.geometry()
+	box := boundingBox{}
+	found := false
+	add := func(pt Point) {
+		if !found {
+			box = boundingBox{Min: pt, Max: pt}
+			found = true
+			return
+		}
+		box.Min.X = math.Min(box.Min.X, pt.X)
+		box.Min.Y = math.Min(box.Min.Y, pt.Y)
+		box.Max.X = math.Max(box.Max.X, pt.X)
+		box.Max.Y = math.Max(box.Max.Y, pt.Y)
+	}
+
+	for _, seg := range q {
+		if seg.Command == PathMoveTo {
+			add(seg.Points[0])
+		}
+	}
+	for _, c := range cs {
+		add(c.pts[0])
+		add(c.end())
+		for _, t := range c.extrema() {
+			add(c.point(t))
+		}
+	}
+	return layout.Rect{X: box.Min.X, Y: box.Min.Y, Width: box.width(), Height: box.height()}, found
+}
+
+// Length returns the total length of the path, including closing lines
+func (p PathData) Length() float64 {
+	_, cs := p.geometry()
+	total := 0.0
+	for _, c := range cs {
+		total += c.length
+	}
+	return total
+}
+
+// PointAtLength returns the point at the given distance along the path,
+// like SVGGeometryElement.getPointAtLength. The distance is clamped to the
+// path. A path that draws nothing returns its first point.
+func (p PathData) PointAtLength(length float64) Point {
+	q, cs := p.geometry()
+	c, t, ok := locate(cs, length)
+	if !ok {
+		if len(q) > 0 {
+			return q[0].End()
+		}
+		return Point{}
+	}
+	return c.point(t)
+}
+
+// TangentAtLength returns the unit direction of the path at the given
+// distance along it; math.Atan2(t.Y, t.X) gives the angle. It returns the
+// zero vector for a path that draws nothing.
+func (p PathData) TangentAtLength(length float64) Point {
+	_, cs := p.geometry()
+	c, t, ok := locate(cs, length)
+	if !ok {
+		return Point{}
+	}
+	return c.tangent(t)
+}
+
+// SplitAtLength splits the path at the given distance along it. Both parts
+// are absolute with expanded shorthands; the second starts with a moveto
+// at the split point, and closepaths that would return to the start of a
+// subpath in the first part are replaced by lines to that start.
+func (p PathData) SplitAtLength(length float64) (PathData, PathData) {
+	q, cs := p.geometry()
+	total := 0.0
+	for _, c := range cs {
+		total += c.length
+	}
+	if length <= 0 || len(cs) == 0 {
+		return PathData{}, q
+	}
+	if length >= total {
+		return q, PathData{}
+	}
+
+	c, t, _ := locate(cs, length)
+	before, after := c.split(t, q[c.index])
+
+	// The start of the subpath being split, where its closepaths return
+	var start Point
+	for _, seg := range q[:c.index] {
+		if seg.Command == PathMoveTo {
+			start = seg.Points[0]
+		}
+	}
+
+	first := append(append(PathData{}, q[:c.index]...), before)
+	second := PathData{{Command: PathMoveTo, Points: [3]Point{before.End()}}, after}
+	open := true
+	for _, seg := range q[c.index+1:] {
+		if seg.Command == PathMoveTo {
+			open = false
+		}
+		if open && seg.Command == PathClose {
+			seg = PathSegment{Command: PathLineTo, Points: [3]Point{start}}
+		}
+		second = append(second, seg)
+	}
+	return first, second
+}
+
+// Transform returns the path with m applied to its coordinates. The result
+// is absolute and horizontal and vertical lines become lines. Arcs stay
+// arcs: their radii and rotation are those of the transformed ellipse, and
+// the sweep flag flips when m mirrors.
+func (p PathData) Transform(m Matrix) PathData {
+	out := make(PathData, 0, len(p))
+	var cur, start Point
+	for _, seg := range p.Absolute() {
+		switch seg.Command {
+		case PathHorizontalLineTo:
+			seg = PathSegment{Command: PathLineTo, Points: [3]Point{{X: seg.Points[0].X, Y: cur.Y}}}
+		case PathVerticalLineTo:
+			seg = PathSegment{Command: PathLineTo, Points: [3]Point{{X: cur.X, Y: seg.Points[0].Y}}}
+		case PathArcTo:
+			if seg.RX != 0 && seg.RY != 0 {
+				seg.RX, seg.RY, seg.Rotation = transformEllipse(m, seg.RX, seg.RY, seg.Rotation)
+				if m.Determinant() < 0 {
+					seg.Sweep = !seg.Sweep
+				}
+			}
+		}
+
+		switch seg.Command {
+		case PathClose:
+			cur = start
+		case PathMoveTo:
+			cur, start = seg.Points[0], seg.Points[0]
+		default:
+			cur = seg.End()
+		}
+
+		for i := range seg.Command.pointCount() {
+			seg.Points[i] = m.Apply(seg.Points[i])
+		}
+		out = append(out, seg)
+	}
+	return out
+}
+
+// transformEllipse returns the radii and rotation in degrees of the ellipse
+// with radii rx, ry rotated by rotation degrees after applying the linear
+// part of m. The radii are the singular values of m·R(rotation)·S(rx, ry).
+func transformEllipse(m Matrix, rx, ry, rotation float64) (float64, float64, float64) {
+	sin, cos := math.Sincos(rotation * math.Pi / 180)
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	// Images of the ellipse's axes
+	u := Point{X: rx * (m.A*cos + m.C*sin), Y: rx * (m.B*cos + m.D*sin)}
+	v := Point{X: ry * (-m.A*sin + m.C*cos), Y: ry * (-m.B*sin + m.D*cos)}
+
+	// Eigenvalues of the symmetric matrix [[p q] [q r]] = T·Tᵀ
+	p := u.X*u.X + v.X*v.X
+	q := u.X*u.Y + v.X*v.Y
+	r := u.Y*u.Y + v.Y*v.Y
+	mean, diff := (p+r)/2, math.Hypot((p-r)/2, q)
+	major := math.Sqrt(mean + diff)
+	minor := math.Sqrt(math.Max(0, mean-diff))
+	if diff <= 1e-12*mean {
+		// A circle: any rotation describes it
+		return major, major, 0
+	}
+	return major, minor, math.Atan2(2*q, p-r) / 2 * 180 / math.Pi
+}
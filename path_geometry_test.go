@@ -0,0 +1,145 @@
+package svg
+
+import (
+	"math"
+	"testing"
+
+	"github.com/SCKelemen/layout"
+)
+
+func mustParsePath(t *testing.T, d string) PathData {
+	t.Helper()
+	path, err := ParsePath(d)
+	if err != nil {
+		t.Fatalf("ParsePath(%q): %v", d, err)
+	}
+	return path
+}
+
+func nearPoint(a, b Point) bool {
+	return math.Abs(a.X-b.X) < 1e-6 && math.Abs(a.Y-b.Y) < 1e-6
+}
+
+func TestPathData_Bounds(t *testing.T) {
+	tests := []struct {
+		d    string
+		want layout.Rect
+	}{
+		{"M 10 20 h 30 v 40 z", layout.Rect{X: 10, Y: 20, Width: 30, Height: 40}},
+		// The curve peaks at y = -7.5, well inside its control points
+		{"M 0 0 C 0 -10 20 -10 20 0", layout.Rect{X: 0, Y: -7.5, Width: 20, Height: 7.5}},
+		{"M 0 0 Q 10 10 20 0", layout.Rect{X: 0, Y: 0, Width: 20, Height: 5}},
+		{"M 0 0 A 10 10 0 0 1 20 0", layout.Rect{X: 0, Y: -10, Width: 20, Height: 10}},
+		{"M 0 0 A 20 10 90 0 1 0 40", layout.Rect{X: 0, Y: 0, Width: 10, Height: 40}}, // rotated
+		// Radii too small to span the endpoints are scaled up
+		{"M 0 0 a 1 1 0 0 0 20 0", layout.Rect{X: 0, Y: 0, Width: 20, Height: 10}},
+	}
+	for _, tt := range tests {
+		got, ok := mustParsePath(t, tt.d).Bounds()
+		if !ok {
+			t.Errorf("%s: expected bounds", tt.d)
+			continue
+		}
+		if math.Abs(got.X-tt.want.X) > 1e-3 || math.Abs(got.Y-tt.want.Y) > 1e-3 ||
+			math.Abs(got.Width-tt.want.Width) > 1e-3 || math.Abs(got.Height-tt.want.Height) > 1e-3 {
+			t.Errorf("%s: expected %+v, got %+v", tt.d, tt.want, got)
+		}
+	}
+	if _, ok := (PathData{}).Bounds(); ok {
+		t.Error("an empty path has no bounds")
+	}
+}
+
+func TestPathData_Length(t *testing.T) {
+	tests := []struct {
+		d    string
+		want float64
+	}{
+		{"M 0 0 h 10 v 10 h -10 z", 40},
+		{"M 0 0 L 3 4 M 10 10 l 0 5", 10},
+		{"M 0 10 A 10 10 0 1 1 20 10 A 10 10 0 1 1 0 10", 20 * math.Pi},
+		{"M 0 0 A 20 10 0 0 1 40 0", 48.44224110273838}, // half an ellipse
+		{"M 0 0 Q 5 0 10 0", 10},
+		{"M 0 0 C 10 0 0 0 10 0", 10},
+		{"M 0 0 A 10 10 0 0 1 0 0", 0},
+	}
+	for _, tt := range tests {
+		if got := mustParsePath(t, tt.d).Length(); math.Abs(got-tt.want) > 1e-6 {
+			t.Errorf("%s: expected length %v, got %v", tt.d, tt.want, got)
+		}
+	}
+}
+
+func TestPathData_PointAndTangentAtLength(t *testing.T) {
+	square := mustParsePath(t, "M 0 0 h 10 v 10 h -10 z")
+	tests := []struct {
+		length         float64
+		point, tangent Point
+	}{
+		{-5, Point{0, 0}, Point{1, 0}},
+		{5, Point{5, 0}, Point{1, 0}},
+		{15, Point{10, 5}, Point{0, 1}},
+		{35, Point{0, 5}, Point{0, -1}}, // on the closing line
+		{100, Point{0, 0}, Point{0, -1}},
+	}
+	for _, tt := range tests {
+		if got := square.PointAtLength(tt.length); !nearPoint(got, tt.point) {
+			t.Errorf("PointAtLength(%v): expected %v, got %v", tt.length, tt.point, got)
+		}
+		if got := square.TangentAtLength(tt.length); !nearPoint(got, tt.tangent) {
+			t.Errorf("TangentAtLength(%v): expected %v, got %v", tt.length, tt.tangent, got)
+		}
+	}
+
+	arc := mustParsePath(t, "M 0 0 A 10 10 0 0 1 20 0")
+	if got := arc.PointAtLength(5 * math.Pi); !nearPoint(got, Point{10, -10}) {
+		t.Errorf("expected the top of the arc, got %v", got)
+	}
+	if got := arc.TangentAtLength(5 * math.Pi); !nearPoint(got, Point{1, 0}) {
+		t.Errorf("expected a horizontal tangent at the top of the arc, got %v", got)
+	}
+
+	// A curve whose speed varies: the point halfway along is not t = 0.5
+	curve := mustParsePath(t, "M 0 0 C 0 0 0 0 100 0")
+	if got := curve.PointAtLength(50); !nearPoint(got, Point{50, 0}) {
+		t.Errorf("expected (50, 0), got %v", got)
+	}
+	// The derivative vanishes at the start; the tangent is taken just inside
+	if got := curve.TangentAtLength(0); !nearPoint(got, Point{1, 0}) {
+		t.Errorf("expected a tangent along the curve, got %v", got)
+	}
+}
+
+func TestPathData_SplitAtLength(t *testing.T) {
+	square := mustParsePath(t, "M 0 0 h 10 v 10 h -10 z M 20 0 h 5")
+	first, second := square.SplitAtLength(15)
+	if got := first.String(); got != "M 0.00 0.00 L 10.00 0.00 L 10.00 5.00" {
+		t.Errorf("unexpected first part: %s", got)
+	}
+	// The closepath would return to the new moveto, so it becomes a line
+	if got := second.String(); got != "M 10.00 5.00 L 10.00 10.00 L 0.00 10.00 L 0.00 0.00 M 20.00 0.00 L 25.00 0.00" {
+		t.Errorf("unexpected second part: %s", got)
+	}
+
+	path := mustParsePath(t, "M 0 0 C 0 -10 20 -10 20 0 A 10 10 0 0 0 40 0 q 10 10 20 0")
+	total := path.Length()
+	for _, at := range []float64{0.25, 0.5, 0.75} {
+		first, second := path.SplitAtLength(at * total)
+		if l := first.Length(); math.Abs(l-at*total) > 1e-6 {
+			t.Errorf("split at %v: expected the first part to be %v long, got %v", at, at*total, l)
+		}
+		if l := second.Length(); math.Abs(l-(1-at)*total) > 1e-6 {
+			t.Errorf("split at %v: expected the second part to be %v long, got %v", at, (1-at)*total, l)
+		}
+		if end, start := first[len(first)-1].End(), second[0].End(); !nearPoint(end, start) || !nearPoint(end, path.PointAtLength(at*total)) {
+			t.Errorf("split at %v: parts should meet at the split point, got %v and %v", at, end, start)
+		}
+	}
+
+	if first, second := path.SplitAtLength(0); len(first) != 0 || len(second) != len(path) {
+		t.Errorf("splitting at 0 should leave the whole path in the second part")
+	}
+	if first, second := path.SplitAtLength(total + 1); len(first) != len(path) || len(second) != 0 {
+		t.Errorf("splitting past the end should leave the whole path in the first part")
+	}
+}
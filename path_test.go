@@ -0,0 +1,117 @@
+package svg
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParsePath_KeepsCommands(t *testing.T) {
+	path, err := ParsePath("M10 10h5v5l-5 0 1 1c1,1 2,2 3,3s4 4 5 5q1 1 2 2t3 3a5 5 0 1 0 10 0z")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got strings.Builder
+	for _, seg := range path {
+		got.WriteByte(seg.letter())
+	}
+	if got.String() != "Mhvllcsqtaz" {
+		t.Errorf("expected commands Mhvllcsqtaz, got %s", got.String())
+	}
+
+	arc := path[9]
+	if arc.RX != 5 || arc.RY != 5 || !arc.LargeArc || arc.Sweep || arc.End() != (Point{X: 10, Y: 0}) {
+		t.Errorf("unexpected arc segment %+v", arc)
+	}
+	if path[1].Points[0].X != 5 || path[2].Points[0].Y != 5 {
+		t.Errorf("unexpected H/V segments %+v %+v", path[1], path[2])
+	}
+}
+
+func TestParsePath_RoundTrip(t *testing.T) {
+	for _, d := range []string{
+		"M 10.00 20.00 L 30.00 40.00 Z",
+		"m 1.00 2.00 h 3.00 v 4.00 c 1.00 1.00, 2.00 2.00, 3.00 3.00 a 5.00 5.00 0.00 1 0 10.00 0.00 Z",
+		SmoothLinePath([]Point{{0, 0}, {10, 5}, {20, 0}}, 0.3),
+	} {
+		path, err := ParsePath(d)
+		if err != nil {
+			t.Fatalf("%s: %v", d, err)
+		}
+		if got := path.String(); got != d {
+			t.Errorf("round trip:\n got %s\nwant %s", got, d)
+		}
+		pb := path.Builder()
+		again, err := pb.PathData()
+		if err != nil || again.String() != d {
+			t.Errorf("PathBuilder round trip failed: %v %s", err, again)
+		}
+	}
+}
+
+func TestParsePath_Errors(t *testing.T) {
+	path, err := ParsePath("M 0 0 L 10 10 L 5")
+	if err == nil {
+		t.Fatal("expected an error for a truncated segment")
+	}
+	if len(path) != 2 {
+		t.Errorf("expected the segments before the error, got %d", len(path))
+	}
+	if _, err := ParsePath("L 0 0"); err == nil {
+		t.Error("expected an error for path data without a moveto")
+	}
+}
+
+func TestPathData_Normalization(t *testing.T) {
+	path, err := ParsePath("m 10 10 h 10 v 10 s 5 5 10 0 t 10 0 z l 5 5")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	abs := path.Absolute()
+	if got := abs.String(); got != "M 10.00 10.00 H 20.00 V 20.00 S 25.00 25.00, 30.00 20.00 T 40.00 20.00 Z L 15.00 15.00" {
+		t.Errorf("Absolute: %s", got)
+	}
+
+	expanded := path.ExpandShorthands()
+	want := "M 10.00 10.00 L 20.00 10.00 L 20.00 20.00 C 20.00 20.00, 25.00 25.00, 30.00 20.00 Q 30.00 20.00, 40.00 20.00 Z L 15.00 15.00"
+	if got := expanded.String(); got != want {
+		t.Errorf("ExpandShorthands:\n got %s\nwant %s", got, want)
+	}
+
+	// S and T reflect the previous control point
+	smooth, _ := ParsePath("M 0 0 C 0 10 10 10 10 0 S 20 -10 20 0 Q 25 10 30 0 T 40 0")
+	norm := smooth.ExpandShorthands()
+	if c1 := norm[2].Points[0]; c1 != (Point{X: 10, Y: -10}) {
+		t.Errorf("expected reflected cubic control point, got %v", c1)
+	}
+	if c1 := norm[4].Points[0]; c1 != (Point{X: 35, Y: -10}) {
+		t.Errorf("expected reflected quadratic control point, got %v", c1)
+	}
+}
+
+func TestPathData_ArcsToCubics(t *testing.T) {
+	path, err := ParsePath(CirclePath(50, 50, 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cubics := path.Normalize()
+	for _, seg := range cubics {
+		switch seg.Command {
+		case PathMoveTo, PathCurveTo, PathClose:
+		default:
+			t.Fatalf("unexpected %c segment after Normalize", seg.Command)
+		}
+		if seg.Command != PathCurveTo {
+			continue
+		}
+		// Every end point lies on the circle
+		if r := math.Hypot(seg.End().X-50, seg.End().Y-50); math.Abs(r-20) > 1e-9 {
+			t.Errorf("end point %v is %.6f from the center", seg.End(), r)
+		}
+	}
+	if n := len(cubics); n != 6 {
+		t.Errorf("expected a moveto, four quarter cubics and a close, got %d segments", n)
+	}
+}
@@ -2,12 +2,22 @@ package svg
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
-// PathBuilder provides a fluent API for constructing SVG path data
+// PathBuilder provides a fluent API for constructing SVG path data.
+// It tracks the current point and the start of the current subpath, so
+// absolute and relative commands can be mixed freely.
 type PathBuilder struct {
 	commands strings.Builder
+	format   NumberFormat
+	shortest bool
+	cur      Point // current point
+	start    Point // start of the current subpath
+	// The current point and subpath start as a reader of the written,
+	// rounded path data reconstructs them
+	written, writtenStart Point
 }
 
 // Point represents a 2D point
@@ -20,52 +30,67 @@ func NewPathBuilder() *PathBuilder {
 	return &PathBuilder{}
 }
 
+// SetNumberFormat sets how coordinates are written by subsequent commands
+func (pb *PathBuilder) SetNumberFormat(format NumberFormat) *PathBuilder {
+	pb.format = format
+	return pb
+}
+
+// SetShortest makes subsequent commands write each segment absolute or
+// relative, whichever encoding is shorter. The geometry is unchanged.
+func (pb *PathBuilder) SetShortest(shortest bool) *PathBuilder {
+	pb.shortest = shortest
+	return pb
+}
+
+// CurrentPoint returns the end point of the last command
+func (pb *PathBuilder) CurrentPoint() Point {
+	return pb.cur
+}
+
+// SubpathStart returns the point of the last moveto, where Close returns to
+func (pb *PathBuilder) SubpathStart() Point {
+	return pb.start
+}
+
 // MoveTo moves the pen to the specified point without drawing
 func (pb *PathBuilder) MoveTo(x, y float64) *PathBuilder {
-	fmt.Fprintf(&pb.commands, "M %.2f %.2f ", x, y)
-	return pb
+	return pb.emit(PathSegment{Command: PathMoveTo, Points: [3]Point{{x, y}}})
 }
 
 // LineTo draws a line from the current point to the specified point
 func (pb *PathBuilder) LineTo(x, y float64) *PathBuilder {
-	fmt.Fprintf(&pb.commands, "L %.2f %.2f ", x, y)
-	return pb
+	return pb.emit(PathSegment{Command: PathLineTo, Points: [3]Point{{x, y}}})
 }
 
 // HorizontalLineTo draws a horizontal line to the specified x coordinate
 func (pb *PathBuilder) HorizontalLineTo(x float64) *PathBuilder {
-	fmt.Fprintf(&pb.commands, "H %.2f ", x)
-	return pb
+	return pb.emit(PathSegment{Command: PathHorizontalLineTo, Points: [3]Point{{X: x}}})
 }
 
 // VerticalLineTo draws a vertical line to the specified y coordinate
 func (pb *PathBuilder) VerticalLineTo(y float64) *PathBuilder {
-	fmt.Fprintf(&pb.commands, "V %.2f ", y)
-	return pb
+	return pb.emit(PathSegment{Command: PathVerticalLineTo, Points: [3]Point{{Y: y}}})
 }
 
 // CurveTo draws a cubic Bézier curve
 func (pb *PathBuilder) CurveTo(x1, y1, x2, y2, x, y float64) *PathBuilder {
-	fmt.Fprintf(&pb.commands, "C %.2f %.2f, %.2f %.2f, %.2f %.2f ", x1, y1, x2, y2, x, y)
-	return pb
+	return pb.emit(PathSegment{Command: PathCurveTo, Points: [3]Point{{x1, y1}, {x2, y2}, {x, y}}})
 }
 
 // SmoothCurveTo draws a smooth cubic Bézier curve (first control point is reflection of previous)
 func (pb *PathBuilder) SmoothCurveTo(x2, y2, x, y float64) *PathBuilder {
-	fmt.Fprintf(&pb.commands, "S %.2f %.2f, %.2f %.2f ", x2, y2, x, y)
-	return pb
+	return pb.emit(PathSegment{Command: PathSmoothCurveTo, Points: [3]Point{{x2, y2}, {x, y}}})
 }
 
 // QuadraticCurveTo draws a quadratic Bézier curve
 func (pb *PathBuilder) QuadraticCurveTo(x1, y1, x, y float64) *PathBuilder {
-	fmt.Fprintf(&pb.commands, "Q %.2f %.2f, %.2f %.2f ", x1, y1, x, y)
-	return pb
+	return pb.emit(PathSegment{Command: PathQuadraticCurveTo, Points: [3]Point{{x1, y1}, {x, y}}})
 }
 
 // SmoothQuadraticCurveTo draws a smooth quadratic Bézier curve
 func (pb *PathBuilder) SmoothQuadraticCurveTo(x, y float64) *PathBuilder {
-	fmt.Fprintf(&pb.commands, "T %.2f %.2f ", x, y)
-	return pb
+	return pb.emit(PathSegment{Command: PathSmoothQuadraticCurveTo, Points: [3]Point{{x, y}}})
 }
 
 // ArcTo draws an elliptical arc
@@ -75,16 +100,155 @@ func (pb *PathBuilder) SmoothQuadraticCurveTo(x, y float64) *PathBuilder {
 // sweepFlag: 0 for counter-clockwise, 1 for clockwise
 // x, y: end point
 func (pb *PathBuilder) ArcTo(rx, ry, xAxisRotation float64, largeArcFlag, sweepFlag int, x, y float64) *PathBuilder {
-	fmt.Fprintf(&pb.commands, "A %.2f %.2f %.2f %d %d %.2f %.2f ", rx, ry, xAxisRotation, largeArcFlag, sweepFlag, x, y)
-	return pb
+	return pb.emit(arcSegment(rx, ry, xAxisRotation, largeArcFlag, sweepFlag, x, y, false))
+}
+
+// Relative commands take coordinates relative to the current point
+
+// RelMoveTo moves the pen by dx, dy without drawing (m)
+func (pb *PathBuilder) RelMoveTo(dx, dy float64) *PathBuilder {
+	return pb.emit(PathSegment{Command: PathMoveTo, Relative: true, Points: [3]Point{{dx, dy}}})
+}
+
+// RelLineTo draws a line by dx, dy (l)
+func (pb *PathBuilder) RelLineTo(dx, dy float64) *PathBuilder {
+	return pb.emit(PathSegment{Command: PathLineTo, Relative: true, Points: [3]Point{{dx, dy}}})
+}
+
+// RelHorizontalLineTo draws a horizontal line by dx (h)
+func (pb *PathBuilder) RelHorizontalLineTo(dx float64) *PathBuilder {
+	return pb.emit(PathSegment{Command: PathHorizontalLineTo, Relative: true, Points: [3]Point{{X: dx}}})
+}
+
+// RelVerticalLineTo draws a vertical line by dy (v)
+func (pb *PathBuilder) RelVerticalLineTo(dy float64) *PathBuilder {
+	return pb.emit(PathSegment{Command: PathVerticalLineTo, Relative: true, Points: [3]Point{{Y: dy}}})
+}
+
+// RelCurveTo draws a cubic Bézier curve with relative control and end points (c)
+func (pb *PathBuilder) RelCurveTo(dx1, dy1, dx2, dy2, dx, dy float64) *PathBuilder {
+	return pb.emit(PathSegment{Command: PathCurveTo, Relative: true, Points: [3]Point{{dx1, dy1}, {dx2, dy2}, {dx, dy}}})
+}
+
+// RelSmoothCurveTo draws a smooth cubic Bézier curve with relative points (s)
+func (pb *PathBuilder) RelSmoothCurveTo(dx2, dy2, dx, dy float64) *PathBuilder {
+	return pb.emit(PathSegment{Command: PathSmoothCurveTo, Relative: true, Points: [3]Point{{dx2, dy2}, {dx, dy}}})
+}
+
+// RelQuadraticCurveTo draws a quadratic Bézier curve with relative points (q)
+func (pb *PathBuilder) RelQuadraticCurveTo(dx1, dy1, dx, dy float64) *PathBuilder {
+	return pb.emit(PathSegment{Command: PathQuadraticCurveTo, Relative: true, Points: [3]Point{{dx1, dy1}, {dx, dy}}})
+}
+
+// RelSmoothQuadraticCurveTo draws a smooth quadratic Bézier curve by dx, dy (t)
+func (pb *PathBuilder) RelSmoothQuadraticCurveTo(dx, dy float64) *PathBuilder {
+	return pb.emit(PathSegment{Command: PathSmoothQuadraticCurveTo, Relative: true, Points: [3]Point{{dx, dy}}})
+}
+
+// RelArcTo draws an elliptical arc ending at dx, dy from the current point (a)
+func (pb *PathBuilder) RelArcTo(rx, ry, xAxisRotation float64, largeArcFlag, sweepFlag int, dx, dy float64) *PathBuilder {
+	return pb.emit(arcSegment(rx, ry, xAxisRotation, largeArcFlag, sweepFlag, dx, dy, true))
 }
 
 // Close closes the current path by drawing a line back to the first point
 func (pb *PathBuilder) Close() *PathBuilder {
-	pb.commands.WriteString("Z ")
+	return pb.emit(PathSegment{Command: PathClose})
+}
+
+func arcSegment(rx, ry, rotation float64, largeArcFlag, sweepFlag int, x, y float64, relative bool) PathSegment {
+	return PathSegment{
+		Command: PathArcTo, Relative: relative, Points: [3]Point{{x, y}},
+		RX: rx, RY: ry, Rotation: rotation, LargeArc: largeArcFlag != 0, Sweep: sweepFlag != 0,
+	}
+}
+
+// emit writes a segment and advances the current point. In shortest mode
+// the segment is written absolute or relative, whichever is shorter.
+// Relative coordinates are measured between the rounded points a reader
+// reconstructs, so rounding errors do not add up.
+func (pb *PathBuilder) emit(seg PathSegment) *PathBuilder {
+	abs := seg.absolute(pb.cur)
+	out := seg
+	if pb.shortest && seg.Command != PathClose {
+		out = abs
+		// The deltas between rounded values read back to the same points
+		if rel := pb.round(abs).relative(pb.written); len(pb.formatSegment(rel)) < len(pb.formatSegment(abs)) {
+			out = rel
+		}
+	}
+	pb.commands.WriteString(pb.formatSegment(out))
+
+	pb.cur, pb.start = advance(pb.cur, pb.start, abs)
+	pb.written, pb.writtenStart = advance(pb.written, pb.writtenStart, pb.round(out).absolute(pb.written))
 	return pb
 }
 
+// advance returns the current point and subpath start after the absolute
+// segment seg
+func advance(cur, start Point, seg PathSegment) (Point, Point) {
+	switch seg.Command {
+	case PathClose:
+		return start, start
+	case PathMoveTo:
+		return seg.Points[0], seg.Points[0]
+	case PathHorizontalLineTo:
+		cur.X = seg.Points[0].X
+	case PathVerticalLineTo:
+		cur.Y = seg.Points[0].Y
+	default:
+		cur = seg.End()
+	}
+	return cur, start
+}
+
+// round returns seg with its points as they read back after formatting
+func (pb *PathBuilder) round(seg PathSegment) PathSegment {
+	for i := range seg.Command.pointCount() {
+		seg.Points[i] = Point{X: pb.roundNum(seg.Points[i].X), Y: pb.roundNum(seg.Points[i].Y)}
+	}
+	return seg
+}
+
+func (pb *PathBuilder) roundNum(v float64) float64 {
+	r, err := strconv.ParseFloat(pb.num(v), 64)
+	if err != nil {
+		return v
+	}
+	return r
+}
+
+// formatSegment writes a segment as path data followed by a space
+func (pb *PathBuilder) formatSegment(seg PathSegment) string {
+	letter := seg.letter()
+	switch seg.Command {
+	case PathClose:
+		return string(letter) + " "
+	case PathHorizontalLineTo:
+		return fmt.Sprintf("%c %s ", letter, pb.num(seg.Points[0].X))
+	case PathVerticalLineTo:
+		return fmt.Sprintf("%c %s ", letter, pb.num(seg.Points[0].Y))
+	case PathArcTo:
+		return fmt.Sprintf("%c %s %s %s %d %d %s %s ", letter, pb.num(seg.RX), pb.num(seg.RY), pb.num(seg.Rotation),
+			boolFlag(seg.LargeArc), boolFlag(seg.Sweep), pb.num(seg.Points[0].X), pb.num(seg.Points[0].Y))
+	}
+
+	// Coordinate pairs are separated by commas
+	var b strings.Builder
+	b.WriteByte(letter)
+	for i, p := range seg.Points[:seg.Command.pointCount()] {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, " %s %s", pb.num(p.X), pb.num(p.Y))
+	}
+	b.WriteByte(' ')
+	return b.String()
+}
+
+func (pb *PathBuilder) num(v float64) string {
+	return pb.format.Format(v)
+}
+
 // String returns the path data string
 func (pb *PathBuilder) String() string {
 	return strings.TrimSpace(pb.commands.String())
@@ -98,6 +262,8 @@ func (pb *PathBuilder) Build() string {
 // Reset clears the path builder for reuse
 func (pb *PathBuilder) Reset() *PathBuilder {
 	pb.commands.Reset()
+	pb.cur, pb.start = Point{}, Point{}
+	pb.written, pb.writtenStart = Point{}, Point{}
 	return pb
 }
 
@@ -135,6 +301,103 @@ func RoundedRectPath(x, y, width, height, rx, ry float64) string {
 	return pb.String()
 }
 
+// CornerRadii are the radii of the four corners of a rectangle
+type CornerRadii struct {
+	TopLeft, TopRight, BottomRight, BottomLeft float64
+}
+
+// UniformRadii returns corner radii with the same radius at every corner
+func UniformRadii(r float64) CornerRadii {
+	return CornerRadii{TopLeft: r, TopRight: r, BottomRight: r, BottomLeft: r}
+}
+
+// IsZero reports whether every corner is square
+func (c CornerRadii) IsZero() bool {
+	return c.TopLeft <= 0 && c.TopRight <= 0 && c.BottomRight <= 0 && c.BottomLeft <= 0
+}
+
+// RoundedRectCornersPath creates a rectangular path with a separate radius
+// for each corner. Radii that do not fit are scaled down together, as CSS
+// does for border-radius.
+func RoundedRectCornersPath(x, y, width, height float64, radii CornerRadii) string {
+	pb := NewPathBuilder()
+	appendRoundedRect(pb, x, y, width, height, clampCorners(width, height, radii.corners()), true)
+	return pb.String()
+}
+
+// corners returns the radii as (rx, ry) pairs: top-left, top-right,
+// bottom-right, bottom-left
+func (c CornerRadii) corners() [4]Point {
+	return [4]Point{
+		{max(c.TopLeft, 0), max(c.TopLeft, 0)},
+		{max(c.TopRight, 0), max(c.TopRight, 0)},
+		{max(c.BottomRight, 0), max(c.BottomRight, 0)},
+		{max(c.BottomLeft, 0), max(c.BottomLeft, 0)},
+	}
+}
+
+// clampCorners scales corner radii down so that adjacent corners do not
+// overlap (CSS Backgrounds 3 §5.5)
+func clampCorners(width, height float64, c [4]Point) [4]Point {
+	f := 1.0
+	for _, fit := range [][3]float64{
+		{width, c[0].X, c[1].X},  // top
+		{width, c[3].X, c[2].X},  // bottom
+		{height, c[0].Y, c[3].Y}, // left
+		{height, c[1].Y, c[2].Y}, // right
+	} {
+		if sum := fit[1] + fit[2]; sum > 0 {
+			f = min(f, fit[0]/sum)
+		}
+	}
+	if f < 1 {
+		for i := range c {
+			c[i].X *= max(f, 0)
+			c[i].Y *= max(f, 0)
+		}
+	}
+	return c
+}
+
+// appendRoundedRect adds a closed rectangle with elliptical corners c
+// (top-left, top-right, bottom-right, bottom-left) to pb, drawn clockwise
+// or counter-clockwise
+func appendRoundedRect(pb *PathBuilder, x, y, w, h float64, c [4]Point, clockwise bool) {
+	for i := range c {
+		// A corner with a zero radius on either axis is square
+		if c[i].X <= 0 || c[i].Y <= 0 {
+			c[i] = Point{}
+		}
+	}
+	// Square corners need no segment: the next line starts at the corner
+	arc := func(r Point, sweep int, ex, ey float64) {
+		if r.X > 0 {
+			pb.ArcTo(r.X, r.Y, 0, 0, sweep, ex, ey)
+		}
+	}
+
+	pb.MoveTo(x+c[0].X, y)
+	if clockwise {
+		pb.HorizontalLineTo(x + w - c[1].X)
+		arc(c[1], 1, x+w, y+c[1].Y)
+		pb.VerticalLineTo(y + h - c[2].Y)
+		arc(c[2], 1, x+w-c[2].X, y+h)
+		pb.HorizontalLineTo(x + c[3].X)
+		arc(c[3], 1, x, y+h-c[3].Y)
+		pb.VerticalLineTo(y + c[0].Y)
+		arc(c[0], 1, x+c[0].X, y)
+	} else {
+		arc(c[0], 0, x, y+c[0].Y)
+		pb.VerticalLineTo(y + h - c[3].Y)
+		arc(c[3], 0, x+c[3].X, y+h)
+		pb.HorizontalLineTo(x + w - c[2].X)
+		arc(c[2], 0, x+w, y+h-c[2].Y)
+		pb.VerticalLineTo(y + c[1].Y)
+		arc(c[1], 0, x+w-c[1].X, y)
+	}
+	pb.Close()
+}
+
 // CirclePath creates a circular path using arcs
 func CirclePath(cx, cy, r float64) string {
 	return NewPathBuilder().
@@ -190,6 +453,7 @@ func PolygonPath(points []Point) string {
 
 // SmoothLinePath creates a smooth curve through points using cubic Bézier curves
 // tension controls how tight the curve is (0 = straight lines, 1 = very curved)
+// See Interpolator for curves that do not overshoot.
 func SmoothLinePath(points []Point, tension float64) string {
 	if len(points) < 2 {
 		return ""
@@ -197,38 +461,7 @@ func SmoothLinePath(points []Point, tension float64) string {
 	if len(points) == 2 {
 		return PolylinePath(points)
 	}
-
-	pb := NewPathBuilder()
-	pb.MoveTo(points[0].X, points[0].Y)
-
-	// Calculate control points for cubic Bézier curves
-	for i := 0; i < len(points)-1; i++ {
-		var cp1x, cp1y, cp2x, cp2y float64
-
-		if i == 0 {
-			// First point
-			cp1x = points[i].X + (points[i+1].X-points[i].X)*tension
-			cp1y = points[i].Y + (points[i+1].Y-points[i].Y)*tension
-		} else {
-			// Calculate control point based on previous point
-			cp1x = points[i].X + (points[i+1].X-points[i-1].X)*tension
-			cp1y = points[i].Y + (points[i+1].Y-points[i-1].Y)*tension
-		}
-
-		if i == len(points)-2 {
-			// Last segment
-			cp2x = points[i+1].X - (points[i+1].X-points[i].X)*tension
-			cp2y = points[i+1].Y - (points[i+1].Y-points[i].Y)*tension
-		} else {
-			// Calculate control point based on next point
-			cp2x = points[i+1].X - (points[i+2].X-points[i].X)*tension
-			cp2y = points[i+1].Y - (points[i+2].Y-points[i].Y)*tension
-		}
-
-		pb.CurveTo(cp1x, cp1y, cp2x, cp2y, points[i+1].X, points[i+1].Y)
-	}
-
-	return pb.String()
+	return CurveLinePath(points, SmoothCurve(tension))
 }
 
 // AreaPath creates a filled area path from points with a baseline
@@ -262,27 +495,7 @@ func SmoothAreaPath(points []Point, baselineY float64, tension float64) string {
 	pb.LineTo(points[0].X, points[0].Y)
 
 	// Draw smooth curve through top points
-	for i := 0; i < len(points)-1; i++ {
-		var cp1x, cp1y, cp2x, cp2y float64
-
-		if i == 0 {
-			cp1x = points[i].X + (points[i+1].X-points[i].X)*tension
-			cp1y = points[i].Y + (points[i+1].Y-points[i].Y)*tension
-		} else {
-			cp1x = points[i].X + (points[i+1].X-points[i-1].X)*tension
-			cp1y = points[i].Y + (points[i+1].Y-points[i-1].Y)*tension
-		}
-
-		if i == len(points)-2 {
-			cp2x = points[i+1].X - (points[i+1].X-points[i].X)*tension
-			cp2y = points[i+1].Y - (points[i+1].Y-points[i].Y)*tension
-		} else {
-			cp2x = points[i+1].X - (points[i+2].X-points[i].X)*tension
-			cp2y = points[i+1].Y - (points[i+2].Y-points[i].Y)*tension
-		}
-
-		pb.CurveTo(cp1x, cp1y, cp2x, cp2y, points[i+1].X, points[i+1].Y)
-	}
+	SmoothCurve(tension)(pb, points)
 
 	pb.LineTo(points[len(points)-1].X, baselineY)
 	pb.Close()
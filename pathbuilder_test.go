@@ -278,3 +278,129 @@ func BenchmarkSmoothLinePath(b *testing.B) {
 		_ = SmoothLinePath(points, 0.3)
 	}
 }
+
+func TestRoundedRectCornersPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		radii    CornerRadii
+		expected string
+	}{
+		{
+			name:     "square corners",
+			radii:    CornerRadii{},
+			expected: "M 0.00 0.00 H 100.00 V 50.00 H 0.00 V 0.00 Z",
+		},
+		{
+			name:     "mixed corners",
+			radii:    CornerRadii{TopLeft: 10, BottomRight: 5},
+			expected: "M 10.00 0.00 H 100.00 V 45.00 A 5.00 5.00 0.00 0 1 95.00 50.00 H 0.00 V 10.00 A 10.00 10.00 0.00 0 1 10.00 0.00 Z",
+		},
+		{
+			// Radii of 40 and 60 on the 50-high left side scale by 0.5
+			name:     "oversized radii are scaled together",
+			radii:    CornerRadii{TopLeft: 40, TopRight: 40, BottomRight: 60, BottomLeft: 60},
+			expected: "M 20.00 0.00 H 80.00 A 20.00 20.00 0.00 0 1 100.00 20.00 V 20.00 A 30.00 30.00 0.00 0 1 70.00 50.00 H 30.00 A 30.00 30.00 0.00 0 1 0.00 20.00 V 20.00 A 20.00 20.00 0.00 0 1 20.00 0.00 Z",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RoundedRectCornersPath(0, 0, 100, 50, tt.radii); got != tt.expected {
+				t.Errorf("got  %q\nwant %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPathBuilder_Relative(t *testing.T) {
+	pb := NewPathBuilder().
+		RelMoveTo(10, 10).
+		RelLineTo(20, 0).
+		RelVerticalLineTo(20).
+		RelHorizontalLineTo(-20).
+		RelCurveTo(0, -5, 5, -10, 10, -10).
+		RelSmoothCurveTo(10, 5, 10, 10).
+		RelQuadraticCurveTo(5, 5, 10, 0).
+		RelSmoothQuadraticCurveTo(10, 0).
+		RelArcTo(5, 5, 0, 0, 1, 10, 0)
+
+	expected := "m 10.00 10.00 l 20.00 0.00 v 20.00 h -20.00 c 0.00 -5.00, 5.00 -10.00, 10.00 -10.00 " +
+		"s 10.00 5.00, 10.00 10.00 q 5.00 5.00, 10.00 0.00 t 10.00 0.00 a 5.00 5.00 0.00 0 1 10.00 0.00"
+	if got := pb.String(); got != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+	if cur := pb.CurrentPoint(); cur != (Point{60, 30}) {
+		t.Errorf("expected current point (60, 30), got %v", cur)
+	}
+	if start := pb.SubpathStart(); start != (Point{10, 10}) {
+		t.Errorf("expected subpath start (10, 10), got %v", start)
+	}
+
+	pb.Close()
+	if cur := pb.CurrentPoint(); cur != (Point{10, 10}) {
+		t.Errorf("Close: expected current point (10, 10), got %v", cur)
+	}
+	pb.Reset()
+	if pb.CurrentPoint() != (Point{}) || pb.SubpathStart() != (Point{}) {
+		t.Error("Reset should clear the current point and subpath start")
+	}
+}
+
+func TestPathBuilder_Shortest(t *testing.T) {
+	pb := NewPathBuilder().SetNumberFormat(ShortestRoundTrip()).SetShortest(true).
+		MoveTo(1000, 1000).
+		LineTo(1005, 1002).
+		HorizontalLineTo(0).
+		RelLineTo(-1, 1).
+		Close()
+
+	expected := "M 1000 1000 l 5 2 H 0 l -1 1 Z"
+	if got := pb.String(); got != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+
+	path, err := ParsePath(pb.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "M 1000 1000 L 1005 1002 H 0 L -1 1003 Z"
+	if got := path.Absolute().AppendTo(NewPathBuilder().SetNumberFormat(ShortestRoundTrip())).String(); got != want {
+		t.Errorf("expected the same geometry %s, got %s", want, got)
+	}
+}
+
+func TestPathBuilder_ShortestKeepsRoundedGeometry(t *testing.T) {
+	abs := NewPathBuilder().MoveTo(0, 0)
+	short := NewPathBuilder().SetShortest(true).MoveTo(0, 0)
+	for i := 1; i <= 200; i++ {
+		x, y := float64(i)*1.004, float64(i%7)*0.333
+		abs.LineTo(x, y)
+		short.LineTo(x, y)
+	}
+	abs.HorizontalLineTo(0.004).Close().MoveTo(5.555, 5.555).LineTo(5.559, 5.559)
+	short.HorizontalLineTo(0.004).Close().MoveTo(5.555, 5.555).LineTo(5.559, 5.559)
+
+	want, err := ParsePath(abs.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParsePath(short.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(short.String(), "l ") == 0 {
+		t.Fatalf("expected relative segments in the shortest encoding: %s", short)
+	}
+	want, got = want.Absolute(), got.Absolute()
+	if len(want) != len(got) {
+		t.Fatalf("expected %d segments, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if !nearPoint(want[i].End(), got[i].End()) {
+			t.Fatalf("segment %d: expected %v, got %v", i, want[i].End(), got[i].End())
+		}
+	}
+	if end := got[200].End(); !nearPoint(end, Point{200.8, 1.33}) {
+		t.Errorf("expected the last line to end at x=200.80, got %v", end)
+	}
+}
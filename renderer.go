@@ -1,7 +1,9 @@
 package svg
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/SCKelemen/layout"
@@ -9,21 +11,37 @@ import (
 
 // Renderer renders layout trees to SVG
 type Renderer struct {
-	options      Options
-	clipPath     *ClipPathManager
-	builder      strings.Builder
-	defaultStyle Style
+	options          Options
+	clipPath         *ClipPathManager
+	defs             *Defs
+	defaultStyle     Style
+	defaultTextStyle Style
+
+	// hoisted marks the nodes painted by a stacking context instead of
+	// their parent
+	hoisted map[*layout.Node]bool
 }
 
 // NewRenderer creates a new SVG renderer with the given options
 func NewRenderer(opts Options) *Renderer {
+	defs := opts.Defs
+	if defs == nil {
+		defs = NewDefs(NewIDAllocator(opts.IDPrefix, opts.IDMode))
+	}
+	clipPath := defs.ClipPaths()
+	clipPath.SetNumberFormat(opts.NumberFormat)
 	return &Renderer{
 		options:  opts,
-		clipPath: NewClipPathManager(),
+		clipPath: clipPath,
+		defs:     defs,
 		defaultStyle: Style{
 			Fill:   "#e0e0e0",
 			Stroke: "#333",
 		},
+		defaultTextStyle: Style{
+			Fill: "#333",
+		},
+		hoisted: make(map[*layout.Node]bool),
 	}
 }
 
@@ -35,150 +53,297 @@ func RenderToSVG(root *layout.Node, opts Options) string {
 
 // Render renders the layout tree to SVG
 func (r *Renderer) Render(root *layout.Node) string {
-	r.builder.Reset()
+	var b strings.Builder
+	// Writing to a strings.Builder never fails.
+	_ = r.RenderTo(&b, root)
+	return b.String()
+}
+
+// RenderTo renders the layout tree to SVG, streaming the output to w.
+// Nodes are written as they are visited, so memory use does not grow with
+// the size of the document. Definitions registered while rendering, such as
+// clipPaths, are written in a second <defs> element after the content.
+func (r *Renderer) RenderTo(w io.Writer, root *layout.Node) error {
+	return r.RenderRootsTo(w, LayoutRoot{Node: root})
+}
+
+// LayoutRoot is one independently laid out tree of a multi-root document
+type LayoutRoot struct {
+	Node *layout.Node
+
+	// X and Y offset the tree within the document
+	X, Y float64
+
+	// ID wraps the tree in a <g> with this id (optional)
+	ID string
+}
+
+// transform returns the translation of the root written with n, or "" if
+// it has no offset
+func (root LayoutRoot) transform(n NumberFormat) string {
+	if root.X == 0 && root.Y == 0 {
+		return ""
+	}
+	return fmt.Sprintf("translate(%s,%s)", n.Format(root.X), n.Format(root.Y))
+}
+
+// RenderRoots renders several layout trees into one SVG. Roots are painted
+// in order and share the document's definitions.
+func (r *Renderer) RenderRoots(roots ...LayoutRoot) string {
+	var b strings.Builder
+	// Writing to a strings.Builder never fails.
+	_ = r.RenderRootsTo(&b, roots...)
+	return b.String()
+}
+
+// RenderRootsTo renders several layout trees into one SVG, streaming the
+// output to w like RenderTo
+func (r *Renderer) RenderRootsTo(w io.Writer, roots ...LayoutRoot) error {
+	bw := bufio.NewWriter(w)
+	sw := &streamWriter{w: &xmlWriter{w: bw}, depth: 1}
 
 	// XML declaration
 	if r.options.IncludeXMLDeclaration {
-		r.builder.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
-		r.builder.WriteString("\n")
+		XMLDeclaration().writeXML(sw.w)
+		sw.w.writeString("\n")
 	}
 
-	// Start SVG tag
-	r.builder.WriteString("<svg")
-
-	// Width and height
-	r.builder.WriteString(fmt.Sprintf(` width="%.0f" height="%.0f"`, r.options.Width, r.options.Height))
+	// The header, with the definitions registered before rendering
+	svgElem, defs := r.documentHead()
+	registered := r.defs.mark()
+	defs.AppendChild(nodes(r.defs.elementsSince(defsMark{}))...)
+	svgElem.writeStartTag(sw.w)
+	sw.w.writeString(">\n")
+	for _, n := range svgElem.Children {
+		sw.write(n)
+	}
 
-	// ViewBox
-	viewBox := r.options.ViewBox
-	if viewBox == "" {
-		viewBox = fmt.Sprintf("0 0 %.0f %.0f", r.options.Width, r.options.Height)
+	// Content (this may add definitions)
+	clear(r.hoisted)
+	for _, root := range roots {
+		r.renderRoot(sw, root)
 	}
-	r.builder.WriteString(fmt.Sprintf(` viewBox="%s"`, escapeAttr(viewBox)))
 
-	// Namespace
-	if r.options.Namespace {
-		r.builder.WriteString(` xmlns="http://www.w3.org/2000/svg"`)
+	// Definitions added during rendering. References to later definitions
+	// are valid in SVG, so they can follow the content.
+	if late := r.defs.elementsSince(registered); len(late) > 0 {
+		sw.write(DefsElement(nodes(late)...))
 	}
 
-	// PreserveAspectRatio
-	if r.options.PreserveAspectRatio != "" {
-		r.builder.WriteString(fmt.Sprintf(` preserveAspectRatio="%s"`, escapeAttr(r.options.PreserveAspectRatio)))
+	sw.w.writeString("</svg>")
+	if sw.w.err != nil {
+		return sw.w.err
 	}
+	return bw.Flush()
+}
 
-	r.builder.WriteString(">")
-	r.builder.WriteString("\n")
+// RenderDocument renders the layout tree to a document tree that can be
+// modified before it is serialized with WriteTo.
+func (r *Renderer) RenderDocument(root *layout.Node) *Document {
+	return r.RenderRootsDocument(LayoutRoot{Node: root})
+}
 
-	// Defs section
-	r.builder.WriteString("<defs>")
-	r.builder.WriteString("\n")
+// RenderRootsDocument renders several layout trees into one document tree
+func (r *Renderer) RenderRootsDocument(roots ...LayoutRoot) *Document {
+	svgElem, defs := r.documentHead()
 
-	// Stylesheet
-	if r.options.StyleSheet != nil {
-		r.builder.WriteString(r.options.StyleSheet.ToSVG())
-		r.builder.WriteString("\n")
+	// Render nodes (this may add definitions)
+	clear(r.hoisted)
+	tw := &treeWriter{root: svgElem}
+	for _, root := range roots {
+		r.renderRoot(tw, root)
 	}
 
-	// Render nodes (this may add clipPaths)
-	content := r.renderNode(root, 0)
+	defs.AppendChild(nodes(r.defs.Elements())...)
+
+	doc := NewDocument(svgElem)
+	if r.options.IncludeXMLDeclaration {
+		doc.Prolog = append(doc.Prolog, XMLDeclaration())
+	}
+	return doc
+}
 
-	// ClipPaths (added during rendering)
-	if clipDefs := r.clipPath.ToSVGDefs(); clipDefs != "" {
-		r.builder.WriteString("    ")
-		r.builder.WriteString(clipDefs)
+// documentHead returns the <svg> element with its <defs>, holding the
+// stylesheet, and background
+func (r *Renderer) documentHead() (svgElem, defs *Element) {
+	viewBox := r.options.ViewBox
+	if viewBox == "" {
+		viewBox = fmt.Sprintf("0 0 %.0f %.0f", r.options.Width, r.options.Height)
 	}
 
-	r.builder.WriteString("</defs>")
-	r.builder.WriteString("\n")
+	svgElem = NewElement("svg",
+		Attr{Name: "width", Value: fmt.Sprintf("%.0f", r.options.Width)},
+		Attr{Name: "height", Value: fmt.Sprintf("%.0f", r.options.Height)},
+		Attr{Name: "viewBox", Value: viewBox},
+	)
+	if r.options.Namespace {
+		svgElem.SetAttr("xmlns", "http://www.w3.org/2000/svg")
+	}
+	if r.options.PreserveAspectRatio != "" {
+		svgElem.SetAttr("preserveAspectRatio", r.options.PreserveAspectRatio)
+	}
+
+	defs = DefsElement()
+	if r.options.StyleSheet != nil {
+		defs.AppendChild(&Raw{XML: r.options.StyleSheet.ToSVG()})
+	}
+	svgElem.AppendChild(defs)
 
-	// Background
 	if r.options.BackgroundColor != "" {
-		r.builder.WriteString(fmt.Sprintf(`<rect width="%.0f" height="%.0f" fill="%s"/>`,
-			r.options.Width, r.options.Height, escapeAttr(r.options.BackgroundColor)))
-		r.builder.WriteString("\n")
+		svgElem.AppendChild(NewElement("rect",
+			Attr{Name: "width", Value: fmt.Sprintf("%.0f", r.options.Width)},
+			Attr{Name: "height", Value: fmt.Sprintf("%.0f", r.options.Height)},
+			Attr{Name: "fill", Value: r.options.BackgroundColor},
+		))
 	}
+	return svgElem, defs
+}
 
-	// Content
-	r.builder.WriteString(content)
+// nodes converts elements to document nodes
+func nodes(elems []*Element) []Node {
+	out := make([]Node, len(elems))
+	for i, e := range elems {
+		out[i] = e
+	}
+	return out
+}
 
-	// End SVG tag
-	r.builder.WriteString("</svg>")
+// renderRoot writes a layout tree, wrapped in a group if it has an offset or ID
+func (r *Renderer) renderRoot(w nodeWriter, root LayoutRoot) {
+	transform := root.transform(r.options.NumberFormat)
+	if transform == "" && root.ID == "" {
+		r.renderNode(w, root.Node, 0)
+		return
+	}
 
-	return r.builder.String()
+	group := NewElement("g")
+	setNonEmpty(group, "id", root.ID)
+	setNonEmpty(group, "transform", transform)
+	w.openGroup(group, false)
+	r.renderNode(w, root.Node, 0)
+	w.closeGroup()
 }
 
-// renderNode recursively renders a layout node and its children
-func (r *Renderer) renderNode(node *layout.Node, depth int) string {
+// renderNode recursively writes a layout node and its children
+func (r *Renderer) renderNode(w nodeWriter, node *layout.Node, depth int) {
 	if node == nil {
-		return ""
+		return
 	}
 
 	// Allow custom rendering
 	if r.options.RenderNodeFunc != nil {
 		if custom := r.options.RenderNodeFunc(node, depth); custom != "" {
-			return custom
+			w.write(&Raw{XML: custom})
+			return
 		}
 	} else if r.options.RenderFunc != nil {
 		if custom := r.options.RenderFunc(node, depth); custom != "" {
-			return custom
+			w.write(&Raw{XML: custom})
+			return
 		}
 	}
 
-	var b strings.Builder
-	rect := node.Rect
-
 	// Get style for this node
-	style := r.defaultStyle
-	if r.options.StyleNodeFunc != nil {
-		style = r.options.StyleNodeFunc(node, depth)
-	} else if r.options.StyleFunc != nil {
-		style = r.options.StyleFunc(node, depth)
-	}
+	style := r.nodeStyle(node, depth)
 
 	// Get transform
 	transform := GetTransformFromNode(node)
 
-	// Start group if there's a transform or children
-	hasTransform := transform != ""
-	hasChildren := len(node.Children) > 0
+	// Stacking contexts paint their positioned descendants in z-index
+	// order, after the children painted in tree order
+	layers := r.collectLayers(node, depth)
+	children := r.paintedChildren(node)
+	hasChildren := len(children) > 0 || !layers.empty()
+
+	// The node's box (only if it has non-zero dimensions) and text
+	box := r.boxStyle(node, depth)
+	parts := r.boxElements(node, style, box)
+	var text *Element
+	if isTextNode(node) {
+		text = r.textElement(node, style)
+	}
 
-	if hasTransform || hasChildren {
-		if hasTransform {
-			b.WriteString(fmt.Sprintf(`<g transform="%s">`, escapeAttr(transform)))
-		} else {
-			b.WriteString("<g>")
-		}
-		b.WriteString("\n")
+	// Overflow clipping applies to the content: text and children
+	clipID := ""
+	if text != nil || hasChildren {
+		clipID = r.overflowClip(node, box, style.NumberFormat)
+	}
+
+	// Start group if there's a transform, children or several parts
+	grouped := transform != "" || hasChildren || clipID != "" || (len(parts) > 0 && text != nil) || len(parts) > 1
+	if grouped {
+		w.openGroup(GroupElement(transform, Style{}), false)
 	}
 
-	// Render the node itself as a rectangle
-	// Only render if it has non-zero dimensions
-	if rect.Width > 0 && rect.Height > 0 {
-		indent := strings.Repeat("  ", depth+1)
-		b.WriteString(indent)
-		b.WriteString(Rect(rect.X, rect.Y, rect.Width, rect.Height, style))
-		b.WriteString("\n")
+	// Render the node itself
+	for _, part := range parts {
+		w.write(part)
+	}
+
+	if clipID != "" {
+		w.openGroup(GroupWithClipPathElement(clipID, Style{}), false)
+	}
+	if layers != nil {
+		r.renderLayer(w, layers.negative)
+	}
+	if text != nil {
+		w.write(text)
 	}
 
 	// Render children
-	for _, child := range node.Children {
-		childContent := r.renderNode(child, depth+1)
-		if childContent != "" {
-			indent := strings.Repeat("  ", depth+1)
-			b.WriteString(indent)
-			b.WriteString(childContent)
-		}
+	for _, child := range children {
+		r.renderNode(w, child, depth+1)
+	}
+
+	if layers != nil {
+		r.renderLayer(w, layers.positioned)
+		r.renderLayer(w, layers.positive)
+	}
+	if clipID != "" {
+		w.closeGroup()
 	}
 
 	// End group
-	if hasTransform || hasChildren {
-		indent := strings.Repeat("  ", depth)
-		b.WriteString(indent)
-		b.WriteString("</g>")
-		b.WriteString("\n")
+	if grouped {
+		w.closeGroup()
 	}
+}
 
-	return b.String()
+// renderLayer writes the hoisted nodes of a stacking context layer, each
+// inside the overflow clips of the ancestors it was hoisted out of
+func (r *Renderer) renderLayer(w nodeWriter, entries []layerEntry) {
+	for _, entry := range entries {
+		clipIDs := r.layerClipIDs(entry)
+		for _, id := range clipIDs {
+			w.openGroup(GroupWithClipPathElement(id, Style{}), true)
+		}
+		r.renderNode(w, entry.node, entry.depth)
+		for range clipIDs {
+			w.closeGroup()
+		}
+	}
+}
+
+// nodeStyle returns the style for a node, falling back to the renderer's
+// number format when the style does not set one. Text nodes start from the
+// default text style and take their font from the layout text style.
+func (r *Renderer) nodeStyle(node *layout.Node, depth int) Style {
+	style := r.defaultStyle
+	if isTextNode(node) {
+		style = r.defaultTextStyle
+	}
+	if r.options.StyleNodeFunc != nil {
+		style = r.options.StyleNodeFunc(node, depth)
+	} else if r.options.StyleFunc != nil {
+		style = r.options.StyleFunc(node, depth)
+	}
+	if isTextNode(node) {
+		style = applyTextStyle(style, node.Style.TextStyle)
+	}
+	if style.NumberFormat == (NumberFormat{}) {
+		style.NumberFormat = r.options.NumberFormat
+	}
+	return style
 }
 
 // GetClipPathManager returns the clipPath manager for custom clipPath creation
@@ -186,55 +351,33 @@ func (r *Renderer) GetClipPathManager() *ClipPathManager {
 	return r.clipPath
 }
 
+// Defs returns the registry of gradients, markers, clipPaths, patterns,
+// filters and symbols written into the rendered documents
+func (r *Renderer) Defs() *Defs {
+	return r.defs
+}
+
+// IDs returns the allocator for the IDs of the renderer's definitions
+func (r *Renderer) IDs() *IDAllocator {
+	return r.defs.IDs()
+}
+
 // SetDefaultStyle sets the default style for rendered nodes
 func (r *Renderer) SetDefaultStyle(style Style) {
 	r.defaultStyle = style
 }
 
+// SetDefaultTextStyle sets the default style for text nodes
+func (r *Renderer) SetDefaultTextStyle(style Style) {
+	r.defaultTextStyle = style
+}
+
 // RenderNodes renders multiple layout nodes at their computed positions
 // This is useful when you have a collection of already-positioned nodes
 func RenderNodes(nodes []*layout.Node, opts Options) string {
-	renderer := NewRenderer(opts)
-
-	var b strings.Builder
-
-	// Start SVG
-	b.WriteString(fmt.Sprintf(`<svg width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f"`,
-		opts.Width, opts.Height, opts.Width, opts.Height))
-
-	if opts.Namespace {
-		b.WriteString(` xmlns="http://www.w3.org/2000/svg"`)
+	roots := make([]LayoutRoot, len(nodes))
+	for i, node := range nodes {
+		roots[i] = LayoutRoot{Node: node}
 	}
-
-	b.WriteString(">")
-	b.WriteString("\n")
-
-	// Defs
-	b.WriteString("<defs>")
-	b.WriteString("\n")
-
-	if opts.StyleSheet != nil {
-		b.WriteString(opts.StyleSheet.ToSVG())
-		b.WriteString("\n")
-	}
-
-	b.WriteString("</defs>")
-	b.WriteString("\n")
-
-	// Background
-	if opts.BackgroundColor != "" {
-		b.WriteString(fmt.Sprintf(`<rect width="%.0f" height="%.0f" fill="%s"/>`,
-			opts.Width, opts.Height, escapeAttr(opts.BackgroundColor)))
-		b.WriteString("\n")
-	}
-
-	// Render each node
-	for _, node := range nodes {
-		content := renderer.renderNode(node, 0)
-		b.WriteString(content)
-	}
-
-	b.WriteString("</svg>")
-
-	return b.String()
+	return NewRenderer(opts).RenderRoots(roots...)
 }
@@ -0,0 +1,352 @@
+package svg
+
+import (
+	"github.com/SCKelemen/layout"
+)
+
+// BorderStyle is the line style of a border side
+type BorderStyle string
+
+const (
+	BorderSolid  BorderStyle = "solid"
+	BorderDashed BorderStyle = "dashed"
+	BorderDotted BorderStyle = "dotted"
+	BorderNone   BorderStyle = "none"
+)
+
+// BorderSide is the paint of one border side. Its width is the node's
+// computed layout border width.
+type BorderSide struct {
+	Color string      // Defaults to the node's stroke (or, for text, fill) color
+	Style BorderStyle // Defaults to solid
+}
+
+// Overflow controls whether a node's content is clipped to its padding box
+type Overflow string
+
+const (
+	OverflowVisible Overflow = "visible"
+	OverflowHidden  Overflow = "hidden"
+	OverflowClip    Overflow = "clip"
+)
+
+// clips reports whether the overflow value clips content
+func (o Overflow) clips() bool {
+	return o == OverflowHidden || o == OverflowClip
+}
+
+// BoxStyle holds the parts of a node's box that layout does not carry:
+// border colors and styles, corner radii and overflow
+type BoxStyle struct {
+	Top, Right, Bottom, Left BorderSide
+	Radii                    CornerRadii
+	Overflow                 Overflow // Defaults to visible
+}
+
+// UniformBorder returns a box style with the same border on every side
+func UniformBorder(color string, style BorderStyle) BoxStyle {
+	side := BorderSide{Color: color, Style: style}
+	return BoxStyle{Top: side, Right: side, Bottom: side, Left: side}
+}
+
+// Debug overlay colors, as in browser developer tools
+var (
+	debugPaddingStyle = Style{Fill: "#c3d08b", FillOpacity: 0.5}
+	debugContentStyle = Style{Fill: "#8cb6c0", FillOpacity: 0.5}
+)
+
+// boxSide is a border side resolved for drawing
+type boxSide struct {
+	width float64
+	color string
+	style BorderStyle
+}
+
+// boxStyle returns the box style of a node
+func (r *Renderer) boxStyle(node *layout.Node, depth int) BoxStyle {
+	if r.options.BoxStyleFunc == nil {
+		return BoxStyle{}
+	}
+	return r.options.BoxStyleFunc(node, depth)
+}
+
+// boxElements draws the background, borders and debug overlays of a node.
+// Nodes without borders, radii or debug overlays are a single rect at
+// node.Rect, and text nodes have no background.
+func (r *Renderer) boxElements(node *layout.Node, style Style, box BoxStyle) []*Element {
+	rect := node.Rect
+	if rect.Width <= 0 || rect.Height <= 0 {
+		return nil
+	}
+	text := isTextNode(node)
+	n := style.NumberFormat
+
+	// Borders take their width from layout and their paint from BoxStyle
+	bt, br, bb, bl := r.resolveSpacing(node, node.Style.Border)
+	defaultColor := style.Stroke
+	if text {
+		defaultColor = style.Fill
+	}
+	if defaultColor == "" || defaultColor == "none" {
+		defaultColor = "black"
+	}
+	var sides [4]boxSide
+	hasBorder := false
+	for i, s := range [4]BorderSide{box.Top, box.Right, box.Bottom, box.Left} {
+		width := [4]float64{bt, br, bb, bl}[i]
+		if s.Style == BorderNone || width <= 0 {
+			continue
+		}
+		sides[i] = boxSide{width: width, color: s.Color, style: s.Style}
+		if sides[i].color == "" {
+			sides[i].color = defaultColor
+		}
+		if sides[i].style == "" {
+			sides[i].style = BorderSolid
+		}
+		hasBorder = true
+	}
+
+	if !hasBorder && box.Radii.IsZero() && !r.options.DebugBoxes {
+		if text {
+			return nil
+		}
+		return []*Element{RectElement(rect.X, rect.Y, rect.Width, rect.Height, style)}
+	}
+
+	outer := clampCorners(rect.Width, rect.Height, box.Radii.corners())
+	var parts []*Element
+
+	// Background. Without borders the node's stroke outlines the box.
+	if !text {
+		bg := style
+		if hasBorder {
+			bg.Stroke, bg.StrokeWidth, bg.StrokeDashArray = "", 0, ""
+		}
+		if box.Radii.IsZero() {
+			parts = append(parts, RectElement(rect.X, rect.Y, rect.Width, rect.Height, bg))
+		} else {
+			parts = append(parts, PathElement(roundedRectData(rect.X, rect.Y, rect.Width, rect.Height, outer, n), bg))
+		}
+	}
+
+	if hasBorder {
+		parts = append(parts, r.borderElements(node, sides, outer, n)...)
+	}
+
+	if r.options.DebugBoxes {
+		// The padding box lies inside the layout border, drawn or not
+		px, py := rect.X+bl, rect.Y+bt
+		pw, ph := rect.Width-bl-br, rect.Height-bt-bb
+		pt, pr, pb, pl := r.resolveSpacing(node, node.Style.Padding)
+		overlay := func(x, y, w, h float64, s Style) {
+			if w > 0 && h > 0 {
+				s.NumberFormat = n
+				parts = append(parts, RectElement(x, y, w, h, s))
+			}
+		}
+		overlay(px, py, pw, ph, debugPaddingStyle)
+		overlay(px+pl, py+pt, pw-pl-pr, ph-pt-pb, debugContentStyle)
+	}
+	return parts
+}
+
+// overflowClip returns the ID of a clipPath for a node's padding box, with
+// the inner corner radii, or "" if the node does not clip its content.
+// Nodes with the same padding box share a clipPath.
+func (r *Renderer) overflowClip(node *layout.Node, box BoxStyle, n NumberFormat) string {
+	rect := node.Rect
+	if !box.Overflow.clips() || rect.Width <= 0 || rect.Height <= 0 {
+		return ""
+	}
+
+	t, rt, b, l := r.resolveSpacing(node, node.Style.Border)
+	x, y, w, h := rect.X+l, rect.Y+t, rect.Width-l-rt, rect.Height-t-b
+	if w <= 0 || h <= 0 {
+		// Nothing inside the border is visible
+		w, h = 0, 0
+	}
+
+	outer := clampCorners(rect.Width, rect.Height, box.Radii.corners())
+	inner := innerCorners(outer, t, rt, b, l)
+	switch c := inner[0]; {
+	case inner == [4]Point{} || w == 0:
+		return r.clipPath.AddRect(x, y, w, h)
+	case c.X == c.Y && inner == [4]Point{c, c, c, c}:
+		return r.clipPath.AddRoundedRect(x, y, w, h, c.X)
+	}
+	return r.clipPath.AddCustom(Path(roundedRectData(x, y, w, h, inner, n), Style{}))
+}
+
+// innerCorners returns the radii of the padding edge for outer radii and
+// border widths. A corner is square where a radius is no larger than the
+// border width.
+func innerCorners(outer [4]Point, t, r, b, l float64) [4]Point {
+	inner := [4]Point{
+		{outer[0].X - l, outer[0].Y - t},
+		{outer[1].X - r, outer[1].Y - t},
+		{outer[2].X - r, outer[2].Y - b},
+		{outer[3].X - l, outer[3].Y - b},
+	}
+	for i, c := range inner {
+		if c.X <= 0 || c.Y <= 0 {
+			inner[i] = Point{}
+		}
+	}
+	return inner
+}
+
+// borderElements draws the border sides of a box with outer corner radii
+// outer. Sides that share a color and style are drawn as one shape.
+func (r *Renderer) borderElements(node *layout.Node, sides [4]boxSide, outer [4]Point, n NumberFormat) []*Element {
+	rect := node.Rect
+	x, y, w, h := rect.X, rect.Y, rect.Width, rect.Height
+	t, rt, b, l := sides[0].width, sides[1].width, sides[2].width, sides[3].width
+
+	uniform := true
+	var first *boxSide
+	for i := range sides {
+		if sides[i].width == 0 {
+			continue
+		}
+		if first == nil {
+			first = &sides[i]
+		} else if sides[i].color != first.color || sides[i].style != first.style {
+			uniform = false
+		}
+	}
+	equalWidths := t == rt && t == b && t == l
+
+	// The border area between the outer edge and the padding edge
+	inner := innerCorners(outer, t, rt, b, l)
+	ring := func() string {
+		pb := NewPathBuilder().SetNumberFormat(n)
+		appendRoundedRect(pb, x, y, w, h, outer, true)
+		if iw, ih := w-l-rt, h-t-b; iw > 0 && ih > 0 {
+			// The hole winds the other way, so nonzero filling leaves it empty
+			appendRoundedRect(pb, x+l, y+t, iw, ih, inner, false)
+		}
+		return pb.String()
+	}
+
+	if uniform && first.style == BorderSolid {
+		return []*Element{PathElement(ring(), Style{Fill: first.color, NumberFormat: n})}
+	}
+	if uniform && equalWidths {
+		// Stroke the middle of the border; the stroke covers half the width
+		// on each side of the path
+		half := t / 2
+		mid := [4]Point{}
+		for i, c := range outer {
+			mid[i] = Point{max(c.X-half, 0), max(c.Y-half, 0)}
+		}
+		d := roundedRectData(x+half, y+half, w-t, h-t, mid, n)
+		return []*Element{PathElement(d, borderStrokeStyle(*first, n))}
+	}
+
+	// Each side on its own. Corners are split along the line from the outer
+	// corner to the inner corner, as in CSS.
+	var parts []*Element
+	rounded := outer != [4]Point{}
+	ringData := ""
+	for i, side := range sides {
+		if side.width == 0 {
+			continue
+		}
+		if side.style != BorderSolid {
+			parts = append(parts, borderLine(i, side, x, y, w, h, outer, n))
+			continue
+		}
+		wedge := borderWedge(i, x, y, w, h, [4]float64{t, rt, b, l}, outer)
+		if !rounded {
+			parts = append(parts, PolygonElement(wedge, Style{Fill: side.color, NumberFormat: n}))
+			continue
+		}
+		if ringData == "" {
+			ringData = ring()
+		}
+		clip := r.clipPath.AddCustom(Polygon(wedge, Style{NumberFormat: n}))
+		parts = append(parts, PathElement(ringData, Style{Fill: side.color, ClipPath: URL(clip), NumberFormat: n}))
+	}
+	return parts
+}
+
+// borderWedge returns the region of side i (top, right, bottom, left) of a
+// box. Its inner edge lies deep enough to contain the side's rounded corners.
+func borderWedge(i int, x, y, w, h float64, widths [4]float64, outer [4]Point) []Point {
+	corners := [4]Point{{x, y}, {x + w, y}, {x + w, y + h}, {x, y + h}}
+	dirs := [4]Point{{1, 1}, {-1, 1}, {-1, -1}, {1, -1}}
+
+	// split returns the end of the corner's split line, past the corner radius
+	split := func(c int) Point {
+		// The horizontal and vertical border widths meeting at corner c
+		dx := widths[3]
+		if c == 1 || c == 2 {
+			dx = widths[1]
+		}
+		dy := widths[0]
+		if c >= 2 {
+			dy = widths[2]
+		}
+		s := 1.0
+		if dx > 0 {
+			s = max(s, outer[c].X/dx)
+		}
+		if dy > 0 {
+			s = max(s, outer[c].Y/dy)
+		}
+		return Point{corners[c].X + dirs[c].X*dx*s, corners[c].Y + dirs[c].Y*dy*s}
+	}
+
+	a, b := i, (i+1)%4
+	return []Point{corners[a], corners[b], split(b), split(a)}
+}
+
+// borderLine strokes side i of a box along the middle of the border
+func borderLine(i int, side boxSide, x, y, w, h float64, outer [4]Point, n NumberFormat) *Element {
+	half := side.width / 2
+	style := borderStrokeStyle(side, n)
+	switch i {
+	case 0:
+		return LineElement(x+outer[0].X, y+half, x+w-outer[1].X, y+half, style)
+	case 1:
+		return LineElement(x+w-half, y+outer[1].Y, x+w-half, y+h-outer[2].Y, style)
+	case 2:
+		return LineElement(x+w-outer[2].X, y+h-half, x+outer[3].X, y+h-half, style)
+	default:
+		return LineElement(x+half, y+h-outer[3].Y, x+half, y+outer[0].Y, style)
+	}
+}
+
+// borderStrokeStyle returns the stroke style of a dashed, dotted or solid
+// border line
+func borderStrokeStyle(side boxSide, n NumberFormat) Style {
+	style := Style{Fill: "none", Stroke: side.color, StrokeWidth: side.width, NumberFormat: n}
+	switch side.style {
+	case BorderDashed:
+		style.StrokeDashArray = n.Format(side.width * 3)
+	case BorderDotted:
+		// Zero-length dashes with round caps are dots one width across
+		style.StrokeDashArray = "0 " + n.Format(side.width*2)
+		style.StrokeLinecap = StrokeLinecapRound
+	}
+	return style
+}
+
+// roundedRectData returns the path data of a rectangle with corner radii c
+func roundedRectData(x, y, w, h float64, c [4]Point, n NumberFormat) string {
+	pb := NewPathBuilder().SetNumberFormat(n)
+	appendRoundedRect(pb, x, y, w, h, c, true)
+	return pb.String()
+}
+
+// resolveSpacing resolves layout spacing (padding or border widths) to
+// pixels: top, right, bottom, left
+func (r *Renderer) resolveSpacing(node *layout.Node, s layout.Spacing) (float64, float64, float64, float64) {
+	fontSize := textFontSize(node.Style.TextStyle)
+	ctx := r.layoutContext()
+	return layout.ResolveLength(s.Top, ctx, fontSize),
+		layout.ResolveLength(s.Right, ctx, fontSize),
+		layout.ResolveLength(s.Bottom, ctx, fontSize),
+		layout.ResolveLength(s.Left, ctx, fontSize)
+}
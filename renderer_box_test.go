@@ -0,0 +1,201 @@
+package svg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/SCKelemen/layout"
+)
+
+// boxNode returns a laid out 100x50 node with the given border widths
+func boxNode(top, right, bottom, left float64) *layout.Node {
+	return &layout.Node{
+		Rect: layout.Rect{X: 0, Y: 0, Width: 100, Height: 50},
+		Style: layout.Style{
+			Border:  layout.Spacing{Top: layout.Px(top), Right: layout.Px(right), Bottom: layout.Px(bottom), Left: layout.Px(left)},
+			Padding: layout.Spacing{Top: layout.Px(5), Right: layout.Px(5), Bottom: layout.Px(5), Left: layout.Px(5)},
+		},
+	}
+}
+
+func renderBox(node *layout.Node, box BoxStyle, debug bool) string {
+	opts := DefaultOptions()
+	opts.Width, opts.Height = 100, 50
+	opts.StyleSheet = nil
+	opts.DebugBoxes = debug
+	opts.BoxStyleFunc = func(*layout.Node, int) BoxStyle { return box }
+	return RenderToSVG(node, opts)
+}
+
+func TestRenderBox_PlainNodeIsSingleRect(t *testing.T) {
+	svg := renderBox(boxNode(0, 0, 0, 0), BoxStyle{}, false)
+	want := `<rect x="0.00" y="0.00" width="100.00" height="50.00" fill="#e0e0e0" stroke="#333"/>`
+	if !strings.Contains(svg, want) || strings.Contains(svg, "<g>") {
+		t.Errorf("expected a bare rect, got:\n%s", svg)
+	}
+}
+
+func TestRenderBox_UniformSolidBorder(t *testing.T) {
+	svg := renderBox(boxNode(2, 2, 2, 2), UniformBorder("red", BorderSolid), false)
+
+	// The background loses its stroke, and the border is one ring path
+	if !strings.Contains(svg, `<rect x="0.00" y="0.00" width="100.00" height="50.00" fill="#e0e0e0"/>`) {
+		t.Errorf("expected unstroked background:\n%s", svg)
+	}
+	ring := `<path d="M 0.00 0.00 H 100.00 V 50.00 H 0.00 V 0.00 Z M 2.00 2.00 V 48.00 H 98.00 V 2.00 Z" fill="red"/>`
+	if !strings.Contains(svg, ring) {
+		t.Errorf("expected ring %s in:\n%s", ring, svg)
+	}
+}
+
+func TestRenderBox_BorderColorDefaultsToStroke(t *testing.T) {
+	svg := renderBox(boxNode(1, 1, 1, 1), BoxStyle{}, false)
+	if !strings.Contains(svg, `fill="#333"/>`) {
+		t.Errorf("expected the border in the stroke color:\n%s", svg)
+	}
+}
+
+func TestRenderBox_PerSideBorders(t *testing.T) {
+	box := BoxStyle{
+		Top:    BorderSide{Color: "red"},
+		Right:  BorderSide{Color: "green", Style: BorderDashed},
+		Bottom: BorderSide{Color: "blue", Style: BorderDotted},
+		Left:   BorderSide{Style: BorderNone},
+	}
+	svg := renderBox(boxNode(2, 4, 2, 2), box, false)
+
+	for _, want := range []string{
+		// Solid sides are trapezoids meeting the neighbours on the diagonal
+		`<polygon points="0.00,0.00 100.00,0.00 96.00,2.00 0.00,2.00" fill="red"/>`,
+		`<line x1="98.00" y1="0.00" x2="98.00" y2="50.00" fill="none" stroke="green" stroke-width="4.00" stroke-dasharray="12.00"/>`,
+		`<line x1="100.00" y1="49.00" x2="0.00" y2="49.00" fill="none" stroke="blue" stroke-width="2.00" stroke-dasharray="0 4.00" stroke-linecap="round"/>`,
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("expected %s in:\n%s", want, svg)
+		}
+	}
+	if strings.Contains(svg, "orange") || strings.Count(svg, "<polygon") != 1 {
+		t.Errorf("border-style none should not be drawn:\n%s", svg)
+	}
+}
+
+func TestRenderBox_RoundedCorners(t *testing.T) {
+	box := UniformBorder("red", BorderSolid)
+	box.Radii = CornerRadii{TopLeft: 10}
+	svg := renderBox(boxNode(2, 2, 2, 2), box, false)
+
+	// Background and ring follow the outer radius; the hole's radius is
+	// reduced by the border width
+	if !strings.Contains(svg, `<path d="M 10.00 0.00 H 100.00 V 50.00 H 0.00 V 10.00 A 10.00 10.00 0.00 0 1 10.00 0.00 Z" fill="#e0e0e0"/>`) {
+		t.Errorf("expected rounded background:\n%s", svg)
+	}
+	if !strings.Contains(svg, `M 10.00 2.00 A 8.00 8.00 0.00 0 0 2.00 10.00 V 48.00 H 98.00 V 2.00 Z" fill="red"/>`) {
+		t.Errorf("expected rounded ring:\n%s", svg)
+	}
+}
+
+func TestRenderBox_RoundedPerSideColorsUseClipPaths(t *testing.T) {
+	box := BoxStyle{
+		Top:    BorderSide{Color: "red"},
+		Right:  BorderSide{Color: "green"},
+		Bottom: BorderSide{Color: "blue"},
+		Left:   BorderSide{Color: "orange"},
+		Radii:  UniformRadii(8),
+	}
+	svg := renderBox(boxNode(2, 2, 2, 2), box, false)
+
+	if got := strings.Count(svg, "<clipPath"); got != 4 {
+		t.Errorf("expected 4 clipPaths, got %d:\n%s", got, svg)
+	}
+	for _, color := range []string{"red", "green", "blue", "orange"} {
+		if !strings.Contains(svg, `fill="`+color+`" clip-path="url(#clip-`) {
+			t.Errorf("expected clipped %s side:\n%s", color, svg)
+		}
+	}
+}
+
+func TestRenderBox_DebugOverlays(t *testing.T) {
+	svg := renderBox(boxNode(2, 2, 2, 2), BoxStyle{}, true)
+
+	for _, want := range []string{
+		`<rect x="2.00" y="2.00" width="96.00" height="46.00" fill="#c3d08b" fill-opacity="0.50"/>`,
+		`<rect x="7.00" y="7.00" width="86.00" height="36.00" fill="#8cb6c0" fill-opacity="0.50"/>`,
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("expected %s in:\n%s", want, svg)
+		}
+	}
+
+	// The tree renderer groups the parts of the node the same way
+	opts := DefaultOptions()
+	opts.DebugBoxes = true
+	doc := NewRenderer(opts).RenderDocument(boxNode(2, 2, 2, 2))
+	if got := len(doc.Root.FindAll("g")[0].ChildElements()); got != 4 {
+		t.Errorf("expected background, border and two overlays, got %d parts", got)
+	}
+}
+
+func TestRenderBox_OverflowClipsContent(t *testing.T) {
+	node := boxNode(2, 2, 2, 2)
+	node.Children = []*layout.Node{{Rect: layout.Rect{X: 0, Y: 0, Width: 200, Height: 200}}}
+
+	box := BoxStyle{Radii: UniformRadii(6), Overflow: OverflowHidden}
+	opts := DefaultOptions()
+	opts.StyleSheet = nil
+	opts.BoxStyleFunc = func(n *layout.Node, depth int) BoxStyle {
+		if n == node {
+			return box
+		}
+		return BoxStyle{}
+	}
+	renderer := NewRenderer(opts)
+	svg := renderer.Render(node)
+
+	// The clip is the padding box with the inner radius
+	defs := renderer.GetClipPathManager().ToSVGDefs()
+	if !strings.Contains(defs, `<rect x="2.00" y="2.00" width="96.00" height="46.00" rx="4.00" ry="4.00"/>`) {
+		t.Errorf("expected padding box clip, got %s", defs)
+	}
+	id := renderer.GetClipPathManager().paths[0].ID
+	group := `<g clip-path="url(#` + id + `)">`
+	if !strings.Contains(svg, group) {
+		t.Fatalf("expected %s in:\n%s", group, svg)
+	}
+	// The node's own box is outside the clip group, the child inside it
+	if strings.Index(svg, `<path d="M 6.00 0.00`) > strings.Index(svg, group) ||
+		strings.Index(svg, `width="200.00"`) < strings.Index(svg, group) {
+		t.Errorf("expected only the children to be clipped:\n%s", svg)
+	}
+
+	doc := NewRenderer(opts).RenderDocument(node)
+	clipped := doc.Root.FindAll("g")[1]
+	if v, _ := clipped.Attr("clip-path"); !strings.HasPrefix(v, "url(#clip-") || len(clipped.ChildElements()) != 1 {
+		t.Errorf("RenderDocument: expected a clip group around the child, got %s", clipped)
+	}
+}
+
+func TestRenderBox_OverflowClipsAreShared(t *testing.T) {
+	// Two nodes with the same padding box and a visible one
+	a, b, c := boxNode(0, 0, 0, 0), boxNode(0, 0, 0, 0), boxNode(0, 0, 0, 0)
+	for _, n := range []*layout.Node{a, b, c} {
+		n.Children = []*layout.Node{{Rect: layout.Rect{Width: 10, Height: 10}}}
+	}
+	root := &layout.Node{Children: []*layout.Node{a, b, c}}
+
+	opts := DefaultOptions()
+	opts.BoxStyleFunc = func(n *layout.Node, depth int) BoxStyle {
+		if n == c {
+			return BoxStyle{Overflow: OverflowVisible}
+		}
+		return BoxStyle{Overflow: OverflowClip}
+	}
+	renderer := NewRenderer(opts)
+	svg := renderer.Render(root)
+
+	if got := len(renderer.GetClipPathManager().paths); got != 1 {
+		t.Errorf("expected one shared clipPath, got %d", got)
+	}
+	if got := strings.Count(svg, `<g clip-path=`); got != 2 {
+		t.Errorf("expected two clipped groups, got %d:\n%s", got, svg)
+	}
+}
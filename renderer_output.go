@@ -0,0 +1,101 @@
+package svg
+
+import "strings"
+
+// nodeWriter receives the output of renderNode: whole nodes, and the groups
+// opened and closed around them. RenderRootsDocument collects it into a tree
+// and RenderRootsTo writes it as it arrives.
+type nodeWriter interface {
+	// openGroup starts g, which must have no children yet. An optional group
+	// is left out if nothing is written into it.
+	openGroup(g *Element, optional bool)
+	write(n Node)
+	closeGroup()
+}
+
+// treeWriter appends the output to the innermost open group, or to root
+type treeWriter struct {
+	root     *Element
+	open     []*Element
+	optional []bool
+}
+
+func (t *treeWriter) openGroup(g *Element, optional bool) {
+	t.open = append(t.open, g)
+	t.optional = append(t.optional, optional)
+}
+
+func (t *treeWriter) write(n Node) {
+	if len(t.open) == 0 {
+		t.root.AppendChild(n)
+		return
+	}
+	t.open[len(t.open)-1].AppendChild(n)
+}
+
+func (t *treeWriter) closeGroup() {
+	last := len(t.open) - 1
+	g, optional := t.open[last], t.optional[last]
+	t.open, t.optional = t.open[:last], t.optional[:last]
+	if optional && len(g.Children) == 0 {
+		return
+	}
+	t.write(g)
+}
+
+// streamWriter writes the output indented one element per line. Start tags
+// of optional groups are held back until something is written into them.
+type streamWriter struct {
+	w     *xmlWriter
+	depth int
+
+	// open holds the open groups; the first written of them are written
+	open    []*Element
+	written int
+}
+
+func (s *streamWriter) openGroup(g *Element, optional bool) {
+	s.open = append(s.open, g)
+	if !optional {
+		s.flush()
+	}
+}
+
+func (s *streamWriter) write(n Node) {
+	s.flush()
+	s.indent(len(s.open))
+	if e, ok := n.(*Element); ok {
+		e.writeIndented(s.w, s.depth+len(s.open))
+	} else {
+		n.writeXML(s.w)
+	}
+	s.w.writeString("\n")
+}
+
+func (s *streamWriter) closeGroup() {
+	last := len(s.open) - 1
+	g := s.open[last]
+	s.open = s.open[:last]
+	if s.written <= last {
+		return
+	}
+	s.written = last
+	s.indent(last)
+	s.w.writeString("</")
+	s.w.writeString(g.Tag)
+	s.w.writeString(">\n")
+}
+
+// flush writes the start tags of the groups held back
+func (s *streamWriter) flush() {
+	for ; s.written < len(s.open); s.written++ {
+		s.indent(s.written)
+		s.open[s.written].writeStartTag(s.w)
+		s.w.writeString(">\n")
+	}
+}
+
+// indent writes the indentation of the group level i
+func (s *streamWriter) indent(i int) {
+	s.w.writeString(strings.Repeat("  ", s.depth+i))
+}
@@ -0,0 +1,143 @@
+package svg
+
+import (
+	"sort"
+
+	"github.com/SCKelemen/layout"
+)
+
+// Painting order follows CSS 2.1 Appendix E, simplified: within a stacking
+// context, the root's box is painted first, then stacking contexts with a
+// negative z-index, then the in-flow content in tree order, then positioned
+// descendants with a zero z-index, then stacking contexts with a positive
+// z-index. In-flow content keeps the nesting of the layout tree; positioned
+// descendants and stacking contexts are hoisted out of it and painted with
+// the stacking context that contains them.
+
+// stackingLayers holds the descendants that a stacking context paints out
+// of tree order
+type stackingLayers struct {
+	negative   []layerEntry // z-index < 0, by z-index
+	positioned []layerEntry // positioned or transformed with z-index 0, in tree order
+	positive   []layerEntry // z-index > 0, by z-index
+}
+
+// layerEntry is a hoisted node with the ancestors, below the stacking
+// context root, whose overflow clip still applies to it
+type layerEntry struct {
+	node  *layout.Node
+	depth int
+	clips []clipAncestor
+}
+
+type clipAncestor struct {
+	node  *layout.Node
+	depth int
+}
+
+func (l *stackingLayers) empty() bool {
+	return l == nil || len(l.negative)+len(l.positioned)+len(l.positive) == 0
+}
+
+// isPositioned reports whether a node is positioned (position other than static)
+func isPositioned(node *layout.Node) bool {
+	return node.Style.Position != layout.PositionStatic
+}
+
+// createsStackingContext reports whether a node starts a stacking context.
+// layout.Style has no "auto" z-index, so positioned nodes with z-index 0
+// are treated as z-index auto.
+func createsStackingContext(node *layout.Node) bool {
+	switch node.Style.Position {
+	case layout.PositionFixed, layout.PositionSticky:
+		return true
+	case layout.PositionRelative, layout.PositionAbsolute:
+		if node.Style.ZIndex != 0 {
+			return true
+		}
+	}
+	return GetTransformFromNode(node) != ""
+}
+
+// collectLayers finds the descendants that the stacking context rooted at
+// root paints out of tree order and marks them hoisted. It returns nil if
+// root does not start a stacking context or DocumentOrder is set.
+func (r *Renderer) collectLayers(root *layout.Node, depth int) *stackingLayers {
+	if r.options.DocumentOrder || (depth > 0 && !createsStackingContext(root)) {
+		return nil
+	}
+
+	layers := &stackingLayers{}
+	var walk func(node *layout.Node, depth int, clips []clipAncestor)
+	walk = func(node *layout.Node, depth int, clips []clipAncestor) {
+		if r.boxStyle(node, depth).Overflow.clips() {
+			clips = append(clips[:len(clips):len(clips)], clipAncestor{node, depth})
+		}
+		for _, child := range node.Children {
+			if child == nil {
+				continue
+			}
+			entry := layerEntry{node: child, depth: depth + 1, clips: clips}
+			switch {
+			case createsStackingContext(child):
+				r.hoisted[child] = true
+				switch z := child.Style.ZIndex; {
+				case z < 0:
+					layers.negative = append(layers.negative, entry)
+				case z > 0:
+					layers.positive = append(layers.positive, entry)
+				default:
+					layers.positioned = append(layers.positioned, entry)
+				}
+				// Its descendants belong to its own stacking context
+				continue
+			case isPositioned(child):
+				// Painted as a layer of its own, but its positioned
+				// descendants stay in this stacking context
+				r.hoisted[child] = true
+				layers.positioned = append(layers.positioned, entry)
+				walk(child, depth+1, clips)
+				continue
+			}
+			walk(child, depth+1, clips)
+		}
+	}
+	walk(root, depth, nil)
+
+	byZ := func(entries []layerEntry) {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].node.Style.ZIndex < entries[j].node.Style.ZIndex
+		})
+	}
+	byZ(layers.negative)
+	byZ(layers.positive)
+	return layers
+}
+
+// paintedChildren returns the children of node that are painted in tree
+// order, leaving out the ones hoisted into a stacking context layer
+func (r *Renderer) paintedChildren(node *layout.Node) []*layout.Node {
+	if len(r.hoisted) == 0 {
+		return node.Children
+	}
+	children := make([]*layout.Node, 0, len(node.Children))
+	for _, child := range node.Children {
+		if !r.hoisted[child] {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// layerClipIDs returns the clipPath IDs of the overflow clips that apply to
+// a hoisted entry, outermost first
+func (r *Renderer) layerClipIDs(entry layerEntry) []string {
+	ids := make([]string, 0, len(entry.clips))
+	for _, a := range entry.clips {
+		style := r.nodeStyle(a.node, a.depth)
+		if id := r.overflowClip(a.node, r.boxStyle(a.node, a.depth), style.NumberFormat); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
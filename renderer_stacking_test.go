@@ -0,0 +1,174 @@
+package svg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/SCKelemen/layout"
+)
+
+// stackNode returns a 10x10 node with a position and z-index
+func stackNode(position layout.Position, z int, children ...*layout.Node) *layout.Node {
+	return &layout.Node{
+		Rect:     layout.Rect{Width: 10, Height: 10},
+		Style:    layout.Style{Position: position, ZIndex: z},
+		Children: children,
+	}
+}
+
+// stackOptions fills every node with its name, so that paintOrder can find it
+func stackOptions(names map[*layout.Node]string) Options {
+	opts := DefaultOptions()
+	opts.StyleSheet = nil
+	opts.StyleNodeFunc = func(node *layout.Node, depth int) Style {
+		return Style{Fill: names[node]}
+	}
+	return opts
+}
+
+// paintOrder returns the fills in the order they appear in svg
+func paintOrder(svg string, names map[*layout.Node]string) []string {
+	var order []string
+	for _, field := range strings.Split(svg, "<rect ") {
+		for _, name := range names {
+			if name != "" && strings.Contains(field, `fill="`+name+`"`) {
+				order = append(order, name)
+			}
+		}
+	}
+	return order
+}
+
+func documentOrder(doc *Document, names map[*layout.Node]string) []string {
+	var b strings.Builder
+	doc.WriteTo(&b)
+	return paintOrder(b.String(), names)
+}
+
+func TestRenderStacking_ZIndexOrder(t *testing.T) {
+	above := stackNode(layout.PositionRelative, 2)
+	below := stackNode(layout.PositionRelative, -1)
+	flow := stackNode(layout.PositionStatic, 0)
+	top := stackNode(layout.PositionAbsolute, 5)
+	root := &layout.Node{Children: []*layout.Node{above, top, below, flow}}
+	names := map[*layout.Node]string{above: "above", below: "below", flow: "flow", top: "top"}
+
+	want := "below flow above top"
+	if got := strings.Join(paintOrder(RenderToSVG(root, stackOptions(names)), names), " "); got != want {
+		t.Errorf("Render: expected %q, got %q", want, got)
+	}
+	if got := strings.Join(documentOrder(NewRenderer(stackOptions(names)).RenderDocument(root), names), " "); got != want {
+		t.Errorf("RenderDocument: expected %q, got %q", want, got)
+	}
+}
+
+func TestRenderStacking_PositionedAfterFlow(t *testing.T) {
+	// A positioned descendant of a static node paints after the static
+	// node's following siblings
+	positioned := stackNode(layout.PositionAbsolute, 0)
+	inner := stackNode(layout.PositionStatic, 0)
+	parent := stackNode(layout.PositionStatic, 0, positioned, inner)
+	sibling := stackNode(layout.PositionStatic, 0)
+	root := &layout.Node{Children: []*layout.Node{parent, sibling}}
+	names := map[*layout.Node]string{positioned: "positioned", inner: "inner", parent: "parent", sibling: "sibling"}
+
+	want := "parent inner sibling positioned"
+	if got := strings.Join(paintOrder(RenderToSVG(root, stackOptions(names)), names), " "); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestRenderStacking_NestedContexts(t *testing.T) {
+	// A z-index inside a stacking context only orders it within that context
+	inside := stackNode(layout.PositionRelative, 100)
+	first := stackNode(layout.PositionRelative, 1, inside)
+	second := stackNode(layout.PositionRelative, 2)
+	root := &layout.Node{Children: []*layout.Node{second, first}}
+	names := map[*layout.Node]string{inside: "inside", first: "first", second: "second"}
+
+	want := "first inside second"
+	if got := strings.Join(paintOrder(RenderToSVG(root, stackOptions(names)), names), " "); got != want {
+		t.Errorf("Render: expected %q, got %q", want, got)
+	}
+	if got := strings.Join(documentOrder(NewRenderer(stackOptions(names)).RenderDocument(root), names), " "); got != want {
+		t.Errorf("RenderDocument: expected %q, got %q", want, got)
+	}
+}
+
+func TestRenderStacking_DocumentOrder(t *testing.T) {
+	above := stackNode(layout.PositionRelative, 2)
+	below := stackNode(layout.PositionRelative, -1)
+	root := &layout.Node{Children: []*layout.Node{above, below}}
+	names := map[*layout.Node]string{above: "above", below: "below"}
+
+	opts := stackOptions(names)
+	opts.DocumentOrder = true
+	if got := strings.Join(paintOrder(RenderToSVG(root, opts), names), " "); got != "above below" {
+		t.Errorf("expected tree order, got %q", got)
+	}
+}
+
+func TestRenderStacking_TransformsStayApplied(t *testing.T) {
+	// A transformed node is a stacking context, so a raised child is painted
+	// inside the transformed group
+	raised := stackNode(layout.PositionRelative, 3)
+	transformed := stackNode(layout.PositionStatic, 0, raised)
+	transformed.Style.Transform = layout.Translate(50, 0)
+	sibling := stackNode(layout.PositionRelative, 1)
+	root := &layout.Node{Children: []*layout.Node{transformed, sibling}}
+	names := map[*layout.Node]string{raised: "raised", transformed: "transformed", sibling: "sibling"}
+
+	svg := RenderToSVG(root, stackOptions(names))
+	group := strings.Index(svg, `<g transform=`)
+	end := group + strings.Index(svg[group:], "</g>")
+	if r := strings.Index(svg, `fill="raised"`); group < 0 || r < group || r > end {
+		t.Errorf("expected the raised child inside the transform group:\n%s", svg)
+	}
+	if got := strings.Join(paintOrder(svg, names), " "); got != "transformed raised sibling" {
+		t.Errorf("expected the transformed context below its sibling, got %q", got)
+	}
+}
+
+func TestRenderStacking_HoistedNodesKeepClips(t *testing.T) {
+	raised := stackNode(layout.PositionRelative, 1)
+	clipper := stackNode(layout.PositionStatic, 0, raised)
+	root := &layout.Node{Children: []*layout.Node{clipper}}
+	names := map[*layout.Node]string{raised: "raised", clipper: "clipper"}
+
+	opts := stackOptions(names)
+	opts.BoxStyleFunc = func(node *layout.Node, depth int) BoxStyle {
+		if node == clipper {
+			return BoxStyle{Overflow: OverflowHidden}
+		}
+		return BoxStyle{}
+	}
+
+	svg := RenderToSVG(root, opts)
+	if got := strings.Count(svg, `<g clip-path="url(#`); got != 1 {
+		t.Fatalf("expected one clip group, got %d:\n%s", got, svg)
+	}
+	clip := strings.Index(svg, `<g clip-path=`)
+	if strings.Index(svg, `fill="raised"`) < clip || strings.Index(svg, `fill="clipper"`) > clip {
+		t.Errorf("expected the hoisted node inside the clip of its ancestor:\n%s", svg)
+	}
+
+	doc := NewRenderer(opts).RenderDocument(root)
+	groups := doc.Root.FindAll("g")
+	clipped := groups[len(groups)-1]
+	if v, _ := clipped.Attr("clip-path"); !strings.HasPrefix(v, "url(#clip-") {
+		t.Errorf("RenderDocument: expected a clip group around the hoisted node, got %s", clipped)
+	}
+}
+
+func TestRenderStacking_UnchangedWithoutPositioning(t *testing.T) {
+	a, b := stackNode(layout.PositionStatic, 0), stackNode(layout.PositionStatic, 0)
+	root := &layout.Node{Children: []*layout.Node{a, b}}
+	names := map[*layout.Node]string{a: "a", b: "b"}
+
+	opts := stackOptions(names)
+	stacked := RenderToSVG(root, opts)
+	opts.DocumentOrder = true
+	if plain := RenderToSVG(root, opts); stacked != plain {
+		t.Errorf("expected identical output:\n%s\n%s", stacked, plain)
+	}
+}
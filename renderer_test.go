@@ -1,6 +1,8 @@
 package svg
 
 import (
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 
@@ -167,6 +169,23 @@ func TestClipPathManager(t *testing.T) {
 	}
 }
 
+func TestClipPathManager_DeduplicatesGeometry(t *testing.T) {
+	manager := NewClipPathManager()
+	a := manager.AddRect(0, 0, 50, 50)
+	b := manager.AddRect(0, 0, 50, 50)
+	c := manager.AddRoundedRect(0, 0, 50, 50, 5)
+
+	if a != b {
+		t.Errorf("identical geometry should share an ID, got %s and %s", a, b)
+	}
+	if a == c {
+		t.Error("different geometry should get a new ID")
+	}
+	if got := strings.Count(manager.ToSVGDefs(), "<clipPath"); got != 2 {
+		t.Errorf("expected 2 clipPaths, got %d", got)
+	}
+}
+
 func TestElements(t *testing.T) {
 	style := Style{
 		Fill:   "#ff0000",
@@ -272,3 +291,256 @@ func TestRenderToSVG_WithTypedRenderNodeFunc(t *testing.T) {
 		t.Fatalf("expected default rect rendering to be bypassed, got: %s", out)
 	}
 }
+
+func TestRendererRenderTo_MatchesRender(t *testing.T) {
+	root := &layout.Node{
+		Rect: layout.Rect{X: 0, Y: 0, Width: 40, Height: 20},
+		Children: []*layout.Node{
+			{Rect: layout.Rect{X: 0, Y: 0, Width: 20, Height: 20}},
+			{Rect: layout.Rect{X: 20, Y: 0, Width: 20, Height: 20}},
+		},
+	}
+
+	opts := DefaultOptions()
+	opts.BackgroundColor = "#fff"
+
+	var b strings.Builder
+	if err := NewRenderer(opts).RenderTo(&b, root); err != nil {
+		t.Fatalf("RenderTo failed: %v", err)
+	}
+	if want := RenderToSVG(root, opts); b.String() != want {
+		t.Fatalf("RenderTo output differs from RenderToSVG:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestRendererRenderTo_EmitsClipPathsAddedWhileRendering(t *testing.T) {
+	root := &layout.Node{
+		Rect: layout.Rect{X: 0, Y: 0, Width: 20, Height: 20},
+	}
+
+	opts := DefaultOptions()
+	renderer := NewRenderer(opts)
+	early := renderer.GetClipPathManager().AddRect(0, 0, 5, 5)
+	var late string
+	opts.RenderNodeFunc = func(node *layout.Node, depth int) string {
+		late = renderer.GetClipPathManager().AddCircle(10, 10, 5)
+		return GroupWithClipPath(Rect(0, 0, 20, 20, Style{}), late, Style{})
+	}
+	renderer.options = opts
+
+	var b strings.Builder
+	if err := renderer.RenderTo(&b, root); err != nil {
+		t.Fatalf("RenderTo failed: %v", err)
+	}
+	out := b.String()
+
+	content := strings.Index(out, `clip-path="url(#`+late+`)"`)
+	if content < 0 {
+		t.Fatalf("expected clipped content, got: %s", out)
+	}
+	if i := strings.Index(out, `<clipPath id="`+early+`"`); i < 0 || i > content {
+		t.Fatalf("expected clipPath registered before rendering in leading defs, got: %s", out)
+	}
+	if i := strings.Index(out, `<clipPath id="`+late+`"`); i < content {
+		t.Fatalf("expected clipPath added while rendering after the content, got: %s", out)
+	}
+	if strings.Count(out, "<clipPath") != 2 {
+		t.Fatalf("expected each clipPath once, got: %s", out)
+	}
+}
+
+var errWriteFailed = errors.New("write failed")
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errWriteFailed
+}
+
+func TestRendererRenderTo_ReturnsWriteError(t *testing.T) {
+	root := &layout.Node{
+		Rect: layout.Rect{X: 0, Y: 0, Width: 20, Height: 20},
+	}
+
+	if err := NewRenderer(DefaultOptions()).RenderTo(failingWriter{}, root); err == nil {
+		t.Fatal("expected write error")
+	}
+}
+
+func TestRenderToSVG_TextNodes(t *testing.T) {
+	label := &layout.Node{
+		Text: "Hello world this is a long label",
+		Style: layout.Style{
+			Display: layout.DisplayInlineText,
+			Padding: layout.Spacing{Left: layout.Px(5), Top: layout.Px(3)},
+			TextStyle: &layout.TextStyle{
+				FontSize:   20,
+				FontFamily: "Arial",
+				FontWeight: layout.FontWeightBold,
+				FontStyle:  layout.FontStyleItalic,
+			},
+		},
+	}
+	root := &layout.Node{
+		Style: layout.Style{
+			Display: layout.DisplayBlock,
+			Width:   layout.Px(200),
+			Padding: layout.Spacing{Left: layout.Px(10), Top: layout.Px(10)},
+		},
+		Children: []*layout.Node{label},
+	}
+	layout.Layout(root, layout.Loose(800, 600), layout.NewLayoutContext(800, 600, 16))
+
+	if label.TextLayout == nil || len(label.TextLayout.Lines) < 2 {
+		t.Fatalf("expected the label to wrap, got %+v", label.TextLayout)
+	}
+
+	svg := RenderToSVG(root, DefaultOptions())
+
+	if !strings.Contains(svg, `<text fill="#333" font-family="Arial" font-size="20.00px" font-weight="bold" font-style="italic">`) {
+		t.Errorf("text element missing mapped typography:\n%s", svg)
+	}
+	if got := strings.Count(svg, "<tspan"); got != len(label.TextLayout.Lines) {
+		t.Errorf("expected one tspan per line (%d), got %d", len(label.TextLayout.Lines), got)
+	}
+	// Lines start at the content box (10+5, 10+3); the first baseline is the
+	// half-leading plus the ascent below the line top.
+	first := label.TextLayout.Lines[0]
+	ascent, descent := lineMetrics(first, 20)
+	baseline := 13 + (label.TextLayout.LineHeight-ascent-descent)/2 + ascent
+	want := fmt.Sprintf(`<tspan x="15.00" y="%.2f">%s</tspan>`, baseline, lineText(first))
+	if !strings.Contains(svg, want) {
+		t.Errorf("expected first line %s in:\n%s", want, svg)
+	}
+	// The text node is drawn as text, not as a box
+	if strings.Count(svg, "<rect") != 1 {
+		t.Errorf("expected only the container rect:\n%s", svg)
+	}
+
+	// The tree renderer emits the same text
+	doc := NewRenderer(DefaultOptions()).RenderDocument(root)
+	if texts := doc.Root.FindAll("tspan"); len(texts) != len(label.TextLayout.Lines) {
+		t.Errorf("RenderDocument: expected %d tspans, got %d", len(label.TextLayout.Lines), len(texts))
+	}
+}
+
+func TestRenderToSVG_TextNodeStyle(t *testing.T) {
+	node := &layout.Node{
+		Text:  "a < b",
+		Rect:  layout.Rect{X: 0, Y: 0, Width: 50, Height: 20},
+		Style: layout.Style{TextStyle: &layout.TextStyle{FontSize: 10, FontWeight: 600}},
+	}
+
+	opts := DefaultOptions()
+	opts.StyleNodeFunc = func(n *layout.Node, depth int) Style {
+		return Style{Fill: "navy", FontFamily: "serif"}
+	}
+	svg := RenderToSVG(node, opts)
+
+	// Without line boxes the text sits on an approximate first baseline
+	want := `<text fill="navy" font-family="serif" font-size="10.00px" font-weight="600"><tspan x="0.00" y="8.00">a &lt; b</tspan></text>`
+	if !strings.Contains(svg, want) {
+		t.Errorf("expected %s in:\n%s", want, svg)
+	}
+}
+
+func TestRenderNodes_MatchesRenderHeader(t *testing.T) {
+	opts := DefaultOptions()
+	opts.ViewBox = "0 0 10 10"
+	opts.PreserveAspectRatio = "none"
+	opts.IncludeXMLDeclaration = true
+	opts.BoxStyleFunc = func(*layout.Node, int) BoxStyle { return BoxStyle{Overflow: OverflowHidden} }
+
+	node := &layout.Node{
+		Rect:     layout.Rect{Width: 10, Height: 10},
+		Children: []*layout.Node{{Rect: layout.Rect{Width: 20, Height: 20}}},
+	}
+	svg := RenderNodes([]*layout.Node{node}, opts)
+
+	// A single node renders exactly like Render
+	if want := RenderToSVG(node, opts); svg != want {
+		t.Errorf("expected RenderNodes to match Render:\n%s\n%s", svg, want)
+	}
+	for _, want := range []string{`<?xml`, `viewBox="0 0 10 10"`, `preserveAspectRatio="none"`, `<clipPath id="clip-1">`} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("expected %s in:\n%s", want, svg)
+		}
+	}
+}
+
+func TestRenderRootsTo_MatchesDocument(t *testing.T) {
+	opts := DefaultOptions()
+	opts.StyleSheet = nil
+	opts.IncludeXMLDeclaration = true
+	opts.BackgroundColor = "white"
+	opts.BoxStyleFunc = func(*layout.Node, int) BoxStyle { return BoxStyle{Overflow: OverflowHidden} }
+
+	node := &layout.Node{
+		Rect: layout.Rect{Width: 10, Height: 10},
+		Children: []*layout.Node{
+			{Rect: layout.Rect{Width: 20, Height: 20}},
+			{Rect: layout.Rect{Width: 20, Height: 10}, Text: "a  b"},
+		},
+	}
+	roots := []LayoutRoot{{Node: node}, {Node: node, X: 10, ID: "copy"}}
+
+	// Once every definition is registered, the streamed output is the
+	// document, indented one element per line
+	renderer := NewRenderer(opts)
+	doc := renderer.RenderRootsDocument(roots...)
+	var b strings.Builder
+	if err := renderer.RenderRootsTo(&b, roots...); err != nil {
+		t.Fatalf("RenderRootsTo failed: %v", err)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(b.String(), "\n") {
+		lines = append(lines, strings.TrimLeft(line, " "))
+	}
+	if got := lines[0] + "\n" + strings.Join(lines[1:], ""); got != doc.String() {
+		t.Errorf("RenderRootsTo differs from RenderRootsDocument:\n%s\nwant:\n%s", b.String(), doc)
+	}
+	if strings.Count(b.String(), "<defs>") != 1 || !strings.Contains(b.String(), "\n  <defs>\n    <clipPath") {
+		t.Errorf("expected one indented <defs>:\n%s", b.String())
+	}
+	if !strings.Contains(b.String(), ">a  b</tspan></text>\n") {
+		t.Errorf("expected text kept inline:\n%s", b.String())
+	}
+}
+
+func TestRenderRoots_OffsetsAndIDs(t *testing.T) {
+	a := &layout.Node{Rect: layout.Rect{Width: 10, Height: 10}}
+	b := &layout.Node{Rect: layout.Rect{Width: 20, Height: 20}}
+	c := &layout.Node{Rect: layout.Rect{Width: 30, Height: 30}}
+	roots := []LayoutRoot{{Node: a}, {Node: b, X: 100, Y: 0.5, ID: "legend"}, {Node: c, ID: "axis"}}
+
+	opts := DefaultOptions()
+	opts.StyleSheet = nil
+	svg := NewRenderer(opts).RenderRoots(roots...)
+
+	// Roots are painted in order, offset roots inside a translated group
+	first := strings.Index(svg, `width="10.00"`)
+	legend := strings.Index(svg, `<g id="legend" transform="translate(100.00,0.50)">`)
+	axis := strings.Index(svg, `<g id="axis">`)
+	if first < 0 || legend < first || axis < legend || strings.Index(svg, `width="20.00"`) < legend {
+		t.Errorf("expected the roots in order with their groups:\n%s", svg)
+	}
+	if strings.Count(svg, "<g") != 2 {
+		t.Errorf("expected no group around the plain root:\n%s", svg)
+	}
+
+	doc := NewRenderer(opts).RenderRootsDocument(roots...)
+	g := doc.Root.FindByID("legend")
+	if g == nil || len(g.ChildElements()) != 1 || doc.Root.FindByID("axis") == nil {
+		t.Fatalf("RenderRootsDocument: expected the root groups, got %s", doc)
+	}
+	if v, _ := g.Attr("transform"); v != "translate(100.00,0.50)" {
+		t.Errorf("RenderRootsDocument: expected the offset, got %q", v)
+	}
+
+	// The offset follows the renderer's number format
+	opts.NumberFormat = TrimmedDecimals(3)
+	if svg := NewRenderer(opts).RenderRoots(roots...); !strings.Contains(svg, `transform="translate(100,0.5)"`) {
+		t.Errorf("expected the offset in the configured number format:\n%s", svg)
+	}
+}
@@ -0,0 +1,143 @@
+package svg
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/SCKelemen/layout"
+	"github.com/SCKelemen/units"
+)
+
+// isTextNode reports whether a layout node carries text content
+func isTextNode(node *layout.Node) bool {
+	return node.Text != ""
+}
+
+// textElement builds the <text> element for a text node. Each line box of the
+// node's TextLayout becomes a <tspan> positioned at the line's baseline, so
+// wrapping and alignment match the layout. Nodes that were not laid out as
+// text are written as a single line at the top of their content box.
+func (r *Renderer) textElement(node *layout.Node, style Style) *Element {
+	n := style.NumberFormat
+	text := NewElement("text").SetStyle(style)
+
+	x, y := r.contentOrigin(node)
+	fontSize := textFontSize(node.Style.TextStyle)
+
+	if node.TextLayout == nil || len(node.TextLayout.Lines) == 0 {
+		// Without line boxes, approximate the ascent of the first line.
+		line := NewElement("tspan", numAttr("x", x, n), numAttr("y", y+fontSize*0.8, n))
+		return text.AppendChild(line.AppendChild(&CharData{Data: node.Text}))
+	}
+
+	lineHeight := node.TextLayout.LineHeight
+	for _, line := range node.TextLayout.Lines {
+		ascent, descent := lineMetrics(line, fontSize)
+		// Half-leading places the glyphs in the middle of the line box
+		baseline := line.OffsetY + (lineHeight-ascent-descent)/2 + ascent
+
+		span := NewElement("tspan", numAttr("x", x+line.OffsetX, n), numAttr("y", y+baseline, n))
+		if line.SpaceAdjustment != 0 {
+			span.SetAttr("word-spacing", n.Format(line.SpaceAdjustment))
+		}
+		if line.CharacterAdjustment != 0 {
+			span.SetAttr("letter-spacing", n.Format(line.CharacterAdjustment))
+		}
+		text.AppendChild(span.AppendChild(&CharData{Data: lineText(line)}))
+	}
+	return text
+}
+
+// contentOrigin returns the top-left corner of a node's content box
+func (r *Renderer) contentOrigin(node *layout.Node) (float64, float64) {
+	paddingTop, _, _, paddingLeft := r.resolveSpacing(node, node.Style.Padding)
+	borderTop, _, _, borderLeft := r.resolveSpacing(node, node.Style.Border)
+	return node.Rect.X + paddingLeft + borderLeft, node.Rect.Y + paddingTop + borderTop
+}
+
+// layoutContext returns the context used to resolve layout lengths
+func (r *Renderer) layoutContext() *layout.LayoutContext {
+	if r.options.LayoutContext != nil {
+		return r.options.LayoutContext
+	}
+	return layout.NewLayoutContext(r.options.Width, r.options.Height, defaultFontSize)
+}
+
+// lineMetrics returns the largest ascent and descent of a line's boxes
+func lineMetrics(line layout.TextLine, fontSize float64) (ascent, descent float64) {
+	for _, box := range line.Boxes {
+		ascent = max(ascent, box.Ascent)
+		descent = max(descent, box.Descent)
+	}
+	if ascent == 0 && descent == 0 {
+		ascent, descent = fontSize*0.8, fontSize*0.2
+	}
+	return ascent, descent
+}
+
+// lineText joins the boxes of a line. Word boxes are separated by a space
+// unless the layout kept the whitespace as boxes of its own.
+func lineText(line layout.TextLine) string {
+	var b strings.Builder
+	for i, box := range line.Boxes {
+		if i > 0 && !endsWithSpace(line.Boxes[i-1].Text) && !startsWithSpace(box.Text) {
+			b.WriteString(" ")
+		}
+		b.WriteString(box.Text)
+	}
+	return b.String()
+}
+
+func startsWithSpace(s string) bool {
+	return s != "" && (s[0] == ' ' || s[0] == '\t')
+}
+
+func endsWithSpace(s string) bool {
+	return s != "" && (s[len(s)-1] == ' ' || s[len(s)-1] == '\t')
+}
+
+// textFontSize returns the font size layout used for a text style
+func textFontSize(ts *layout.TextStyle) float64 {
+	if ts == nil || ts.FontSize <= 0 {
+		return defaultFontSize
+	}
+	return ts.FontSize
+}
+
+// applyTextStyle maps the typography of a layout text style onto the font
+// fields of style that are not already set
+func applyTextStyle(style Style, ts *layout.TextStyle) Style {
+	if ts == nil {
+		return style
+	}
+	if style.FontFamily == "" {
+		style.FontFamily = ts.FontFamily
+	}
+	if style.FontSize.Value == 0 && ts.FontSize > 0 {
+		style.FontSize = units.Px(ts.FontSize)
+	}
+	if style.FontWeight == "" {
+		style.FontWeight = fontWeight(ts.FontWeight)
+	}
+	if style.FontStyle == "" {
+		switch ts.FontStyle {
+		case layout.FontStyleItalic:
+			style.FontStyle = FontStyleItalic
+		case layout.FontStyleOblique:
+			style.FontStyle = FontStyleOblique
+		}
+	}
+	return style
+}
+
+// fontWeight converts a numeric layout font weight to an SVG font-weight.
+// The default weight is left unset.
+func fontWeight(w layout.FontWeight) FontWeight {
+	switch w {
+	case 0, layout.FontWeightNormal:
+		return ""
+	case layout.FontWeightBold:
+		return FontWeightBold
+	}
+	return FontWeight(strconv.Itoa(int(w)))
+}